@@ -0,0 +1,169 @@
+// Package apperror berisi error API dengan kode yang stabil. Handler tidak
+// menulis respons error sendiri, tetapi memanggil Abort; middleware
+// ErrorHandler lalu mengirim body RFC 7807 (application/problem+json) dengan
+// pesan dalam bahasa dari header Accept-Language. Penyebab asli (Err) hanya
+// dicatat di log dan tidak pernah dikirim ke klien.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code adalah kode error yang bisa dibaca mesin. Nilainya bagian dari kontrak
+// API dan tidak boleh diubah.
+type Code string
+
+const (
+	// Umum
+	CodeInternal           Code = "INTERNAL_ERROR"
+	CodeRouteNotFound      Code = "ROUTE_NOT_FOUND"
+	CodeInvalidRequestBody Code = "INVALID_REQUEST_BODY"
+	CodeValidationFailed   Code = "VALIDATION_FAILED"
+	CodeInvalidID          Code = "INVALID_ID"
+
+	// Autentikasi dan otorisasi
+	CodeAuthRequired        Code = "AUTH_REQUIRED"
+	CodeTokenInvalid        Code = "TOKEN_INVALID"
+	CodeTokenRevoked        Code = "TOKEN_REVOKED"
+	CodePermissionDenied    Code = "PERMISSION_DENIED"
+	CodeInvalidCredentials  Code = "INVALID_CREDENTIALS"
+	CodeLoginLocked         Code = "LOGIN_LOCKED"
+	CodeEmailNotVerified    Code = "EMAIL_NOT_VERIFIED"
+	CodeEmailTaken          Code = "EMAIL_TAKEN"
+	CodeRefreshTokenInvalid Code = "REFRESH_TOKEN_INVALID"
+	CodeRefreshTokenReused  Code = "REFRESH_TOKEN_REUSED"
+	CodeOneTimeTokenInvalid Code = "ONE_TIME_TOKEN_INVALID"
+	CodeInvitationInvalid   Code = "INVITATION_INVALID"
+
+	// Two-factor authentication
+	CodeMFATokenInvalid    Code = "MFA_TOKEN_INVALID"
+	CodeMFACodeInvalid     Code = "MFA_CODE_INVALID"
+	CodeMFAAlreadyEnabled  Code = "MFA_ALREADY_ENABLED"
+	CodeMFANotEnabled      Code = "MFA_NOT_ENABLED"
+	CodeMFANotStarted      Code = "MFA_ENROLLMENT_NOT_STARTED"
+	CodeMFARequiredForRole Code = "MFA_REQUIRED_FOR_ROLE"
+
+	// User, role dan percakapan chatbot
+	CodeNothingToUpdate      Code = "NOTHING_TO_UPDATE"
+	CodeUserNotFound         Code = "USER_NOT_FOUND"
+	CodeRoleNotFound         Code = "ROLE_NOT_FOUND"
+	CodeRoleExists           Code = "ROLE_EXISTS"
+	CodeRoleNameInvalid      Code = "ROLE_NAME_INVALID"
+	CodePermissionUnknown    Code = "PERMISSION_UNKNOWN"
+	CodeRoleBuiltIn          Code = "ROLE_BUILT_IN"
+	CodeRoleInUse            Code = "ROLE_IN_USE"
+	CodeLastAdmin            Code = "LAST_ADMIN"
+	CodeConversationNotFound Code = "CONVERSATION_NOT_FOUND"
+
+	// Katalog, keranjang dan pesanan
+	CodeProductNotFound   Code = "PRODUCT_NOT_FOUND"
+	CodeInsufficientStock Code = "INSUFFICIENT_STOCK"
+	CodeCartEmpty         Code = "CART_EMPTY"
+	CodeCartItemNotFound  Code = "CART_ITEM_NOT_FOUND"
+	CodeCheckoutConflict  Code = "CHECKOUT_CONFLICT"
+	CodeOrderNotFound     Code = "ORDER_NOT_FOUND"
+)
+
+// Error adalah error API. Params mengisi placeholder di pesan detail, misalnya
+// {product}, dan Fields berisi detail validasi per field.
+type Error struct {
+	Code   Code
+	Params map[string]string
+	Fields []FieldError
+	// Err adalah penyebab asli untuk log
+	Err error
+	// HTTPStatus mengganti status bawaan kode, nol berarti memakai status
+	// dari katalog. Hanya untuk rute yang harus mempertahankan status lama.
+	HTTPStatus int
+}
+
+// FieldError menjelaskan satu field yang tidak valid. Rule mengikuti nama tag
+// validator (required, email, min, ...), Param adalah argumennya.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// New membuat error dengan kode tertentu
+func New(code Code) *Error {
+	return &Error{Code: code}
+}
+
+// Wrap membuat error dengan kode tertentu dan menyimpan penyebabnya untuk log
+func Wrap(code Code, err error) *Error {
+	return &Error{Code: code, Err: err}
+}
+
+// Internal membungkus error tak terduga menjadi INTERNAL_ERROR (500)
+func Internal(err error) *Error {
+	return Wrap(CodeInternal, err)
+}
+
+// InvalidID dipakai untuk ObjectID yang tidak valid di path, query atau body
+func InvalidID(field string) *Error {
+	return &Error{Code: CodeInvalidID, Fields: []FieldError{{Field: field, Rule: "objectid"}}}
+}
+
+// Validation membuat VALIDATION_FAILED dengan detail per field
+func Validation(fields ...FieldError) *Error {
+	return &Error{Code: CodeValidationFailed, Fields: fields}
+}
+
+// With menambahkan parameter untuk pesan detail
+func (e *Error) With(key, value string) *Error {
+	if e.Params == nil {
+		e.Params = make(map[string]string)
+	}
+	e.Params[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Err.Error()
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithStatus mengganti status HTTP tanpa mengubah kode error
+func (e *Error) WithStatus(status int) *Error {
+	e.HTTPStatus = status
+	return e
+}
+
+// Status mengembalikan status HTTP untuk kode error ini
+func (e *Error) Status() int {
+	if e.HTTPStatus != 0 {
+		return e.HTTPStatus
+	}
+	if entry, ok := catalog[e.Code]; ok {
+		return entry.status
+	}
+	return http.StatusInternalServerError
+}
+
+// From mengubah error apa pun menjadi *Error. Error yang tidak dikenal
+// dianggap INTERNAL_ERROR.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
+
+// Abort mendaftarkan error ke context Gin dan menghentikan handler berikutnya.
+// Respons ditulis oleh middleware ErrorHandler.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,121 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   Lang
+	}{
+		{"", LangID},
+		{"en", LangEN},
+		{"en-US,en;q=0.9", LangEN},
+		{"id-ID,id;q=0.9,en;q=0.8", LangID},
+		{"en;q=0.5,id;q=0.8", LangID},
+		{"fr-FR,en;q=0.7", LangEN},
+		{"fr,de", LangID},
+		{"en;q=abc", LangID},
+	} {
+		if got := Language(tc.header); got != tc.want {
+			t.Errorf("Language(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestBindingReportsFieldsWithoutValidatorMessages(t *testing.T) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8"`
+		Quantity int    `json:"quantity" binding:"gt=0"`
+	}
+	err := binding.JSON.BindBody([]byte(`{"email":"bukan-email","password":"abc"}`), &req)
+	appErr := Binding(err)
+	if appErr.Code != CodeValidationFailed || appErr.Status() != http.StatusBadRequest {
+		t.Fatalf("unexpected error %v", appErr)
+	}
+
+	problem := appErr.Problem(LangEN)
+	want := map[string]string{
+		"email":    "email must be a valid email address",
+		"password": "password must be at least 8 characters long",
+		"quantity": "quantity must be greater than 0",
+	}
+	if len(problem.Errors) != len(want) {
+		t.Fatalf("got fields %+v", problem.Errors)
+	}
+	for _, field := range problem.Errors {
+		if want[field.Field] != field.Message {
+			t.Errorf("field %s: got %q, want %q", field.Field, field.Message, want[field.Field])
+		}
+	}
+	body, _ := json.Marshal(problem)
+	if strings.Contains(string(body), "Key:") || strings.Contains(string(body), "Field validation") {
+		t.Fatalf("validator message leaked: %s", body)
+	}
+}
+
+func TestBindingTypeAndSyntaxErrors(t *testing.T) {
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+	err := binding.JSON.BindBody([]byte(`{"quantity":"dua"}`), &req)
+	problem := Binding(err).Problem(LangID)
+	if problem.Code != CodeValidationFailed || len(problem.Errors) != 1 || problem.Errors[0].Message != "quantity bertipe int" {
+		t.Fatalf("type error: got %+v", problem)
+	}
+
+	err = binding.JSON.BindBody([]byte(`{"quantity":`), &req)
+	problem = Binding(err).Problem(LangEN)
+	if problem.Code != CodeInvalidRequestBody || problem.Detail != "" {
+		t.Fatalf("syntax error: got %+v", problem)
+	}
+}
+
+func TestProblemLocalizesDetailAndHidesCause(t *testing.T) {
+	err := Wrap(CodeInsufficientStock, errors.New("mongo: write conflict")).With("product", "Topi")
+	en := err.Problem(LangEN)
+	if en.Status != http.StatusBadRequest || en.Title != "Insufficient stock" || en.Detail != "Not enough stock for Topi" {
+		t.Fatalf("en: got %+v", en)
+	}
+	if id := err.Problem(LangID); id.Detail != "Stok untuk produk Topi tidak mencukupi" {
+		t.Fatalf("id: got %+v", id)
+	}
+	if en.Type != "urn:tokobiru:error:INSUFFICIENT_STOCK" {
+		t.Fatalf("type: got %q", en.Type)
+	}
+	// WithStatus hanya mengganti status, kodenya tetap sama
+	if moved := New(CodeInsufficientStock).WithStatus(http.StatusNotFound); moved.Status() != http.StatusNotFound || moved.Problem(LangEN).Status != http.StatusNotFound {
+		t.Fatalf("status override: got %d", moved.Status())
+	}
+
+	internal := From(fmt.Errorf("wrapped: %w", errors.New("connection refused"))).Problem(LangEN)
+	if internal.Code != CodeInternal || internal.Status != http.StatusInternalServerError || internal.Detail != "" {
+		t.Fatalf("internal: got %+v", internal)
+	}
+
+	// Error bertipe *Error yang dibungkus tetap dikenali
+	wrapped := From(fmt.Errorf("checkout: %w", New(CodeCartEmpty)))
+	if wrapped.Code != CodeCartEmpty {
+		t.Fatalf("wrapped: got %v", wrapped)
+	}
+}
+
+func TestCatalogIsComplete(t *testing.T) {
+	for code, definition := range catalog {
+		if definition.status < 400 || definition.title.id == "" || definition.title.en == "" {
+			t.Errorf("%s: incomplete definition %+v", code, definition)
+		}
+		if (definition.detail.id == "") != (definition.detail.en == "") {
+			t.Errorf("%s: detail must exist in both languages", code)
+		}
+	}
+}
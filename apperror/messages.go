@@ -0,0 +1,140 @@
+package apperror
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Lang adalah bahasa pesan error
+type Lang string
+
+const (
+	LangID Lang = "id"
+	LangEN Lang = "en"
+
+	// DefaultLang dipakai jika Accept-Language kosong atau tidak didukung
+	DefaultLang = LangID
+)
+
+// text adalah satu pesan dalam semua bahasa yang didukung
+type text struct {
+	id, en string
+}
+
+func (t text) in(lang Lang) string {
+	if lang == LangEN {
+		return t.en
+	}
+	return t.id
+}
+
+// entry mendefinisikan status HTTP, judul dan (opsional) detail sebuah kode.
+// Detail boleh berisi placeholder {nama} yang diisi dari Error.Params; jika
+// parameternya tidak ada, detail tidak dikirim.
+type entry struct {
+	status int
+	title  text
+	detail text
+}
+
+var catalog = map[Code]entry{
+	CodeInternal:           {http.StatusInternalServerError, text{"Terjadi kesalahan pada server", "Internal server error"}, text{}},
+	CodeRouteNotFound:      {http.StatusNotFound, text{"Endpoint tidak ditemukan", "Endpoint not found"}, text{}},
+	CodeInvalidRequestBody: {http.StatusBadRequest, text{"Body request tidak bisa dibaca", "Request body could not be parsed"}, text{}},
+	CodeValidationFailed:   {http.StatusBadRequest, text{"Data yang dikirim tidak valid", "Request contains invalid fields"}, text{}},
+	CodeInvalidID:          {http.StatusBadRequest, text{"ID tidak valid", "Invalid ID"}, text{}},
+
+	CodeAuthRequired:        {http.StatusUnauthorized, text{"Silakan login terlebih dahulu", "Authentication is required"}, text{}},
+	CodeTokenInvalid:        {http.StatusUnauthorized, text{"Token tidak valid atau sudah kedaluwarsa", "Invalid or expired token"}, text{}},
+	CodeTokenRevoked:        {http.StatusUnauthorized, text{"Token sudah dicabut", "Token has been revoked"}, text{}},
+	CodePermissionDenied:    {http.StatusForbidden, text{"Anda tidak memiliki akses ke resource ini", "You do not have permission to access this resource"}, text{}},
+	CodeInvalidCredentials:  {http.StatusUnauthorized, text{"Email atau password salah", "Invalid email or password"}, text{}},
+	CodeLoginLocked:         {http.StatusTooManyRequests, text{"Terlalu banyak percobaan login gagal, coba lagi nanti", "Too many failed login attempts, try again later"}, text{}},
+	CodeEmailNotVerified:    {http.StatusForbidden, text{"Alamat email belum diverifikasi", "Email address is not verified"}, text{}},
+	CodeEmailTaken:          {http.StatusConflict, text{"Email sudah terdaftar", "Email already registered"}, text{}},
+	CodeRefreshTokenInvalid: {http.StatusUnauthorized, text{"Refresh token tidak valid atau sudah kedaluwarsa", "Invalid or expired refresh token"}, text{}},
+	CodeRefreshTokenReused:  {http.StatusUnauthorized, text{"Refresh token sudah pernah dipakai, silakan login ulang", "Refresh token reuse detected, please log in again"}, text{}},
+	CodeOneTimeTokenInvalid: {http.StatusBadRequest, text{"Token tidak valid, kedaluwarsa atau sudah dipakai", "Invalid, expired or already used token"}, text{}},
+	CodeInvitationInvalid:   {http.StatusBadRequest, text{"Undangan tidak valid, kedaluwarsa atau sudah dipakai", "Invalid, expired or already used invitation"}, text{}},
+
+	CodeMFATokenInvalid:    {http.StatusUnauthorized, text{"Token MFA tidak valid atau sudah kedaluwarsa", "Invalid or expired MFA token"}, text{}},
+	CodeMFACodeInvalid:     {http.StatusUnauthorized, text{"Kode verifikasi salah", "Invalid verification code"}, text{}},
+	CodeMFAAlreadyEnabled:  {http.StatusConflict, text{"Two-factor authentication sudah aktif", "Two-factor authentication is already enabled"}, text{}},
+	CodeMFANotEnabled:      {http.StatusBadRequest, text{"Two-factor authentication belum aktif", "Two-factor authentication is not enabled"}, text{}},
+	CodeMFANotStarted:      {http.StatusBadRequest, text{"Enrollment two-factor belum dimulai", "Two-factor enrollment has not been started"}, text{}},
+	CodeMFARequiredForRole: {http.StatusConflict, text{"Two-factor authentication wajib untuk role ini", "Two-factor authentication is required for this role"}, text{}},
+
+	CodeNothingToUpdate:      {http.StatusBadRequest, text{"Tidak ada data yang diubah", "No fields to update provided"}, text{}},
+	CodeUserNotFound:         {http.StatusNotFound, text{"User tidak ditemukan", "User not found"}, text{}},
+	CodeRoleNotFound:         {http.StatusNotFound, text{"Role atau user tidak ditemukan", "Role or user not found"}, text{}},
+	CodeRoleExists:           {http.StatusConflict, text{"Role sudah ada", "Role already exists"}, text{}},
+	CodeRoleNameInvalid:      {http.StatusBadRequest, text{"Nama role harus 2-32 huruf kecil, angka, '-' atau '_'", "Role name must be 2-32 lowercase letters, digits, '-' or '_'"}, text{}},
+	CodePermissionUnknown:    {http.StatusBadRequest, text{"Permission tidak dikenal", "Unknown permission"}, text{}},
+	CodeRoleBuiltIn:          {http.StatusConflict, text{"Role bawaan tidak bisa diubah", "Built-in role cannot be modified"}, text{}},
+	CodeRoleInUse:            {http.StatusConflict, text{"Role masih dipakai oleh user", "Role is still assigned to users"}, text{}},
+	CodeLastAdmin:            {http.StatusConflict, text{"Admin terakhir tidak bisa dihapus", "Cannot remove the last admin"}, text{}},
+	CodeConversationNotFound: {http.StatusNotFound, text{"Percakapan tidak ditemukan", "Conversation not found"}, text{}},
+
+	CodeProductNotFound: {http.StatusNotFound, text{"Produk tidak ditemukan", "Product not found"},
+		text{"Produk dengan ID {id} tidak ditemukan", "Product with ID {id} does not exist"}},
+	CodeInsufficientStock: {http.StatusBadRequest, text{"Stok tidak mencukupi", "Insufficient stock"},
+		text{"Stok untuk produk {product} tidak mencukupi", "Not enough stock for {product}"}},
+	CodeCartEmpty:        {http.StatusBadRequest, text{"Keranjang kosong atau tidak ditemukan", "Cart is empty or does not exist"}, text{}},
+	CodeCartItemNotFound: {http.StatusNotFound, text{"Produk tidak ada di keranjang", "Item not found in cart"}, text{}},
+	CodeCheckoutConflict: {http.StatusConflict, text{"Keranjang sedang diproses, silakan coba lagi", "Cart is being checked out, please try again"}, text{}},
+	CodeOrderNotFound:    {http.StatusNotFound, text{"Pesanan tidak ditemukan", "Order not found"}, text{}},
+}
+
+// ruleMessages adalah pesan untuk setiap aturan validasi field. Nama field
+// ditambahkan di depan, misalnya "email wajib diisi".
+var ruleMessages = map[string]text{
+	"required": {"wajib diisi", "is required"},
+	"email":    {"harus berupa alamat email yang valid", "must be a valid email address"},
+	"min":      {"minimal {param}", "must be at least {param}"},
+	"max":      {"maksimal {param}", "must be at most {param}"},
+	"len":      {"harus berukuran {param}", "must have length {param}"},
+	"gt":       {"harus lebih dari {param}", "must be greater than {param}"},
+	"gte":      {"minimal {param}", "must be at least {param}"},
+	"lt":       {"harus kurang dari {param}", "must be less than {param}"},
+	"lte":      {"maksimal {param}", "must be at most {param}"},
+	"oneof":    {"harus salah satu dari: {param}", "must be one of: {param}"},
+	"objectid": {"bukan ID yang valid", "is not a valid ID"},
+	"date":     {"harus berformat YYYY-MM-DD", "must be formatted as YYYY-MM-DD"},
+	"currency": {"harus dalam mata uang {param}", "must be in {param}"},
+	"type":     {"bertipe {param}", "must be of type {param}"},
+	"min_len":  {"minimal {param} karakter", "must be at least {param} characters long"},
+	"max_len":  {"maksimal {param} karakter", "must be at most {param} characters long"},
+	"invalid":  {"tidak valid", "is invalid"},
+}
+
+// Language memilih bahasa dari header Accept-Language dengan memperhatikan
+// nilai q, misalnya "en-US,en;q=0.9,id;q=0.8" menghasilkan LangEN
+func Language(header string) Lang {
+	best, bestQ := DefaultLang, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		lang := Lang(base)
+		if (lang == LangID || lang == LangEN) && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// fill mengganti placeholder {nama} dengan nilai dari params
+func fill(template string, params map[string]string) string {
+	for key, value := range params {
+		template = strings.ReplaceAll(template, "{"+key+"}", value)
+	}
+	return template
+}
@@ -0,0 +1,122 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"tokobiru/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ContentType adalah media type body error (RFC 7807)
+const ContentType = "application/problem+json"
+
+// typePrefix membentuk URI "type" problem dari kode error
+const typePrefix = "urn:tokobiru:error:"
+
+// Problem adalah body error RFC 7807. Selain anggota standar, code berisi
+// kode yang stabil, requestId sama dengan header X-Request-ID, dan errors
+// berisi detail per field untuk VALIDATION_FAILED dan INVALID_ID.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func init() {
+	// Nama field di detail validasi mengikuti tag json, bukan nama field Go
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// Binding mengubah error dari c.ShouldBindJSON menjadi VALIDATION_FAILED
+// (dengan detail per field) atau INVALID_REQUEST_BODY. Pesan asli dari
+// validator atau decoder JSON tidak dikirim ke klien.
+func Binding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			rule := fe.Tag()
+			if (rule == "min" || rule == "max") && fe.Kind() == reflect.String {
+				rule += "_len"
+			}
+			fields = append(fields, FieldError{Field: fieldPath(fe.Namespace()), Rule: rule, Param: fe.Param()})
+		}
+		return &Error{Code: CodeValidationFailed, Fields: fields, Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &Error{Code: CodeValidationFailed, Fields: []FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.Kind().String()}}, Err: err}
+	}
+	return Wrap(CodeInvalidRequestBody, err)
+}
+
+// fieldPath membuang nama struct di depan namespace validator, misalnya
+// "User.email" menjadi "email"
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+// Problem membentuk body RFC 7807 dalam bahasa lang. Params yang tidak
+// dipakai di template detail diabaikan.
+func (e *Error) Problem(lang Lang) Problem {
+	code := e.Code
+	definition, ok := catalog[code]
+	if !ok {
+		code, definition = CodeInternal, catalog[CodeInternal]
+	}
+	problem := Problem{
+		Type:   typePrefix + string(code),
+		Title:  definition.title.in(lang),
+		Status: definition.status,
+		Code:   code,
+	}
+	if e.HTTPStatus != 0 && ok {
+		problem.Status = e.HTTPStatus
+	}
+	if detail := definition.detail.in(lang); detail != "" && len(e.Params) > 0 {
+		problem.Detail = fill(detail, e.Params)
+	}
+	for _, field := range e.Fields {
+		message, ok := ruleMessages[field.Rule]
+		if !ok {
+			message = ruleMessages["invalid"]
+		}
+		field.Message = field.Field + " " + fill(message.in(lang), map[string]string{"param": field.Param})
+		problem.Errors = append(problem.Errors, field)
+	}
+	return problem
+}
+
+// ProblemFor membentuk body error untuk request yang sedang berjalan: bahasa
+// dari Accept-Language, instance dari path dan requestId dari context
+func ProblemFor(c *gin.Context, err error) Problem {
+	problem := From(err).Problem(Language(c.GetHeader("Accept-Language")))
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = logging.RequestID(c.Request.Context())
+	return problem
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// passwordEnv adalah variabel environment untuk password pada perintah user.
+// Jika kosong, password dibaca dari baris pertama stdin agar tidak muncul di
+// riwayat shell atau daftar proses.
+const passwordEnv = "TOKOBIRU_PASSWORD"
+
+const minPasswordLength = 8
+
+// parseFlags mem-parse flag perintah yang tidak menerima argumen posisi
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// readPassword membaca password dari passwordEnv atau baris pertama stdin
+func (a *app) readPassword() (string, error) {
+	password := a.getenv(passwordEnv)
+	if password == "" {
+		line, err := bufio.NewReader(a.in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters (set %s or pipe it on stdin)", minPasswordLength, passwordEnv)
+	}
+	return password, nil
+}
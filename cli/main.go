@@ -0,0 +1,126 @@
+// tokobiru adalah CLI admin untuk operator. CLI ini memakai konfigurasi dan
+// repository yang sama dengan server, sehingga pekerjaan rutin seperti
+// migrasi, membuat admin atau mengekspor order tidak perlu dilakukan dengan
+// mengubah MongoDB secara manual.
+//
+// Penggunaan:
+//
+//	./tokobiru <command> [flags]
+//	./tokobiru help
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"tokobiru/config"
+	"tokobiru/database"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// app berisi dependensi bersama untuk semua perintah
+type app struct {
+	cfg   config.Config
+	store *repositories.Store
+	// db hanya terisi jika terhubung ke MongoDB; dibutuhkan oleh migrate
+	db     *mongo.Database
+	in     io.Reader
+	out    io.Writer
+	getenv func(string) string
+}
+
+// command adalah satu subcommand. Nama bisa terdiri dari dua kata, misalnya
+// "user create-admin".
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "status | up | down [--steps N]", "Tampilkan, terapkan atau batalkan migrasi database", runMigrate},
+	{"seed", "[--fixtures file.json]", "Muat user dan produk dari file fixture (default: data demo)", runSeed},
+	{"user create-admin", "--email <email> [--name <name>]", "Buat admin pertama; password dari " + passwordEnv + " atau stdin", runCreateAdmin},
+	{"user reset-password", "--email <email>", "Ganti password user dan cabut semua sessionnya", runResetPassword},
+	{"product import", "--file <products.json|products.csv> [--dry-run]", "Tambah atau perbarui produk berdasarkan nama", runProductImport},
+	{"order export", "[--from YYYY-MM-DD] [--to YYYY-MM-DD] [--status s] [--format csv|json] [--output file]", "Ekspor order ke CSV atau JSON", runOrderExport},
+	{"reindex", "", "Hitung ulang embedding semua produk untuk retrieval chatbot", runReindex},
+}
+
+// errUsage dikembalikan perintah jika argumennya tidak valid
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	log.SetFlags(0)
+	cmd, args, ok := findCommand(os.Args[1:])
+	if !ok {
+		usage(os.Stderr)
+		if len(os.Args) > 1 && os.Args[1] != "help" && os.Args[1] != "-h" && os.Args[1] != "--help" {
+			os.Exit(2)
+		}
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Could not load config: %v", err)
+	}
+	database.ConnectDB(cfg.MongoURI, cfg.MongoDatabase)
+	a := &app{
+		cfg:    cfg,
+		store:  repositories.NewMongoStore(database.DB),
+		db:     database.DB,
+		in:     os.Stdin,
+		out:    os.Stdout,
+		getenv: os.Getenv,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := cmd.run(ctx, a, args); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "Usage: tokobiru %s %s\n", cmd.name, cmd.args)
+			os.Exit(2)
+		}
+		log.Fatalf("%s: %v", cmd.name, err)
+	}
+}
+
+// findCommand mencocokkan argumen dengan nama perintah terpanjang yang ada
+func findCommand(args []string) (command, []string, bool) {
+	for _, words := range []int{2, 1} {
+		if len(args) < words {
+			continue
+		}
+		name := strings.Join(args[:words], " ")
+		for _, cmd := range commands {
+			if cmd.name == name {
+				return cmd, args[words:], true
+			}
+		}
+	}
+	return command{}, nil, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: tokobiru <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-20s %s\n", cmd.name, cmd.summary)
+		if cmd.args != "" {
+			fmt.Fprintf(w, "  %-20s   %s\n", "", cmd.args)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Konfigurasi (MONGO_URI, MONGO_DATABASE, ...) dibaca dari environment/.env seperti server.")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"tokobiru/migrations"
+)
+
+// runMigrate menggantikan binary migrate yang lama:
+//
+//	tokobiru migrate status
+//	tokobiru migrate up
+//	tokobiru migrate down [--steps 1]
+func runMigrate(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	action := args[0]
+	fs := newFlagSet("migrate " + action)
+	steps := 1
+	if action == "down" {
+		fs.IntVar(&steps, "steps", 1, "Jumlah migrasi terakhir yang dibatalkan")
+	}
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if steps < 1 {
+		return fmt.Errorf("%w: --steps must be at least 1", errUsage)
+	}
+	if a.db == nil {
+		return errors.New("migrations require a MongoDB connection")
+	}
+	migrator := migrations.NewMongoMigrator(a.db)
+
+	switch action {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("read migration status: %w", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Local().Format(time.DateTime)
+			}
+			if status.Unknown {
+				state += " (unknown to this binary)"
+			}
+			fmt.Fprintf(a.out, "%4d  %-50s  %s\n", status.Version, status.Description, state)
+		}
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(a.out, "Applied %d: %s\n", migration.Version, migration.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(a.out, "Database is up to date.")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(a.out, "Reverted %d: %s\n", migration.Version, migration.Description)
+		}
+		return err
+	default:
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"tokobiru/fixtures"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// productCSVColumns adalah kolom file CSV import produk. Harga ditulis dalam
+// satuan utama mata uang default, misalnya 85000 untuk Rp 85.000.
+var productCSVColumns = []string{"name", "description", "price", "stock", "category", "image_url"}
+
+// importRow adalah satu produk dari file import beserta produk yang akan
+// diperbarui; existing nil berarti produk baru
+type importRow struct {
+	product  models.Product
+	existing *models.Product
+}
+
+// runProductImport menambah produk baru dan memperbarui produk yang namanya
+// sudah ada. Semua baris divalidasi sebelum ada yang ditulis, lalu ditulis
+// dalam satu transaksi. Pada MongoDB standalone yang tidak mendukung
+// transaksi, baris ditulis satu per satu; jika penulisan gagal di tengah,
+// produk yang sudah tersimpan dicetak agar import bisa dilanjutkan.
+func runProductImport(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("product import")
+	path := fs.String("file", "", "File produk .json (array produk) atau .csv (wajib)")
+	dryRun := fs.Bool("dry-run", false, "Validasi dan tampilkan hasil tanpa menyimpan")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("%w: --file is required", errUsage)
+	}
+	products, err := readProducts(*path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *path, err)
+	}
+
+	var rows []importRow
+	var created, updated int
+	now := time.Now()
+	for _, product := range products {
+		existing, err := a.store.Products.FindByName(ctx, product.Name)
+		if err != nil && err != repositories.ErrNotFound {
+			return err
+		}
+		product.UpdatedAt = now
+		product.Embedding = services.EmbedProduct(product)
+		if existing == nil {
+			product.ID = primitive.NewObjectID()
+			product.CreatedAt = now
+			created++
+			fmt.Fprintf(a.out, "create  %s\n", product.Name)
+		} else {
+			updated++
+			fmt.Fprintf(a.out, "update  %s\n", product.Name)
+		}
+		rows = append(rows, importRow{product: product, existing: existing})
+	}
+
+	summary := fmt.Sprintf("%d created, %d updated", created, updated)
+	if *dryRun {
+		fmt.Fprintln(a.out, summary+" (dry run, nothing saved)")
+		return nil
+	}
+
+	err = a.store.Tx.RunInTransaction(ctx, func(ctx context.Context) error {
+		_, err := writeProducts(ctx, a.store.Products, rows)
+		return err
+	})
+	if err == repositories.ErrTransactionsNotSupported {
+		var saved []string
+		if saved, err = writeProducts(ctx, a.store.Products, rows); err != nil && len(saved) > 0 {
+			fmt.Fprintf(a.out, "Saved before the error (%d of %d): %s\n", len(saved), len(rows), strings.Join(saved, ", "))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, summary)
+	return nil
+}
+
+// writeProducts menulis rows berurutan dan mengembalikan nama produk yang
+// sudah tersimpan sebelum error pertama
+func writeProducts(ctx context.Context, products repositories.ProductRepository, rows []importRow) ([]string, error) {
+	var saved []string
+	for _, row := range rows {
+		product := row.product
+		if row.existing == nil {
+			if err := products.Create(ctx, &product); err != nil {
+				return saved, fmt.Errorf("create product %q: %w", product.Name, err)
+			}
+		} else if err := products.Update(ctx, row.existing.ID, &product); err != nil {
+			return saved, fmt.Errorf("update product %q: %w", product.Name, err)
+		}
+		saved = append(saved, product.Name)
+	}
+	return saved, nil
+}
+
+// readProducts membaca dan memvalidasi file produk JSON atau CSV
+func readProducts(path string) ([]models.Product, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var products []models.Product
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		products, err = parseProductsCSV(file)
+	} else {
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&products)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, errors.New("no products found")
+	}
+
+	seen := make(map[string]bool, len(products))
+	for i := range products {
+		if err := fixtures.ValidateProduct(&products[i]); err != nil {
+			return nil, fmt.Errorf("product %d: %w", i+1, err)
+		}
+		if seen[products[i].Name] {
+			return nil, fmt.Errorf("product %d: duplicate name %q", i+1, products[i].Name)
+		}
+		seen[products[i].Name] = true
+	}
+	return products, nil
+}
+
+func parseProductsCSV(r io.Reader) ([]models.Product, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range productCSVColumns {
+		if _, ok := columns[name]; !ok && name != "image_url" {
+			return nil, fmt.Errorf("missing column %q (columns: %s)", name, strings.Join(productCSVColumns, ", "))
+		}
+	}
+
+	var products []models.Product
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return products, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		price, err := strconv.ParseFloat(field("price"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price %q", line, field("price"))
+		}
+		stock, err := strconv.Atoi(field("stock"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid stock %q", line, field("stock"))
+		}
+		products = append(products, models.Product{
+			Name:        field("name"),
+			Description: field("description"),
+			Price:       models.MoneyFromMajor(price, models.DefaultCurrency),
+			Stock:       stock,
+			Category:    field("category"),
+			ImageURL:    field("image_url"),
+		})
+	}
+}
+
+// runReindex menghitung ulang embedding produk, misalnya setelah produk
+// diubah langsung di database atau setelah tokenizer embedding berubah
+func runReindex(ctx context.Context, a *app, args []string) error {
+	if err := parseFlags(newFlagSet("reindex"), args); err != nil {
+		return err
+	}
+	count, err := services.ReindexProductEmbeddings(ctx, a.store.Products)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Reindexed %d products.\n", count)
+	return nil
+}
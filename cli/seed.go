@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"tokobiru/fixtures"
+)
+
+// runSeed memuat file fixture, atau data demo jika --fixtures tidak diisi.
+// Data yang sudah ada dilewati sehingga seed aman dijalankan ulang.
+func runSeed(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("seed")
+	path := fs.String("fixtures", "", "File fixture JSON (default: data demo bawaan)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	data := fixtures.Demo()
+	if *path != "" {
+		file, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if data, err = fixtures.Parse(file); err != nil {
+			return fmt.Errorf("%s: %w", *path, err)
+		}
+	}
+
+	result, err := fixtures.Apply(ctx, a.store, data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Users: %d created, %d skipped. Products: %d created, %d skipped.\n",
+		result.UsersCreated, result.UsersSkipped, result.ProductsCreated, result.ProductsSkipped)
+	return nil
+}
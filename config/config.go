@@ -1,10 +1,19 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
 // Config struct holds all configuration for the application
@@ -12,14 +21,102 @@ type Config struct {
 	ServerPort    string
 	MongoURI      string
 	MongoDatabase string
-	JWTSecretKey  string
-	JWTExpiration string
+	JWTSecretKey  string // Hanya untuk memverifikasi token HS256 lama; token baru ditandatangani dengan kunci asimetris
+
+	// Terapkan migrasi database yang belum berjalan saat server start. Jika
+	// dinonaktifkan, migrasi dijalankan manual dengan perintah migrate.
+	MigrateOnStartup bool
+
+	// Log JSON (default) atau text dengan level debug, info, warn atau error
+	LogLevel  string
+	LogFormat string
+
+	// Tracing OpenTelemetry, dikirim lewat OTLP/HTTP. Nonaktif secara default.
+	TracingEnabled     bool
+	TracingEndpoint    string  // URL collector, misalnya http://localhost:4318
+	TracingSampleRatio float64 // Rasio trace yang disimpan, 0..1
+
+	// ShutdownTimeout adalah batas waktu menunggu request yang sedang berjalan
+	// (termasuk jawaban chatbot) selesai saat server dihentikan
+	ShutdownTimeout time.Duration
+
+	// Kunci penandatangan JWT (RS256 atau EdDSA) dimuat dari JWTKeysDir dan
+	// diganti setiap JWTKeyRotation. Kunci baru dipublikasikan di JWKS selama
+	// JWTKeyActivationDelay sebelum dipakai, dan kunci lama tetap diterima
+	// selama JWTKeyRetention setelah pensiun.
+	JWTKeysDir            string
+	JWTAlgorithm          string
+	JWTKeyRotation        time.Duration
+	JWTKeyActivationDelay time.Duration
+	JWTKeyRetention       time.Duration
+
+	// Masa berlaku access token (JWT) dan refresh token
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Masa berlaku undangan admin
+	InvitationTTL time.Duration
+
+	// Reset password dan verifikasi email
+	PasswordResetTTL         time.Duration
+	EmailVerificationTTL     time.Duration
+	RequireEmailVerification bool   // Tolak login user yang belum memverifikasi email
+	AppBaseURL               string // Dipakai untuk membuat link di email
+
+	// Proteksi brute-force login. Setelah LoginMaxFailures gagal berturut-turut
+	// dalam LoginFailureWindow, akun dikunci selama LoginLockoutDuration. IP yang
+	// melewati LoginIPMaxFailures juga dikunci. Setiap kegagalan diberi jeda
+	// yang berlipat dua mulai dari LoginDelayBase sampai LoginDelayMax.
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginDelayBase       time.Duration
+	LoginDelayMax        time.Duration
+
+	// Two-factor authentication (TOTP)
+	MFAIssuer        string        // Nama yang tampil di aplikasi authenticator
+	MFATokenTTL      time.Duration // Masa berlaku token "mfa pending" di antara dua langkah login
+	MFARequiredRoles []string      // Role yang wajib memakai 2FA, misalnya "admin"
+
+	// Pengiriman email: "log" (default, untuk development) atau "smtp"
+	MailDriver   string
+	MailFrom     string
+	MailDir      string // Driver log: jika diisi, setiap email juga ditulis ke file .eml
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// Chatbot LLM provider: "gemini", "openai" or "rule" (offline fallback).
+	// Jika kosong, dipilih "gemini" bila GEMINI_API_KEY tersedia, selain itu "rule".
+	LLMProvider   string
+	GeminiAPIKey  string
+	GeminiModel   string
+	OpenAIBaseURL string // OpenAI-compatible endpoint, misalnya Ollama atau llama.cpp
+	OpenAIAPIKey  string
+	OpenAIModel   string
+
+	// Retrieval produk untuk chatbot
+	ChatTopK       int  // Jumlah maksimum produk dalam prompt
+	ChatEmbeddings bool // Gabungkan pencarian kata kunci dengan embedding lokal
+
+	// Perkiraan token riwayat percakapan yang dikirim ulang ke LLM
+	ChatHistoryTokens int
 }
 
-// LoadConfig reads configuration from environment variables.
-// It will optionally load a .env file if it exists, but will not
-// fail if it doesn't. This makes it work seamlessly in both
-// local development and Docker environments.
+// defaultConfigFiles dicari di direktori kerja jika CONFIG_FILE tidak diisi
+var defaultConfigFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// LoadConfig membaca konfigurasi aplikasi. Fungsi ini dipanggil sekali saat
+// startup dan hasilnya diteruskan ke service, middleware dan controller.
+//
+// Setiap nilai diambil dari environment variable (termasuk file .env jika
+// ada), lalu dari file konfigurasi YAML/TOML, lalu default. File konfigurasi
+// dipilih lewat CONFIG_FILE, atau config.yaml / config.yml / config.toml di
+// direktori kerja. Key di file sama dengan nama environment variable dalam
+// huruf kecil, dan boleh dikelompokkan: `login: {max_failures: 5}` sama
+// dengan LOGIN_MAX_FAILURES=5.
 func LoadConfig() (config Config, err error) {
 	// Attempt to load .env file but don't treat it as a fatal error.
 	// This is useful for local development.
@@ -27,13 +124,284 @@ func LoadConfig() (config Config, err error) {
 		log.Println("Warning: Could not find .env file. Using environment variables instead.")
 	}
 
-	// Read configuration from environment variables
+	file, err := readConfigFile(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return config, err
+	}
+	l := &loader{file: file}
+
 	config = Config{
-		ServerPort:    os.Getenv("SERVER_PORT"),
-		MongoURI:      os.Getenv("MONGO_URI"),
-		MongoDatabase: os.Getenv("MONGO_DATABASE"),
-		JWTSecretKey:  os.Getenv("JWT_SECRET_KEY"),
-		JWTExpiration: os.Getenv("JWT_EXPIRATION_HOURS"),
+		ServerPort:    l.string("SERVER_PORT", "8080"),
+		MongoURI:      l.string("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDatabase: l.string("MONGO_DATABASE", "tokobiru"),
+		JWTSecretKey:  l.string("JWT_SECRET_KEY", ""),
+		LogLevel:      l.string("LOG_LEVEL", "info"),
+		LogFormat:     l.string("LOG_FORMAT", "json"),
+		JWTKeysDir:    l.string("JWT_KEYS_DIR", "keys"),
+		JWTAlgorithm:  l.string("JWT_ALGORITHM", "RS256"),
+		LLMProvider:   l.string("LLM_PROVIDER", ""),
+		GeminiAPIKey:  l.string("GEMINI_API_KEY", ""),
+		GeminiModel:   l.string("GEMINI_MODEL", "gemini-1.5-flash"),
+		OpenAIBaseURL: l.string("OPENAI_BASE_URL", "http://localhost:11434/v1"),
+		OpenAIAPIKey:  l.string("OPENAI_API_KEY", ""),
+		OpenAIModel:   l.string("OPENAI_MODEL", "llama3.2"),
+		AppBaseURL:    l.string("APP_BASE_URL", "http://localhost:8080"),
+		MFAIssuer:     l.string("MFA_ISSUER", "Tokobiru"),
+		MailDriver:    l.string("MAIL_DRIVER", "log"),
+		MailFrom:      l.string("MAIL_FROM", "Tokobiru <no-reply@tokobiru.local>"),
+		MailDir:       l.string("MAIL_DIR", ""),
+		SMTPHost:      l.string("SMTP_HOST", "localhost"),
+		SMTPUsername:  l.string("SMTP_USERNAME", ""),
+		SMTPPassword:  l.string("SMTP_PASSWORD", ""),
+
+		ShutdownTimeout:       l.duration("SHUTDOWN_TIMEOUT", "30s"),
+		AccessTokenTTL:        l.duration("ACCESS_TOKEN_TTL", "15m"),
+		RefreshTokenTTL:       l.duration("REFRESH_TOKEN_TTL", "720h"),
+		JWTKeyRotation:        l.duration("JWT_KEY_ROTATION", "720h"),
+		JWTKeyActivationDelay: l.duration("JWT_KEY_ACTIVATION_DELAY", "10m"),
+		JWTKeyRetention:       l.duration("JWT_KEY_RETENTION", "24h"),
+		InvitationTTL:         l.duration("INVITATION_TTL", "72h"),
+		PasswordResetTTL:      l.duration("PASSWORD_RESET_TTL", "1h"),
+		EmailVerificationTTL:  l.duration("EMAIL_VERIFICATION_TTL", "48h"),
+		LoginFailureWindow:    l.duration("LOGIN_FAILURE_WINDOW", "15m"),
+		LoginLockoutDuration:  l.duration("LOGIN_LOCKOUT_DURATION", "15m"),
+		LoginDelayBase:        l.duration("LOGIN_DELAY_BASE", "250ms"),
+		LoginDelayMax:         l.duration("LOGIN_DELAY_MAX", "4s"),
+		MFATokenTTL:           l.duration("MFA_TOKEN_TTL", "5m"),
+
+		MFARequiredRoles:         l.list("MFA_REQUIRED_ROLES"),
+		LoginMaxFailures:         l.int("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:       l.int("LOGIN_IP_MAX_FAILURES", 50),
+		RequireEmailVerification: l.bool("REQUIRE_EMAIL_VERIFICATION", false),
+		MigrateOnStartup:         l.bool("MIGRATE_ON_STARTUP", true),
+		TracingEnabled:           l.bool("TRACING_ENABLED", false),
+		TracingEndpoint:          l.string("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		TracingSampleRatio:       l.float("TRACING_SAMPLE_RATIO", 1),
+		SMTPPort:                 l.int("SMTP_PORT", 1025),
+		ChatTopK:                 l.int("CHAT_TOP_K", 5),
+		ChatEmbeddings:           l.bool("CHAT_EMBEDDINGS", false),
+		ChatHistoryTokens:        l.int("CHAT_HISTORY_TOKENS", 1500),
+	}
+
+	// JWT_EXPIRATION_HOURS tidak lagi dipakai sejak access token berumur pendek
+	if l.string("JWT_EXPIRATION_HOURS", "") != "" {
+		log.Println("Warning: JWT_EXPIRATION_HOURS is no longer used. Set ACCESS_TOKEN_TTL instead.")
+	}
+
+	if err := errors.Join(l.errs...); err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+// Validate memeriksa nilai yang akan membuat aplikasi gagal atau tidak aman
+// saat berjalan, agar kesalahan konfigurasi ketahuan saat startup.
+func (c Config) Validate() error {
+	var errs []error
+	if port, err := strconv.Atoi(c.ServerPort); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid SERVER_PORT %q", c.ServerPort))
+	}
+	if !strings.HasPrefix(c.MongoURI, "mongodb://") && !strings.HasPrefix(c.MongoURI, "mongodb+srv://") {
+		errs = append(errs, errors.New("MONGO_URI must start with mongodb:// or mongodb+srv://"))
+	}
+	if c.MongoDatabase == "" {
+		errs = append(errs, errors.New("MONGO_DATABASE is required"))
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	if format := strings.ToLower(c.LogFormat); format != "json" && format != "text" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be json or text, got %q", c.LogFormat))
+	}
+
+	// Token ditandatangani dengan kunci di JWT_KEYS_DIR; JWT_SECRET_KEY hanya
+	// untuk token HS256 lama sehingga boleh kosong
+	if c.JWTKeysDir == "" {
+		errs = append(errs, errors.New("JWT_KEYS_DIR is required"))
+	}
+	if c.JWTAlgorithm != "RS256" && c.JWTAlgorithm != "EdDSA" {
+		errs = append(errs, fmt.Errorf("JWT_ALGORITHM must be RS256 or EdDSA, got %q", c.JWTAlgorithm))
+	}
+	if c.JWTKeyRotation < 0 || (c.JWTKeyRotation > 0 && c.JWTKeyRotation <= c.JWTKeyActivationDelay) {
+		errs = append(errs, errors.New("JWT_KEY_ROTATION must be 0 or longer than JWT_KEY_ACTIVATION_DELAY"))
+	}
+	if c.JWTKeyRetention < c.AccessTokenTTL {
+		errs = append(errs, errors.New("JWT_KEY_RETENTION must be at least ACCESS_TOKEN_TTL"))
+	}
+
+	positive := map[string]time.Duration{
+		"SHUTDOWN_TIMEOUT":       c.ShutdownTimeout,
+		"ACCESS_TOKEN_TTL":       c.AccessTokenTTL,
+		"REFRESH_TOKEN_TTL":      c.RefreshTokenTTL,
+		"INVITATION_TTL":         c.InvitationTTL,
+		"PASSWORD_RESET_TTL":     c.PasswordResetTTL,
+		"EMAIL_VERIFICATION_TTL": c.EmailVerificationTTL,
+		"LOGIN_FAILURE_WINDOW":   c.LoginFailureWindow,
+		"LOGIN_LOCKOUT_DURATION": c.LoginLockoutDuration,
+		"MFA_TOKEN_TTL":          c.MFATokenTTL,
+	}
+	for key, value := range positive {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", key))
+		}
+	}
+	if c.LoginMaxFailures < 1 || c.LoginIPMaxFailures < 1 {
+		errs = append(errs, errors.New("LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be at least 1"))
+	}
+
+	if _, err := url.ParseRequestURI(c.AppBaseURL); err != nil {
+		errs = append(errs, fmt.Errorf("invalid APP_BASE_URL: %w", err))
+	}
+	switch strings.ToLower(c.MailDriver) {
+	case "log":
+	case "smtp":
+		if c.SMTPHost == "" || c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			errs = append(errs, errors.New("MAIL_DRIVER=smtp requires SMTP_HOST and a valid SMTP_PORT"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("MAIL_DRIVER must be log or smtp, got %q", c.MailDriver))
+	}
+	switch strings.ToLower(c.LLMProvider) {
+	case "", "gemini", "openai", "rule":
+	default:
+		errs = append(errs, fmt.Errorf("LLM_PROVIDER must be gemini, openai or rule, got %q", c.LLMProvider))
+	}
+	if c.TracingEnabled {
+		if u, err := url.Parse(c.TracingEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be an http(s) URL, got %q", c.TracingEndpoint))
+		}
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, errors.New("TRACING_SAMPLE_RATIO must be between 0 and 1"))
+	}
+	if c.ChatTopK < 1 {
+		errs = append(errs, errors.New("CHAT_TOP_K must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// loader membaca satu nilai konfigurasi dari environment, file, lalu default.
+// Kesalahan parsing dikumpulkan agar semuanya dilaporkan sekaligus.
+type loader struct {
+	file map[string]string
+	errs []error
+}
+
+func (l *loader) string(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value, ok := l.file[key]; ok {
+		return value
+	}
+	return fallback
+}
+
+func (l *loader) duration(key, fallback string) time.Duration {
+	value, err := time.ParseDuration(l.string(key, fallback))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return value
+}
+
+func (l *loader) int(key string, fallback int) int {
+	value, err := strconv.Atoi(l.string(key, strconv.Itoa(fallback)))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return value
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(l.string(key, strconv.FormatBool(fallback)))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return value
+}
+
+func (l *loader) float(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(l.string(key, strconv.FormatFloat(fallback, 'f', -1, 64)), 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %w", key, err))
+	}
+	return value
+}
+
+// list membaca nilai yang dipisahkan koma
+func (l *loader) list(key string) []string {
+	var values []string
+	for _, value := range strings.Split(l.string(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// readConfigFile membaca file YAML/TOML menjadi map dengan key seperti
+// environment variable. Jika path kosong dan tidak ada file default, map
+// kosong dikembalikan.
+func readConfigFile(path string) (map[string]string, error) {
+	if path == "" {
+		for _, candidate := range defaultConfigFiles {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %q: use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flattenConfig("", raw, values)
+	log.Printf("Loaded configuration from %s", path)
+	return values, nil
+}
+
+// flattenConfig mengubah tabel bersarang menjadi key seperti LOGIN_MAX_FAILURES.
+// List digabung dengan koma.
+func flattenConfig(prefix string, raw map[string]interface{}, out map[string]string) {
+	for key, value := range raw {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenConfig(name, v, out)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[name] = strings.Join(items, ",")
+		case nil:
+			out[name] = ""
+		default:
+			out[name] = fmt.Sprint(v)
+		}
 	}
-	return config, nil // No error is returned from this function anymore
 }
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFileWithEnvOverride(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server_port: 9090
+mongo_database: tokobiru_test
+access_token_ttl: 5m
+mfa_required_roles: [admin, warehouse]
+login:
+  max_failures: 3
+  lockout_duration: 1h
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("SERVER_PORT", "7070")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ServerPort != "7070" {
+		t.Errorf("env should override file: got port %q", cfg.ServerPort)
+	}
+	if cfg.MongoDatabase != "tokobiru_test" || cfg.AccessTokenTTL != 5*time.Minute {
+		t.Errorf("file values not applied: %q %v", cfg.MongoDatabase, cfg.AccessTokenTTL)
+	}
+	if cfg.LoginMaxFailures != 3 || cfg.LoginLockoutDuration != time.Hour {
+		t.Errorf("nested keys not applied: %d %v", cfg.LoginMaxFailures, cfg.LoginLockoutDuration)
+	}
+	if strings.Join(cfg.MFARequiredRoles, ",") != "admin,warehouse" {
+		t.Errorf("list not applied: %v", cfg.MFARequiredRoles)
+	}
+	if cfg.RefreshTokenTTL != 720*time.Hour {
+		t.Errorf("default not applied: %v", cfg.RefreshTokenTTL)
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "config.toml", `
+mail_driver = "smtp"
+
+[smtp]
+host = "mailhog"
+port = 2525
+`))
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MailDriver != "smtp" || cfg.SMTPHost != "mailhog" || cfg.SMTPPort != 2525 {
+		t.Errorf("unexpected mail config: %q %q %d", cfg.MailDriver, cfg.SMTPHost, cfg.SMTPPort)
+	}
+}
+
+func TestLoadConfigFailsFast(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "config.yaml", "server_port:\n"))
+	t.Setenv("ACCESS_TOKEN_TTL", "fifteen minutes")
+	t.Setenv("LOGIN_MAX_FAILURES", "many")
+
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, key := range []string{"ACCESS_TOKEN_TTL", "LOGIN_MAX_FAILURES"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error does not mention %s: %v", key, err)
+		}
+	}
+
+	// Kesalahan parsing dilaporkan lebih dulu; setelah diperbaiki, validasi menolak port kosong
+	t.Setenv("ACCESS_TOKEN_TTL", "15m")
+	t.Setenv("LOGIN_MAX_FAILURES", "5")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "SERVER_PORT") {
+		t.Fatalf("expected SERVER_PORT error, got %v", err)
+	}
+}
+
+func TestLoadConfigValidatesTracing(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "config.yaml", "server_port: 8080\n"))
+	t.Setenv("TRACING_ENABLED", "true")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318")
+	t.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "TRACING_SAMPLE_RATIO"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error does not mention %s: %v", key, err)
+		}
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.TracingEnabled || cfg.TracingSampleRatio != 0.25 {
+		t.Errorf("unexpected tracing config: %v %v", cfg.TracingEnabled, cfg.TracingSampleRatio)
+	}
+}
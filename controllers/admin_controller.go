@@ -1,45 +1,42 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
-	"tokobiru/database"
+	"tokobiru/apperror"
 	"tokobiru/models"
+	"tokobiru/repositories"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type AdminController struct {
-	db *mongo.Client
+	users         repositories.UserRepository
+	orders        repositories.OrderRepository
+	conversations repositories.ConversationRepository
 }
 
-func NewAdminController(db *mongo.Client) *AdminController {
-	return &AdminController{db: db}
+func NewAdminController(users repositories.UserRepository, orders repositories.OrderRepository, conversations repositories.ConversationRepository) *AdminController {
+	return &AdminController{users: users, orders: orders, conversations: conversations}
+}
+
+// OrderStatusInput adalah body untuk mengubah status pesanan
+type OrderStatusInput struct {
+	Status string `json:"status" binding:"required"`
 }
 
 // GetAllUsers retrieves all user data (Admin only)
 func (ac *AdminController) GetAllUsers(c *gin.Context) {
-	userCollection := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	// Projection to exclude password field
-	projection := options.Find().SetProjection(bson.M{"password": 0})
-
-	cursor, err := userCollection.Find(ctx, bson.M{}, projection)
+	users, err := ac.users.FindAll(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
-		return
-	}
-
-	var users []models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode users"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -48,19 +45,12 @@ func (ac *AdminController) GetAllUsers(c *gin.Context) {
 
 // GetAllOrders retrieves all orders from all users (Admin only)
 func (ac *AdminController) GetAllOrders(c *gin.Context) {
-	orderCollection := database.GetCollection("orders")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	cursor, err := orderCollection.Find(ctx, bson.M{})
+	orders, err := ac.orders.FindAll(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch all orders"})
-		return
-	}
-
-	var orders []models.Order
-	if err = cursor.All(ctx, &orders); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode orders"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -71,47 +61,178 @@ func (ac *AdminController) GetAllOrders(c *gin.Context) {
 func (ac *AdminController) UpdateOrderStatus(c *gin.Context) {
 	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
-	var req struct {
-		Status string `json:"status" binding:"required"`
-	}
+	var req OrderStatusInput
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
 	// Validate status
-	validStatuses := []string{"baru", "diproses", "dikirim", "selesai", "dibatalkan"}
-	isValidStatus := false
-	for _, s := range validStatuses {
-		if s == req.Status {
-			isValidStatus = true
-			break
+	if !slices.Contains(models.OrderStatuses, req.Status) {
+		apperror.Abort(c, apperror.Validation(apperror.FieldError{Field: "status", Rule: "oneof", Param: strings.Join(models.OrderStatuses, " ")}))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	err = ac.orders.UpdateStatus(ctx, orderID, req.Status)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeOrderNotFound))
+			return
 		}
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
+}
+
+// salesReportTimezone adalah zona waktu yang dipakai untuk filter tanggal dan
+// pengelompokan laporan penjualan. Toko Biru beroperasi dalam WIB.
+const salesReportTimezone = "Asia/Jakarta"
+
+// jakartaLocation mengembalikan lokasi Asia/Jakarta, dengan fallback ke UTC+7
+// jika database zona waktu tidak tersedia (misalnya di image alpine).
+func jakartaLocation() *time.Location {
+	loc, err := time.LoadLocation(salesReportTimezone)
+	if err != nil {
+		// Nama zona berupa offset agar tetap dikenali oleh $dateTrunc MongoDB
+		return time.FixedZone("+07:00", 7*60*60)
 	}
-	if !isValidStatus {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+	return loc
+}
+
+// GetSalesReport builds an aggregated sales summary over the orders collection (Admin only).
+// Query params: from & to (YYYY-MM-DD, inclusive, WIB) and interval (day, week, month).
+func (ac *AdminController) GetSalesReport(c *gin.Context) {
+	loc := jakartaLocation()
+
+	interval := c.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" && interval != "month" {
+		apperror.Abort(c, apperror.Validation(apperror.FieldError{Field: "interval", Rule: "oneof", Param: "day week month"}))
 		return
 	}
 
-	orderCollection := database.GetCollection("orders")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	filter := repositories.SalesReportFilter{Interval: interval, Location: loc}
+	if from := c.Query("from"); from != "" {
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			apperror.Abort(c, apperror.Validation(apperror.FieldError{Field: "from", Rule: "date"}))
+			return
+		}
+		filter.Start = start
+	}
+	if to := c.Query("to"); to != "" {
+		end, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			apperror.Abort(c, apperror.Validation(apperror.FieldError{Field: "to", Rule: "date"}))
+			return
+		}
+		// 'to' bersifat inklusif, jadi ambil sampai awal hari berikutnya
+		filter.End = end.AddDate(0, 0, 1)
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	update := bson.M{"$set": bson.M{"status": req.Status}}
-	result, err := orderCollection.UpdateOne(ctx, bson.M{"_id": orderID}, update)
+	report, err := ac.orders.SalesReport(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
+	report.Timezone = salesReportTimezone
+	report.From = c.Query("from")
+	report.To = c.Query("to")
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	// Pastikan array selalu dikembalikan, bukan 'null'
+	if report.TopSellingProducts == nil {
+		report.TopSellingProducts = make([]models.TopSellingProduct, 0)
+	}
+	if report.StatusBreakdown == nil {
+		report.StatusBreakdown = make([]models.StatusBreakdown, 0)
+	}
+	if report.TimeSeries == nil {
+		report.TimeSeries = make([]models.SalesBucket, 0)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetConversations lists chatbot conversations for quality review (Admin only).
+// Query params: userId or sessionId to filter by owner, page and limit.
+func (ac *AdminController) GetConversations(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := repositories.ConversationFilter{
+		SessionID: c.Query("sessionId"),
+		Skip:      (page - 1) * limit,
+		Limit:     limit,
+	}
+	if userID := c.Query("userId"); userID != "" {
+		id, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			apperror.Abort(c, apperror.InvalidID("userId"))
+			return
+		}
+		filter.UserID = id
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	conversations, total, err := ac.conversations.List(ctx, filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
+	if conversations == nil {
+		conversations = make([]models.Conversation, 0)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"data": conversations,
+		"meta": gin.H{
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
+}
+
+// GetConversationByID returns the full transcript of any conversation (Admin only)
+func (ac *AdminController) GetConversationByID(c *gin.Context) {
+	conversationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	conversation, err := ac.conversations.FindByID(ctx, conversationID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeConversationNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
 }
@@ -2,71 +2,152 @@ package controllers
 
 import (
 	"context"
+	"log/slog"
+	"math"
 	"net/http"
-	"strings" // <-- IMPORT BARU untuk memanipulasi string
+	"strconv"
 	"time"
-	"tokobiru/database"
+	"tokobiru/apperror"
+	"tokobiru/config"
 	"tokobiru/models"
+	"tokobiru/repositories"
 	"tokobiru/services"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AuthController struct {
-	db *mongo.Client
+	users    repositories.UserRepository
+	sessions *services.SessionService
+	accounts *services.AccountService
+	guard    *services.LoginGuard
+	mfa      *services.MFAService
+	// keys menandatangani dan memvalidasi token "mfa pending"
+	keys        *services.KeyRing
+	mfaTokenTTL time.Duration
+	// requireVerification menolak login user yang belum memverifikasi email
+	requireVerification bool
 }
 
-func NewAuthController(db *mongo.Client) *AuthController {
-	return &AuthController{db: db}
+func NewAuthController(cfg config.Config, users repositories.UserRepository, sessions repositories.SessionRepository, revocations repositories.RevocationRepository, userTokens repositories.UserTokenRepository, loginAttempts repositories.LoginAttemptRepository, audit repositories.AuditRepository, keys *services.KeyRing, mailer services.Mailer) *AuthController {
+	return &AuthController{
+		users:               users,
+		sessions:            services.NewSessionService(users, sessions, revocations, keys, cfg.AccessTokenTTL, cfg.RefreshTokenTTL),
+		accounts:            services.NewAccountService(users, userTokens, sessions, mailer, cfg.AppBaseURL, cfg.PasswordResetTTL, cfg.EmailVerificationTTL),
+		guard:               services.NewLoginGuard(loginAttempts, audit, services.LoginPolicyFromConfig(cfg)),
+		mfa:                 services.NewMFAService(users, cfg.MFAIssuer, cfg.MFARequiredRoles),
+		keys:                keys,
+		mfaTokenTTL:         cfg.MFATokenTTL,
+		requireVerification: cfg.RequireEmailVerification,
+	}
+}
+
+// LoginInput adalah body untuk login dengan email dan password
+type LoginInput struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password"`
+}
+
+// MFATokenInput adalah body berisi token "mfa pending" dari login
+type MFATokenInput struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+}
+
+// MFAVerifyInput adalah body langkah kedua login: token "mfa pending" dan
+// kode TOTP atau recovery code
+type MFAVerifyInput struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RefreshInput adalah body berisi refresh token
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutInput adalah body logout. Refresh token opsional jika access token dikirim.
+type LogoutInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// EmailInput adalah body yang hanya berisi alamat email
+type EmailInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordInput adalah body untuk mengganti password dengan token reset
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// TokenInput adalah body berisi token sekali pakai dari email
+type TokenInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// MFAVerifyResponse adalah token hasil login dua langkah. RecoveryCodes hanya
+// diisi jika langkah ini sekaligus mengaktifkan 2FA.
+type MFAVerifyResponse struct {
+	*services.TokenPair
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// clientInfo mengambil metadata perangkat untuk disimpan bersama session
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
 }
 
 // Register a new user
 func (ac *AuthController) Register(c *gin.Context) {
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
-	userCollection := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+	exists, err := ac.users.ExistsByEmail(ctx, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email existence"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
-	if count > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+	if exists {
+		apperror.Abort(c, apperror.New(apperror.CodeEmailTaken))
 		return
 	}
 
 	hashedPassword, err := services.HashPassword(user.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 	user.Password = hashedPassword
 
-	// Periksa apakah email mengandung kata "admin"
-	if strings.Contains(strings.ToLower(user.Email), "admin") {
-		user.Role = "admin"
-	} else {
-		user.Role = "customer"
-	}
+	// Registrasi publik selalu membuat customer. Admin dibuat lewat undangan.
+	user.Role = models.RoleCustomer
+	user.EmailVerified = false
+	user.MFAEnabled = false
 
 	user.ID = primitive.NewObjectID()
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	_, err = userCollection.InsertOne(ctx, user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	// Index unik users.email menangkap registrasi bersamaan dengan email yang sama
+	if err := ac.users.Create(ctx, &user); err == repositories.ErrDuplicate {
+		apperror.Abort(c, apperror.New(apperror.CodeEmailTaken))
 		return
+	} else if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	// Kegagalan kirim email tidak membatalkan registrasi; link bisa diminta ulang
+	if err := ac.accounts.SendVerificationEmail(ctx, &user); err != nil {
+		slog.WarnContext(ctx, "could not send verification email", "email", user.Email, "error", err)
 	}
 
 	user.Password = ""
@@ -75,42 +156,332 @@ func (ac *AuthController) Register(c *gin.Context) {
 
 // Login an existing user
 func (ac *AuthController) Login(c *gin.Context) {
-	// ... (Fungsi ini tidak berubah) ...
-	var loginDetails struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password" `
-	}
+	var loginDetails LoginInput
 
 	if err := c.ShouldBindJSON(&loginDetails); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
-	userCollection := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"email": loginDetails.Email}).Decode(&user)
+	ip := c.ClientIP()
+	retryAfter, err := ac.guard.Check(ctx, loginDetails.Email, ip)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		apperror.Abort(c, apperror.New(apperror.CodeLoginLocked))
+		return
+	}
+
+	user, err := ac.users.FindByEmail(ctx, loginDetails.Email)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			services.CheckPasswordHash(loginDetails.Password, services.DummyPasswordHash)
+			ac.rejectLogin(c, ctx, loginDetails.Email, nil, apperror.New(apperror.CodeInvalidCredentials))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	if !services.CheckPasswordHash(loginDetails.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		ac.rejectLogin(c, ctx, loginDetails.Email, &user.ID, apperror.New(apperror.CodeInvalidCredentials))
+		return
+	}
+
+	if ac.requireVerification && !user.EmailVerified {
+		apperror.Abort(c, apperror.New(apperror.CodeEmailNotVerified))
+		return
+	}
+
+	// Langkah kedua: token "mfa pending" ditukar dengan kode TOTP di /auth/mfa/verify
+	if ac.mfa.LoginRequiresMFA(user) {
+		mfaToken, err := services.GenerateMFAToken(ac.keys, user.ID.Hex(), ac.mfaTokenTTL)
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfaRequired": true, "mfaEnrolled": user.MFAEnabled, "mfaToken": mfaToken})
+		return
+	}
+
+	// Penghitung kegagalan baru di-reset saat login benar-benar selesai. Jika
+	// MFA diperlukan, reset terjadi di VerifyMFA supaya password yang benar
+	// tidak membuka kunci tebakan kode TOTP.
+	if err := ac.guard.Success(ctx, loginDetails.Email); err != nil {
+		slog.WarnContext(ctx, "could not reset login attempts", "email", loginDetails.Email, "error", err)
+	}
+
+	tokens, err := ac.sessions.Login(ctx, user, clientInfo(c))
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// rejectLogin mencatat login gagal lalu membalas dengan reason setelah jeda
+// progresif. Email yang tidak terdaftar diperlakukan sama agar tidak bisa dibedakan.
+func (ac *AuthController) rejectLogin(c *gin.Context, ctx context.Context, email string, userID *primitive.ObjectID, reason *apperror.Error) {
+	delay, err := ac.guard.Failure(ctx, email, c.ClientIP(), userID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-c.Request.Context().Done():
+		}
+	}
+	apperror.Abort(c, reason)
+}
+
+// mfaUser mengambil user dari token "mfa pending". Respons error sudah dikirim jika ok bernilai false.
+func (ac *AuthController) mfaUser(c *gin.Context, ctx context.Context, mfaToken string) (*models.User, bool) {
+	userIDHex, err := services.ValidateMFAToken(mfaToken, ac.keys)
+	if err != nil {
+		apperror.Abort(c, apperror.New(apperror.CodeMFATokenInvalid))
+		return nil, false
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		apperror.Abort(c, apperror.New(apperror.CodeMFATokenInvalid))
+		return nil, false
+	}
+	user, err := ac.users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeMFATokenInvalid))
+			return nil, false
+		}
+		apperror.Abort(c, apperror.Internal(err))
+		return nil, false
+	}
+	return user, true
+}
+
+// EnrollMFA starts TOTP enrollment during login for users whose role requires
+// 2FA but who have not enrolled yet
+func (ac *AuthController) EnrollMFA(c *gin.Context) {
+	var req MFATokenInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := ac.mfaUser(c, ctx, req.MFAToken)
+	if !ok {
+		return
+	}
+	enrollment, err := ac.mfa.BeginEnrollment(ctx, user)
+	if err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// VerifyMFA completes the two-step login with a TOTP or recovery code. For a
+// pending enrollment the code also activates 2FA and recovery codes are returned.
+func (ac *AuthController) VerifyMFA(c *gin.Context) {
+	var req MFAVerifyInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := ac.mfaUser(c, ctx, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	// Tebakan kode dihitung sebagai login gagal sehingga ikut terkunci
+	retryAfter, err := ac.guard.Check(ctx, user.Email, c.ClientIP())
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		apperror.Abort(c, apperror.New(apperror.CodeLoginLocked))
+		return
+	}
+
+	var recoveryCodes []string
+	if user.MFAEnabled {
+		err = ac.mfa.Verify(ctx, user, req.Code)
+	} else {
+		recoveryCodes, err = ac.mfa.ConfirmEnrollment(ctx, user, req.Code)
+	}
+	if err != nil {
+		if err == services.ErrInvalidMFACode {
+			ac.rejectLogin(c, ctx, user.Email, &user.ID, serviceError(err))
+			return
+		}
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+	if err := ac.guard.Success(ctx, user.Email); err != nil {
+		slog.WarnContext(ctx, "could not reset login attempts", "email", user.Email, "error", err)
+	}
+
+	tokens, err := ac.sessions.Login(ctx, user, clientInfo(c))
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	token, err := services.GenerateToken(user.ID.Hex(), user.Role)
+	c.JSON(http.StatusOK, MFAVerifyResponse{tokens, recoveryCodes})
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Every refresh token can only be used once.
+func (ac *AuthController) Refresh(c *gin.Context) {
+	var req RefreshInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	tokens, err := ac.sessions.Refresh(ctx, req.RefreshToken, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the current session. The session is identified by the access
+// token (if present) and/or the refresh token in the body.
+func (ac *AuthController) Logout(c *gin.Context) {
+	var req LogoutInput
+	// Body bersifat opsional jika access token dikirim
+	_ = c.ShouldBindJSON(&req)
+
+	claims, authenticated := c.Get("tokenClaims")
+	if !authenticated && req.RefreshToken == "" {
+		apperror.Abort(c, apperror.Validation(apperror.FieldError{Field: "refreshToken", Rule: "required"}))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if authenticated {
+		tokenClaims := claims.(*services.JWTClaims)
+		var err error
+		if tokenClaims.SessionID != "" {
+			err = ac.sessions.RevokeFamily(ctx, tokenClaims.SessionID)
+		} else {
+			err = ac.sessions.RevokeAccessToken(ctx, tokenClaims.ID, tokenClaims.ExpiresAt.Time)
+		}
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+	}
+	if req.RefreshToken != "" {
+		err := ac.sessions.LogoutRefreshToken(ctx, req.RefreshToken)
+		if err != nil && err != services.ErrInvalidRefreshToken {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// ForgotPassword sends a password reset link. The response is the same whether
+// or not the email is registered.
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var req EmailInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if err := ac.accounts.ForgotPassword(ctx, req.Email); err != nil {
+		// Tidak dikembalikan ke klien agar tidak membocorkan email yang terdaftar
+		slog.WarnContext(ctx, "could not send password reset email", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a single-use reset token and logs
+// out every session of the user
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var req ResetPasswordInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if err := ac.accounts.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		apperror.Abort(c, serviceError(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token, "role": user.Role})
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// VerifyEmail marks the email of the user as verified using a single-use token
+func (ac *AuthController) VerifyEmail(c *gin.Context) {
+	var req TokenInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if err := ac.accounts.VerifyEmail(ctx, req.Token); err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a new verification link to an unverified account
+func (ac *AuthController) ResendVerification(c *gin.Context) {
+	var req EmailInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if err := ac.accounts.ResendVerification(ctx, req.Email); err != nil {
+		slog.WarnContext(ctx, "could not send verification email", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists and is not verified, a verification link has been sent"})
 }
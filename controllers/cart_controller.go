@@ -1,24 +1,47 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	"time"
-	"tokobiru/database"
+	"tokobiru/apperror"
+	"tokobiru/metrics"
 	"tokobiru/models"
+	"tokobiru/repositories"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CartController struct {
-	db *mongo.Client
+	carts    repositories.CartRepository
+	products repositories.ProductRepository
 }
 
-func NewCartController(db *mongo.Client) *CartController {
-	return &CartController{db: db}
+func NewCartController(carts repositories.CartRepository, products repositories.ProductRepository) *CartController {
+	return &CartController{carts: carts, products: products}
+}
+
+// CartInsufficientStockStatus adalah status INSUFFICIENT_STOCK di rute
+// keranjang. Rute ini sejak awal membalas 404 untuk stok yang tidak cukup,
+// jadi status itu dipertahankan agar klien lama tidak rusak.
+const CartInsufficientStockStatus = http.StatusNotFound
+
+func insufficientStock(product string) *apperror.Error {
+	return apperror.New(apperror.CodeInsufficientStock).With("product", product).WithStatus(CartInsufficientStockStatus)
+}
+
+// AddCartItemInput adalah body untuk menambahkan produk ke keranjang
+type AddCartItemInput struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// UpdateCartItemInput adalah body untuk mengubah jumlah produk di keranjang.
+// Quantity 0 menghapus produk dari keranjang. Quantity berupa pointer agar
+// body tanpa quantity ditolak, bukan dianggap 0.
+type UpdateCartItemInput struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  *int   `json:"quantity" binding:"required,gte=0"`
 }
 
 // Get the user's shopping cart
@@ -26,18 +49,16 @@ func (cc *CartController) GetCart(c *gin.Context) {
 	userIDHex, _ := c.Get("userID")
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 
-	cartCollection := database.GetCollection("carts")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	var cart models.Cart
-	err := cartCollection.FindOne(ctx, bson.M{"userId": userID}).Decode(&cart)
+	cart, err := cc.carts.FindByUserID(ctx, userID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == repositories.ErrNotFound {
 			c.JSON(http.StatusOK, gin.H{"items": []models.CartItem{}}) // Return empty cart
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cart"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -46,12 +67,9 @@ func (cc *CartController) GetCart(c *gin.Context) {
 
 // Add an item to the cart
 func (cc *CartController) AddItemToCart(c *gin.Context) {
-	var req struct {
-		ProductID string `json:"productId" binding:"required"`
-		Quantity  int    `json:"quantity" binding:"required,gt=0"`
-	}
+	var req AddCartItemInput
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
@@ -59,31 +77,32 @@ func (cc *CartController) AddItemToCart(c *gin.Context) {
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 	productID, err := primitive.ObjectIDFromHex(req.ProductID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Product ID"})
+		apperror.Abort(c, apperror.InvalidID("productId"))
 		return
 	}
 
-	// Check if product exists and has enough stock
-	var product models.Product
-	productCollection := database.GetCollection("products")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
-	err = productCollection.FindOne(ctx, bson.M{"_id": productID, "stock": bson.M{"$gte": req.Quantity}}).Decode(&product)
+
+	// Check if product exists and has enough stock
+	product, err := cc.products.FindByID(ctx, productID)
+	if err == repositories.ErrNotFound {
+		apperror.Abort(c, apperror.New(apperror.CodeProductNotFound).With("id", req.ProductID))
+		return
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or insufficient stock"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify product"})
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if product.Stock < req.Quantity {
+		metrics.StockOuts.WithLabelValues("cart").Inc()
+		apperror.Abort(c, insufficientStock(product.Name))
 		return
 	}
 
-	cartCollection := database.GetCollection("carts")
 	// Find user's cart
-	var cart models.Cart
-	err = cartCollection.FindOne(ctx, bson.M{"userId": userID}).Decode(&cart)
-
-	if err == mongo.ErrNoDocuments {
+	cart, err := cc.carts.FindByUserID(ctx, userID)
+	if err == repositories.ErrNotFound {
 		// Create a new cart if not exists
 		newCart := models.Cart{
 			ID:     primitive.NewObjectID(),
@@ -96,15 +115,19 @@ func (cc *CartController) AddItemToCart(c *gin.Context) {
 				ImageURL:  product.ImageURL,
 			}},
 		}
-		_, err = cartCollection.InsertOne(ctx, newCart)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
+		err = cc.carts.Create(ctx, &newCart)
+		if err == nil {
+			metrics.CartAdditions.Inc()
+			c.JSON(http.StatusCreated, newCart)
 			return
 		}
-		c.JSON(http.StatusCreated, newCart)
-		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cart"})
+		// Request lain membuat keranjang lebih dulu; tambahkan ke keranjang itu
+		if err == repositories.ErrDuplicate {
+			cart, err = cc.carts.FindByUserID(ctx, userID)
+		}
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -121,6 +144,13 @@ func (cc *CartController) AddItemToCart(c *gin.Context) {
 	if itemIndex != -1 {
 		// Product exists, update quantity
 		cart.Items[itemIndex].Quantity += req.Quantity
+
+		// Check stock for updated quantity
+		if product.Stock < cart.Items[itemIndex].Quantity {
+			metrics.StockOuts.WithLabelValues("cart").Inc()
+			apperror.Abort(c, insufficientStock(product.Name))
+			return
+		}
 	} else {
 		// Product does not exist, add new item
 		cart.Items = append(cart.Items, models.CartItem{
@@ -132,32 +162,20 @@ func (cc *CartController) AddItemToCart(c *gin.Context) {
 		})
 	}
 
-	// Check stock for updated quantity
-	if itemIndex != -1 {
-		err = productCollection.FindOne(ctx, bson.M{"_id": productID, "stock": bson.M{"$gte": cart.Items[itemIndex].Quantity}}).Decode(&models.Product{})
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Insufficient stock for updated quantity"})
-			return
-		}
-	}
-
 	// Save the updated cart
-	_, err = cartCollection.UpdateOne(ctx, bson.M{"_id": cart.ID}, bson.M{"$set": bson.M{"items": cart.Items}})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart"})
+	if err := cc.carts.UpdateItems(ctx, cart.ID, cart.Items); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
+	metrics.CartAdditions.Inc()
 	c.JSON(http.StatusOK, cart)
 }
 
 // Update quantity of an item in the cart
 func (cc *CartController) UpdateCartItem(c *gin.Context) {
-	var req struct {
-		ProductID string `json:"productId" binding:"required"`
-		Quantity  int    `json:"quantity" binding:"required,gte=0"`
-	}
+	var req UpdateCartItemInput
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
@@ -165,47 +183,42 @@ func (cc *CartController) UpdateCartItem(c *gin.Context) {
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 	productID, err := primitive.ObjectIDFromHex(req.ProductID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Product ID"})
+		apperror.Abort(c, apperror.InvalidID("productId"))
 		return
 	}
 
-	// Check stock
-	if req.Quantity > 0 {
-		var product models.Product
-		productCollection := database.GetCollection("products")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		err = productCollection.FindOne(ctx, bson.M{"_id": productID, "stock": bson.M{"$gte": req.Quantity}}).Decode(&product)
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	quantity := *req.Quantity
+	if quantity > 0 {
+		// Check stock
+		product, err := cc.products.FindByID(ctx, productID)
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeProductNotFound).With("id", req.ProductID))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or insufficient stock"})
+			apperror.Abort(c, apperror.Internal(err))
 			return
 		}
-	}
-
-	cartCollection := database.GetCollection("carts")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	var filter bson.M
-	var update bson.M
-
-	if req.Quantity > 0 {
-		// Update quantity of a specific item in the array
-		filter = bson.M{"userId": userID, "items.productId": productID}
-		update = bson.M{"$set": bson.M{"items.$.quantity": req.Quantity}}
+		if product.Stock < quantity {
+			apperror.Abort(c, insufficientStock(product.Name))
+			return
+		}
+		// Update quantity of a specific item in the cart
+		err = cc.carts.SetItemQuantity(ctx, userID, productID, quantity)
 	} else {
 		// If quantity is 0, remove the item from the cart
-		filter = bson.M{"userId": userID}
-		update = bson.M{"$pull": bson.M{"items": bson.M{"productId": productID}}}
+		err = cc.carts.RemoveItem(ctx, userID, productID)
 	}
 
-	result, err := cartCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
-		return
-	}
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Cart or item not found"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeCartItemNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -217,27 +230,23 @@ func (cc *CartController) RemoveItemFromCart(c *gin.Context) {
 	productIDHex := c.Param("productId")
 	productID, err := primitive.ObjectIDFromHex(productIDHex)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		apperror.Abort(c, apperror.InvalidID("productId"))
 		return
 	}
 
 	userIDHex, _ := c.Get("userID")
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 
-	cartCollection := database.GetCollection("carts")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"userId": userID}
-	update := bson.M{"$pull": bson.M{"items": bson.M{"productId": productID}}}
-
-	result, err := cartCollection.UpdateOne(ctx, filter, update)
+	err = cc.carts.RemoveItem(ctx, userID, productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove item from cart"})
-		return
-	}
-	if result.ModifiedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in cart"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeCartItemNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -2,47 +2,214 @@ package controllers
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"tokobiru/apperror"
+	"tokobiru/config"
+	"tokobiru/models"
+	"tokobiru/repositories"
 	"tokobiru/services"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// SessionHeader membawa ID sesi anonim untuk melanjutkan percakapan chatbot
+const SessionHeader = "X-Session-ID"
+
 type ChatController struct {
 	chatService *services.ChatService
+	llmStatus   services.LLMStatus
 }
 
 // Struct untuk menangkap input dari frontend
 type ChatInput struct {
-	Prompt string `json:"prompt" binding:"required"`
+	Prompt         string `json:"prompt" binding:"required"`
+	ConversationID string `json:"conversationId"` // Kosong untuk memulai percakapan baru
 }
 
 // NewChatController menginisialisasi controller dengan service yang dibutuhkan
-func NewChatController(db *mongo.Client) *ChatController {
-	// Inisialisasi klien Gemini dan ChatService
-	geminiModel := services.SetupGeminiClient(context.Background())
-	chatService := services.NewChatService(db, geminiModel)
+func NewChatController(cfg config.Config, products repositories.ProductRepository, orders repositories.OrderRepository, carts repositories.CartRepository, conversations repositories.ConversationRepository) *ChatController {
+	// Pilih LLM provider dari konfigurasi. Jika gagal (misalnya API key tidak ada),
+	// chatbot tetap berjalan dengan provider rule-based alih-alih mematikan server.
+	var status services.LLMStatus
+	provider, err := services.NewLLMProvider(context.Background(), cfg)
+	if err != nil {
+		slog.Warn("chatbot LLM provider unavailable, falling back to rule-based answers", "error", err)
+		provider = services.NewRuleBasedProvider()
+		status.Fallback = true
+		status.Error = err.Error()
+	}
+	status.Provider = provider.Name()
+	slog.Info("chatbot LLM provider selected", "provider", provider.Name())
+	retriever := services.NewProductRetriever(products, cfg.ChatTopK, cfg.ChatEmbeddings)
+	tools := services.NewChatTools(orders, carts, products)
+	chatService := services.NewChatService(retriever, tools, conversations, provider, cfg.ChatHistoryTokens)
 
 	return &ChatController{
 		chatService: chatService,
+		llmStatus:   status,
+	}
+}
+
+// LLMStatus mengembalikan provider yang dipakai chatbot
+func (cc *ChatController) LLMStatus() services.LLMStatus {
+	return cc.llmStatus
+}
+
+// chatOwner mengambil identitas pemanggil: userID dari token (diisi oleh
+// OptionalAuthMiddleware) atau ID sesi anonim dari header.
+func chatOwner(c *gin.Context) services.ChatOwner {
+	return services.ChatOwner{
+		UserID:    c.GetString("userID"),
+		SessionID: c.GetHeader(SessionHeader),
+	}
+}
+
+// conversationError memetakan ErrNotFound dari ChatService ke CONVERSATION_NOT_FOUND
+func conversationError(err error) *apperror.Error {
+	if err == repositories.ErrNotFound {
+		return apperror.New(apperror.CodeConversationNotFound)
 	}
+	return apperror.Internal(err)
 }
 
 // HandleChat menangani request dari frontend dan memanggil service
 func (cc *ChatController) HandleChat(c *gin.Context) {
 	var input ChatInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Pesan tidak boleh kosong"})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
 	// Memanggil service untuk mendapatkan jawaban dari LLM
-	response, err := cc.chatService.GenerateResponse(c.Request.Context(), input.Prompt)
+	response, err := cc.chatService.GenerateResponse(c.Request.Context(), services.ChatRequest{
+		Prompt:         input.Prompt,
+		ConversationID: input.ConversationID,
+		Owner:          chatOwner(c),
+	})
+	if err != nil {
+		apperror.Abort(c, conversationError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleChatStream sama seperti HandleChat, tetapi jawaban dikirim bertahap
+// sebagai Server-Sent Events: event "token" untuk setiap potongan teks, lalu
+// event "done" berisi metadata, atau event "error" jika terjadi kegagalan.
+func (cc *ChatController) HandleChatStream(c *gin.Context) {
+	var input ChatInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	// Header SSE baru dikirim saat potongan pertama tersedia, sehingga error
+	// sebelum itu (misalnya percakapan tidak ditemukan) tetap berupa JSON biasa.
+	started := false
+	startStream := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // Matikan buffering di reverse proxy (nginx)
+		c.Status(http.StatusOK)
+	}
+
+	ctx := c.Request.Context()
+	response, err := cc.chatService.GenerateStream(ctx, services.ChatRequest{
+		Prompt:         input.Prompt,
+		ConversationID: input.ConversationID,
+		Owner:          chatOwner(c),
+	}, func(token string) error {
+		// Hentikan LLM segera jika klien sudah memutus koneksi
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		startStream()
+		c.SSEvent("token", gin.H{"text": token})
+		c.Writer.Flush()
+		return nil
+	})
+	if ctx.Err() != nil {
+		// Klien sudah pergi, tidak ada yang perlu dikirim
+		return
+	}
+	if err != nil {
+		if !started {
+			apperror.Abort(c, conversationError(err))
+			return
+		}
+		// Header sudah terkirim, jadi error dikirim sebagai event berisi problem
+		slog.ErrorContext(ctx, "chat stream failed", "error", err)
+		c.SSEvent("error", apperror.ProblemFor(c, apperror.Internal(err)))
+		c.Writer.Flush()
+		return
+	}
+
+	startStream()
+	c.SSEvent("done", gin.H{
+		"productIds":     response.ProductIDs,
+		"conversationId": response.ConversationID,
+		"sessionId":      response.SessionID,
+	})
+	c.Writer.Flush()
+}
+
+// GetConversations menampilkan daftar percakapan milik pemanggil
+func (cc *ChatController) GetConversations(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	conversations, total, err := cc.chatService.ListConversations(c.Request.Context(), chatOwner(c), (page-1)*limit, limit)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if conversations == nil {
+		conversations = make([]models.Conversation, 0)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": conversations,
+		"meta": gin.H{
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
+}
+
+// GetConversation menampilkan isi lengkap sebuah percakapan milik pemanggil
+func (cc *ChatController) GetConversation(c *gin.Context) {
+	conversation, err := cc.chatService.GetConversation(c.Request.Context(), c.Param("id"), chatOwner(c))
+	if err != nil {
+		apperror.Abort(c, conversationError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// DeleteConversation menghapus percakapan milik pemanggil
+func (cc *ChatController) DeleteConversation(c *gin.Context) {
+	err := cc.chatService.DeleteConversation(c.Request.Context(), c.Param("id"), chatOwner(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses permintaan Anda"})
+		apperror.Abort(c, conversationError(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"reply": response})
+	c.JSON(http.StatusOK, gin.H{"message": "Percakapan berhasil dihapus"})
 }
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestContext membuat context dengan batas waktu untuk operasi database.
+// Nilai dari context request (request ID, user ID) ikut dibawa agar muncul di
+// log, tetapi pembatalannya tidak: operasi seperti checkout tetap selesai
+// walaupun klien memutus koneksi di tengah jalan.
+func requestContext(c *gin.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(c.Request.Context()), timeout)
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"tokobiru/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsController menyajikan dokumen OpenAPI dan halaman Swagger UI. Keduanya
+// dibuat sekali saat startup karena rute tidak berubah selama server berjalan.
+type DocsController struct {
+	spec []byte
+	ui   []byte
+}
+
+func NewDocsController(spec *openapi.Spec, specURL string) (*DocsController, error) {
+	document := spec.Document()
+	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("encode OpenAPI document: %w", err)
+	}
+	var ui bytes.Buffer
+	if err := openapi.WriteUI(&ui, document.Info.Title, specURL); err != nil {
+		return nil, fmt.Errorf("render API docs page: %w", err)
+	}
+	return &DocsController{spec: body, ui: ui.Bytes()}, nil
+}
+
+// GetSpec returns the OpenAPI document
+func (dc *DocsController) GetSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", dc.spec)
+}
+
+// GetUI returns the interactive API documentation
+func (dc *DocsController) GetUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", dc.ui)
+}
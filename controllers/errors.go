@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"tokobiru/apperror"
+	"tokobiru/services"
+)
+
+// serviceErrorCodes memetakan error bisnis dari services ke kode API
+var serviceErrorCodes = map[error]apperror.Code{
+	services.ErrInvalidUserToken:        apperror.CodeOneTimeTokenInvalid,
+	services.ErrInvalidRefreshToken:     apperror.CodeRefreshTokenInvalid,
+	services.ErrRefreshTokenReused:      apperror.CodeRefreshTokenReused,
+	services.ErrInvalidInvitation:       apperror.CodeInvitationInvalid,
+	services.ErrEmailRegistered:         apperror.CodeEmailTaken,
+	services.ErrInvalidMFACode:          apperror.CodeMFACodeInvalid,
+	services.ErrMFAAlreadyEnabled:       apperror.CodeMFAAlreadyEnabled,
+	services.ErrMFANotEnabled:           apperror.CodeMFANotEnabled,
+	services.ErrMFAEnrollmentNotStarted: apperror.CodeMFANotStarted,
+	services.ErrMFARequired:             apperror.CodeMFARequiredForRole,
+	services.ErrInvalidRoleName:         apperror.CodeRoleNameInvalid,
+	services.ErrUnknownPermission:       apperror.CodePermissionUnknown,
+	services.ErrBuiltInRole:             apperror.CodeRoleBuiltIn,
+	services.ErrRoleInUse:               apperror.CodeRoleInUse,
+	services.ErrLastAdmin:               apperror.CodeLastAdmin,
+}
+
+// serviceError mengubah error dari services menjadi error API. Error yang
+// tidak dikenal menjadi INTERNAL_ERROR.
+func serviceError(err error) *apperror.Error {
+	if code, ok := serviceErrorCodes[err]; ok {
+		return apperror.Wrap(code, err)
+	}
+	return apperror.Internal(err)
+}
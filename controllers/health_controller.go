@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	db  repositories.HealthChecker
+	llm func() services.LLMStatus
+}
+
+func NewHealthController(db repositories.HealthChecker, llm func() services.LLMStatus) *HealthController {
+	return &HealthController{db: db, llm: llm}
+}
+
+// Liveness hanya menandakan proses masih melayani request, tanpa memeriksa
+// dependensi, agar container tidak di-restart ketika MongoDB sedang down
+func (hc *HealthController) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness memeriksa koneksi MongoDB dan melaporkan provider LLM chatbot.
+// Provider yang jatuh ke rule-based tidak membuat aplikasi "not ready" karena
+// chatbot tetap bisa menjawab.
+func (hc *HealthController) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	status, code := "ok", http.StatusOK
+	mongo := gin.H{"status": "ok"}
+	start := time.Now()
+	if err := hc.db.Ping(ctx); err != nil {
+		status, code = "unavailable", http.StatusServiceUnavailable
+		mongo = gin.H{"status": "unavailable", "error": err.Error()}
+	} else {
+		mongo["latencyMs"] = time.Since(start).Milliseconds()
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": gin.H{
+			"mongodb": mongo,
+			"llm":     hc.llm(),
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/config"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvitationController struct {
+	invitations *services.InvitationService
+}
+
+func NewInvitationController(cfg config.Config, users repositories.UserRepository, invitations repositories.InvitationRepository) *InvitationController {
+	return &InvitationController{
+		invitations: services.NewInvitationService(users, invitations, cfg.InvitationTTL),
+	}
+}
+
+// AcceptInvitationInput adalah body untuk membuat akun dari undangan
+type AcceptInvitationInput struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// CreateInvitation issues a single-use admin invitation (Admin only).
+// The raw token is only returned in this response.
+func (ic *InvitationController) CreateInvitation(c *gin.Context) {
+	var req EmailInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	invitedBy, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		apperror.Abort(c, apperror.Wrap(apperror.CodeTokenInvalid, err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	invitation, token, err := ic.invitations.Invite(ctx, req.Email, invitedBy)
+	if err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invitation": invitation, "token": token})
+}
+
+// AcceptInvitation creates the invited account using a single-use invitation token
+func (ic *InvitationController) AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, err := ic.invitations.Accept(ctx, req.Token, req.Name, req.Password)
+	if err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
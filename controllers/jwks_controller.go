@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct {
+	keys *services.KeyRing
+}
+
+func NewJWKSController(keys *services.KeyRing) *JWKSController {
+	return &JWKSController{keys: keys}
+}
+
+// GetJWKS returns the public keys used to verify access tokens. Kunci yang
+// baru dibuat sudah muncul di sini sebelum dipakai menandatangani, sehingga
+// cache singkat di sisi klien aman.
+func (jc *JWKSController) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jc.keys.JWKS())
+}
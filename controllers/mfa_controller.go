@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/config"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MFAController mengelola two-factor authentication milik user yang sedang login
+type MFAController struct {
+	users repositories.UserRepository
+	mfa   *services.MFAService
+}
+
+func NewMFAController(cfg config.Config, users repositories.UserRepository) *MFAController {
+	return &MFAController{
+		users: users,
+		mfa:   services.NewMFAService(users, cfg.MFAIssuer, cfg.MFARequiredRoles),
+	}
+}
+
+// MFACodeInput adalah body berisi kode TOTP atau recovery code
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// currentUser mengambil user dari token. Respons error sudah dikirim jika ok bernilai false.
+func (mc *MFAController) currentUser(c *gin.Context, ctx context.Context) (*models.User, bool) {
+	userIDHex, _ := c.Get("userID")
+	userID, err := primitive.ObjectIDFromHex(userIDHex.(string))
+	if err != nil {
+		apperror.Abort(c, apperror.Wrap(apperror.CodeTokenInvalid, err))
+		return nil, false
+	}
+	user, err := mc.users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeUserNotFound))
+			return nil, false
+		}
+		apperror.Abort(c, apperror.Internal(err))
+		return nil, false
+	}
+	return user, true
+}
+
+// GetStatus returns whether 2FA is enabled or required for the current user
+func (mc *MFAController) GetStatus(c *gin.Context) {
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := mc.currentUser(c, ctx)
+	if !ok {
+		return
+	}
+	remaining := 0
+	if user.MFAEnabled && user.MFA != nil {
+		remaining = len(user.MFA.RecoveryCodes)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                user.MFAEnabled,
+		"required":               mc.mfa.RequiredForRole(user.Role),
+		"recoveryCodesRemaining": remaining,
+	})
+}
+
+// Enroll creates a new TOTP secret. 2FA is enabled once the secret is confirmed.
+func (mc *MFAController) Enroll(c *gin.Context) {
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := mc.currentUser(c, ctx)
+	if !ok {
+		return
+	}
+	enrollment, err := mc.mfa.BeginEnrollment(ctx, user)
+	if err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm enables 2FA with a code from the authenticator app and returns
+// recovery codes. The recovery codes are only shown once.
+func (mc *MFAController) Confirm(c *gin.Context) {
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := mc.currentUser(c, ctx)
+	if !ok {
+		return
+	}
+	codes, err := mc.mfa.ConfirmEnrollment(ctx, user, input.Code)
+	if err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// Disable turns off 2FA. Not allowed when the user's role requires 2FA.
+func (mc *MFAController) Disable(c *gin.Context) {
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := mc.currentUser(c, ctx)
+	if !ok {
+		return
+	}
+	if err := mc.mfa.Disable(ctx, user, input.Code); err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes
+func (mc *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, ok := mc.currentUser(c, ctx)
+	if !ok {
+		return
+	}
+	codes, err := mc.mfa.RegenerateRecoveryCodes(ctx, user, input.Code)
+	if err != nil {
+		apperror.Abort(c, serviceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
@@ -3,74 +3,126 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
-	"tokobiru/database"
+	"tokobiru/apperror"
+	"tokobiru/metrics"
 	"tokobiru/models"
+	"tokobiru/repositories"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type OrderController struct {
-	db *mongo.Client
+	orders   repositories.OrderRepository
+	carts    repositories.CartRepository
+	products repositories.ProductRepository
+	tx       repositories.Transactor
 }
 
-func NewOrderController(db *mongo.Client) *OrderController {
-	return &OrderController{db: db}
+func NewOrderController(orders repositories.OrderRepository, carts repositories.CartRepository, products repositories.ProductRepository, tx repositories.Transactor) *OrderController {
+	return &OrderController{orders: orders, carts: carts, products: products, tx: tx}
 }
 
-// Checkout (Versi Baru Tanpa Transaksi)
+// checkoutOutcomes memetakan error bisnis checkout ke label metrik
+// tokobiru_checkouts_total
+var checkoutOutcomes = map[apperror.Code]string{
+	apperror.CodeCartEmpty:         "empty_cart",
+	apperror.CodeProductNotFound:   "product_not_found",
+	apperror.CodeInsufficientStock: "out_of_stock",
+	apperror.CodeCheckoutConflict:  "conflict",
+}
+
+// Checkout mengubah keranjang menjadi pesanan. Stok dikurangi dengan $inc bersyarat
+// (stock >= qty) di dalam transaksi MongoDB, sehingga pesanan dibuat dan keranjang
+// dikosongkan sepenuhnya atau tidak ada yang berubah sama sekali. Pada deployment
+// standalone (tanpa replica set) dipakai saga dengan langkah kompensasi.
 func (oc *OrderController) Checkout(c *gin.Context) {
-	// ... (Fungsi ini tidak berubah) ...
 	userIDHex, _ := c.Get("userID")
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 
-	cartCollection := database.GetCollection("carts")
-	productCollection := database.GetCollection("products")
-	orderCollection := database.GetCollection("orders")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := requestContext(c, 30*time.Second)
 	defer cancel()
 
-	var cart models.Cart
-	err := cartCollection.FindOne(ctx, bson.M{"userId": userID}).Decode(&cart)
-	if err != nil || len(cart.Items) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Keranjang kosong atau tidak ditemukan"})
+	var newOrder *models.Order
+	err := oc.tx.RunInTransaction(ctx, func(txCtx context.Context) error {
+		order, err := oc.placeOrder(txCtx, userID, nil)
+		newOrder = order
+		return err
+	})
+	if err == repositories.ErrTransactionsNotSupported {
+		newOrder, err = oc.checkoutSaga(ctx, userID)
+	}
+
+	if err != nil {
+		appErr := apperror.From(err)
+		outcome, ok := checkoutOutcomes[appErr.Code]
+		if !ok {
+			outcome = "error"
+		}
+		metrics.Checkouts.WithLabelValues(outcome).Inc()
+		if appErr.Code == apperror.CodeInsufficientStock {
+			metrics.StockOuts.WithLabelValues("checkout").Inc()
+		}
+		apperror.Abort(c, appErr)
 		return
 	}
 
+	metrics.Checkouts.WithLabelValues("success").Inc()
+	c.JSON(http.StatusCreated, gin.H{"message": "Checkout berhasil", "order": newOrder})
+}
+
+// placeOrder menjalankan seluruh langkah checkout: mengurangi stok, membuat pesanan,
+// lalu menghapus keranjang. Jika compensations tidak nil, setiap langkah yang berhasil
+// mendaftarkan fungsi pembatalannya di sana (dipakai oleh saga).
+func (oc *OrderController) placeOrder(ctx context.Context, userID primitive.ObjectID, compensations *[]func(context.Context) error) (*models.Order, error) {
+	compensate := func(fn func(context.Context) error) {
+		if compensations != nil {
+			*compensations = append(*compensations, fn)
+		}
+	}
+
+	cart, err := oc.carts.FindByUserID(ctx, userID)
+	if err == repositories.ErrNotFound || (err == nil && len(cart.Items) == 0) {
+		return nil, apperror.New(apperror.CodeCartEmpty)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	var orderItems []models.OrderItem
-	var total float64
+	var total models.Money
 
 	for _, item := range cart.Items {
-		var product models.Product
-		err := productCollection.FindOne(ctx, bson.M{"_id": item.ProductID}).Decode(&product)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Produk dengan ID %s tidak ditemukan", item.ProductID.Hex())})
-			return
+		product, err := oc.products.FindByID(ctx, item.ProductID)
+		if err == repositories.ErrNotFound {
+			return nil, apperror.New(apperror.CodeProductNotFound).With("id", item.ProductID.Hex())
 		}
-		if product.Stock < item.Quantity {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stok untuk produk %s tidak mencukupi", product.Name)})
-			return
+		if err != nil {
+			return nil, err
 		}
 
-		newStock := product.Stock - item.Quantity
-		update := bson.M{"$set": bson.M{"stock": newStock}}
-		_, err = productCollection.UpdateOne(ctx, bson.M{"_id": item.ProductID}, update)
+		// Pengurangan stok atomik: hanya berhasil jika stok masih mencukupi
+		err = oc.products.DecrementStock(ctx, item.ProductID, item.Quantity)
+		if err == repositories.ErrInsufficientStock {
+			return nil, apperror.New(apperror.CodeInsufficientStock).With("product", product.Name)
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gagal memperbarui stok untuk produk %s", product.Name)})
-			return
+			return nil, err
 		}
+		productID, quantity := item.ProductID, item.Quantity
+		compensate(func(ctx context.Context) error {
+			return oc.products.IncrementStock(ctx, productID, quantity)
+		})
 
 		orderItems = append(orderItems, models.OrderItem{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
 			Price:     product.Price,
 		})
-		total += product.Price * float64(item.Quantity)
+		total = total.Add(product.Price.Mul(item.Quantity))
 	}
 
 	newOrder := models.Order{
@@ -79,23 +131,48 @@ func (oc *OrderController) Checkout(c *gin.Context) {
 		UserID:    userID,
 		Items:     orderItems,
 		Total:     total,
-		Status:    "baru",
+		Status:    models.OrderStatusNew,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	_, err = orderCollection.InsertOne(ctx, newOrder)
+	if err := oc.orders.Create(ctx, &newOrder); err != nil {
+		return nil, err
+	}
+	compensate(func(ctx context.Context) error {
+		return oc.orders.Delete(ctx, newOrder.ID)
+	})
+
+	err = oc.carts.Delete(ctx, cart.ID)
+	if err == repositories.ErrNotFound {
+		// Keranjang sudah di-checkout oleh request lain secara bersamaan
+		return nil, apperror.New(apperror.CodeCheckoutConflict)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat pesanan"})
-		return
+		return nil, err
 	}
 
-	_, err = cartCollection.DeleteOne(ctx, bson.M{"_id": cart.ID})
-	if err != nil {
-		log.Printf("Peringatan: Gagal menghapus keranjang untuk user %s setelah checkout", userID.Hex())
+	return &newOrder, nil
+}
+
+// checkoutSaga menjalankan placeOrder tanpa transaksi dan membatalkan semua langkah
+// yang sudah berhasil (dalam urutan terbalik) jika ada langkah yang gagal.
+func (oc *OrderController) checkoutSaga(ctx context.Context, userID primitive.ObjectID) (*models.Order, error) {
+	var compensations []func(context.Context) error
+	order, err := oc.placeOrder(ctx, userID, &compensations)
+	if err == nil {
+		return order, nil
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Checkout berhasil", "order": newOrder})
+	// Gunakan context baru agar kompensasi tetap berjalan walau ctx sudah timeout
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	for i := len(compensations) - 1; i >= 0; i-- {
+		if cErr := compensations[i](rollbackCtx); cErr != nil {
+			slog.ErrorContext(rollbackCtx, "checkout compensation failed", "step", i, "error", cErr)
+		}
+	}
+	return nil, err
 }
 
 // GetUserOrders retrieves all orders for the logged-in user
@@ -103,23 +180,15 @@ func (oc *OrderController) GetUserOrders(c *gin.Context) {
 	userIDHex, _ := c.Get("userID")
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 
-	orderCollection := database.GetCollection("orders")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	cursor, err := orderCollection.Find(ctx, bson.M{"userId": userID})
+	orders, err := oc.orders.FindByUserID(ctx, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
-		return
-	}
-
-	var orders []models.Order
-	if err = cursor.All(ctx, &orders); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode orders"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	// --- PERBAIKAN DI SINI ---
 	// Pastikan kita selalu mengembalikan array, bukan 'null' jika tidak ada pesanan.
 	if orders == nil {
 		orders = make([]models.Order, 0)
@@ -130,28 +199,25 @@ func (oc *OrderController) GetUserOrders(c *gin.Context) {
 
 // GetOrderByID retrieves a single order by its ID for the logged-in user
 func (oc *OrderController) GetOrderByID(c *gin.Context) {
-	// ... (Fungsi ini tidak berubah) ...
 	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	userIDHex, _ := c.Get("userID")
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 
-	orderCollection := database.GetCollection("orders")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	var order models.Order
-	err = orderCollection.FindOne(ctx, bson.M{"_id": orderID, "userId": userID}).Decode(&order)
+	order, err := oc.orders.FindByIDForUser(ctx, orderID, userID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeOrderNotFound))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order details"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
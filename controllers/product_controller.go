@@ -1,48 +1,44 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"time"
-	"tokobiru/database"
+	"tokobiru/apperror"
 	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductController struct {
-	db *mongo.Client
+	products repositories.ProductRepository
 }
 
-func NewProductController(db *mongo.Client) *ProductController {
-	return &ProductController{db: db}
+func NewProductController(products repositories.ProductRepository) *ProductController {
+	return &ProductController{products: products}
 }
 
 // Create a new product (Admin only)
 func (pc *ProductController) CreateProduct(c *gin.Context) {
 	var product models.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
-	productCollection := database.GetCollection("products")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
 	product.ID = primitive.NewObjectID()
-	// --- PERBAIKAN DI SINI ---
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
+	product.Embedding = services.EmbedProduct(product)
 
-	_, err := productCollection.InsertOne(ctx, product)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+	if err := pc.products.Create(ctx, &product); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -51,48 +47,36 @@ func (pc *ProductController) CreateProduct(c *gin.Context) {
 
 // Get all products with filtering and pagination
 func (pc *ProductController) GetProducts(c *gin.Context) {
-	// ... (Fungsi ini tidak berubah) ...
-	productCollection := database.GetCollection("products")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-
-	if name := c.Query("name"); name != "" {
-		filter["name"] = bson.M{"$regex": name, "$options": "i"}
-	}
-
-	if category := c.Query("category"); category != "" {
-		filter["category"] = category
-	}
-
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
-	skip := (page - 1) * limit
-
-	findOptions := options.Find()
-	findOptions.SetSkip(skip)
-	findOptions.SetLimit(limit)
-
-	cursor, err := productCollection.Find(ctx, filter, findOptions)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
-		return
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
 	}
-	defer cursor.Close(ctx)
 
-	var products []models.Product
-	if err = cursor.All(ctx, &products); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode products"})
-		return
+	filter := repositories.ProductFilter{
+		Name:     c.Query("name"),
+		Category: c.Query("category"),
+		Skip:     (page - 1) * limit,
+		Limit:    limit,
 	}
 
-	total, err := productCollection.CountDocuments(ctx, filter)
+	products, total, err := pc.products.List(ctx, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count products"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
+	// Pastikan kita selalu mengembalikan array, bukan 'null'
+	if products == nil {
+		products = make([]models.Product, 0)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": products,
 		"meta": gin.H{
@@ -106,25 +90,22 @@ func (pc *ProductController) GetProducts(c *gin.Context) {
 
 // Get a single product by ID
 func (pc *ProductController) GetProductByID(c *gin.Context) {
-	// ... (Fungsi ini tidak berubah) ...
 	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
-	productCollection := database.GetCollection("products")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	var product models.Product
-	err = productCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	product, err := pc.products.FindByID(ctx, productID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeProductNotFound))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -135,40 +116,28 @@ func (pc *ProductController) GetProductByID(c *gin.Context) {
 func (pc *ProductController) UpdateProduct(c *gin.Context) {
 	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	var productUpdate models.Product
 	if err := c.ShouldBindJSON(&productUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
-	productCollection := database.GetCollection("products")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"name":        productUpdate.Name,
-			"description": productUpdate.Description,
-			"price":       productUpdate.Price,
-			"stock":       productUpdate.Stock,
-			"category":    productUpdate.Category,
-			"image_url":   productUpdate.ImageURL,
-			"updated_at":  time.Now(), // --- PERBAIKAN DI SINI ---
-		},
-	}
-
-	result, err := productCollection.UpdateOne(ctx, bson.M{"_id": productID}, update)
+	productUpdate.UpdatedAt = time.Now()
+	productUpdate.Embedding = services.EmbedProduct(productUpdate)
+	err = pc.products.Update(ctx, productID, &productUpdate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
-		return
-	}
-
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeProductNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -177,25 +146,22 @@ func (pc *ProductController) UpdateProduct(c *gin.Context) {
 
 // Delete a product (Admin only)
 func (pc *ProductController) DeleteProduct(c *gin.Context) {
-	// ... (Fungsi ini tidak berubah) ...
 	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
-	productCollection := database.GetCollection("products")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	result, err := productCollection.DeleteOne(ctx, bson.M{"_id": productID})
+	err = pc.products.Delete(ctx, productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
-		return
-	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeProductNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RoleController struct {
+	authz *services.AuthorizationService
+}
+
+func NewRoleController(authz *services.AuthorizationService) *RoleController {
+	return &RoleController{authz: authz}
+}
+
+// RoleInput adalah body untuk membuat atau mengubah role
+type RoleInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// AssignRoleInput adalah body untuk mengganti role user
+type AssignRoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// GetPermissions returns every permission that can be granted to a role
+func (rc *RoleController) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, models.Permissions)
+}
+
+// GetRoles lists all roles with their permissions
+func (rc *RoleController) GetRoles(c *gin.Context) {
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	roles, err := rc.authz.ListRoles(ctx)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole creates a custom role
+func (rc *RoleController) CreateRole(c *gin.Context) {
+	var input RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	role, err := rc.authz.CreateRole(ctx, input.Name, input.Description, input.Permissions)
+	if err != nil {
+		apperror.Abort(c, roleError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole replaces the description and permissions of a role
+func (rc *RoleController) UpdateRole(c *gin.Context) {
+	var input RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	role, err := rc.authz.UpdateRole(ctx, c.Param("name"), input.Description, input.Permissions)
+	if err != nil {
+		apperror.Abort(c, roleError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes a custom role that is no longer assigned to any user
+func (rc *RoleController) DeleteRole(c *gin.Context) {
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if err := rc.authz.DeleteRole(ctx, c.Param("name")); err != nil {
+		apperror.Abort(c, roleError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// AssignUserRole changes the role of a user. The new role applies from the
+// user's next access token.
+func (rc *RoleController) AssignUserRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	var req AssignRoleInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Abort(c, apperror.Binding(err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	if err := rc.authz.AssignRole(ctx, userID, req.Role); err != nil {
+		apperror.Abort(c, roleError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
+}
+
+// roleError memetakan error dari AuthorizationService ke kode API
+func roleError(err error) *apperror.Error {
+	switch err {
+	case repositories.ErrNotFound:
+		return apperror.New(apperror.CodeRoleNotFound)
+	case repositories.ErrDuplicate:
+		return apperror.New(apperror.CodeRoleExists)
+	}
+	return serviceError(err)
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/config"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SecurityController struct {
+	users repositories.UserRepository
+	audit repositories.AuditRepository
+	guard *services.LoginGuard
+}
+
+func NewSecurityController(cfg config.Config, users repositories.UserRepository, loginAttempts repositories.LoginAttemptRepository, audit repositories.AuditRepository) *SecurityController {
+	return &SecurityController{
+		users: users,
+		audit: audit,
+		guard: services.NewLoginGuard(loginAttempts, audit, services.LoginPolicyFromConfig(cfg)),
+	}
+}
+
+// UnlockUser clears the failed login counter of a locked account
+func (sc *SecurityController) UnlockUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+	actor, _ := c.Get("userID")
+	actorID, err := primitive.ObjectIDFromHex(actor.(string))
+	if err != nil {
+		apperror.Abort(c, apperror.Wrap(apperror.CodeTokenInvalid, err))
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	user, err := sc.users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeUserNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if err := sc.guard.Unlock(ctx, user, actorID); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked successfully"})
+}
+
+// GetAuditLogs lists security audit entries, newest first.
+// Optional filters: action and userId.
+func (sc *SecurityController) GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := repositories.AuditFilter{
+		Action: c.Query("action"),
+		Skip:   (page - 1) * limit,
+		Limit:  limit,
+	}
+	if userID := c.Query("userId"); userID != "" {
+		id, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			apperror.Abort(c, apperror.InvalidID("userId"))
+			return
+		}
+		filter.UserID = id
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second)
+	defer cancel()
+
+	entries, total, err := sc.audit.List(ctx, filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if entries == nil {
+		entries = make([]models.AuditLog, 0)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": entries,
+		"meta": gin.H{
+			"total":       total,
+			"page":        page,
+			"limit":       limit,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
+}
@@ -1,26 +1,31 @@
 package controllers
 
 import (
-	"context"
+	"errors"
 	"net/http"
 	"time"
-	"tokobiru/database"
+	"tokobiru/apperror"
+	"tokobiru/repositories"
 	"tokobiru/services"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // UserController untuk mengelola operasi terkait user
 type UserController struct {
-	db *mongo.Client
+	users repositories.UserRepository
 }
 
 // NewUserController membuat instance baru dari UserController
-func NewUserController(db *mongo.Client) *UserController {
-	return &UserController{db: db}
+func NewUserController(users repositories.UserRepository) *UserController {
+	return &UserController{users: users}
+}
+
+// ProfileInput adalah body untuk mengubah profil sendiri. Field kosong tidak diubah.
+type ProfileInput struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
 }
 
 // UpdateUserProfile mengizinkan pengguna yang sudah login untuk memperbarui
@@ -29,67 +34,52 @@ func (uc *UserController) UpdateUserProfile(c *gin.Context) {
 	// Mengambil userID dari token yang sudah divalidasi oleh middleware
 	userIDHex, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		apperror.Abort(c, apperror.Internal(errors.New("user ID not found in context")))
 		return
 	}
 	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
 
 	// Menangkap data dari body request
-	var req struct {
-		Name     string `json:"name"`
-		Password string `json:"password"`
-	}
+	var req ProfileInput
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.Binding(err))
 		return
 	}
 
-	userCollection := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c, 10*time.Second)
 	defer cancel()
 
-	// Mempersiapkan field yang akan di-update
-	updateFields := bson.M{}
-	if req.Name != "" {
-		updateFields["name"] = req.Name
+	// Jika tidak ada data yang dikirim, kembalikan error
+	if req.Name == "" && req.Password == "" {
+		apperror.Abort(c, apperror.New(apperror.CodeNothingToUpdate))
+		return
 	}
 
+	var hashedPassword string
 	if req.Password != "" {
 		// Validasi sederhana untuk panjang password
 		if len(req.Password) < 6 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 6 characters long"})
+			apperror.Abort(c, apperror.Validation(apperror.FieldError{Field: "password", Rule: "min_len", Param: "6"}))
 			return
 		}
 		// Hash password baru sebelum disimpan
-		hashedPassword, err := services.HashPassword(req.Password)
+		var err error
+		hashedPassword, err = services.HashPassword(req.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash new password"})
+			apperror.Abort(c, apperror.Internal(err))
 			return
 		}
-		updateFields["password"] = hashedPassword
-	}
-
-	// Jika tidak ada data yang dikirim, kembalikan error
-	if len(updateFields) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update provided"})
-		return
 	}
 
-	// Selalu perbarui timestamp `updated_at`
-	updateFields["updated_at"] = time.Now()
-
-	updateQuery := bson.M{"$set": updateFields}
-
-	// Menjalankan query update ke database
-	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": userID}, updateQuery)
+	// Menjalankan query update ke database (updated_at selalu diperbarui)
+	err := uc.users.UpdateProfile(ctx, userID, req.Name, hashedPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
-		return
-	}
-
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		if err == repositories.ErrNotFound {
+			apperror.Abort(c, apperror.New(apperror.CodeUserNotFound))
+			return
+		}
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"reflect"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Aturan binding pada field Money (required, gt=0, ...) berlaku untuk
+	// nominalnya dalam minor unit
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+			if money, ok := field.Interface().(models.Money); ok {
+				return money.Amount
+			}
+			return nil
+		}, models.Money{})
+
+		// Toko hanya memakai satu mata uang. Total pesanan dan laporan
+		// penjualan menjumlahkan harga tanpa konversi kurs, jadi harga
+		// produk dalam mata uang lain ditolak sejak awal.
+		v.RegisterStructValidation(func(sl validator.StructLevel) {
+			product := sl.Current().Interface().(models.Product)
+			if product.Price.Currency != "" && product.Price.Currency != models.DefaultCurrency {
+				sl.ReportError(product.Price, "price", "Price", "currency", models.DefaultCurrency)
+			}
+		}, models.Product{})
+	}
+}
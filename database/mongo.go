@@ -4,9 +4,12 @@ import (
 	"context"
 	"log"
 	"time"
+	"tokobiru/metrics"
+	"tokobiru/tracing"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 // DB instance
@@ -17,7 +20,7 @@ func ConnectDB(uri, dbName string) *mongo.Client {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetMonitor(tracing.CombineMonitors(metrics.MongoMonitor(), otelmongo.NewMonitor())))
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
@@ -0,0 +1,165 @@
+// Package fixtures memuat data awal (user dan produk) dari file JSON ke
+// database. Dipakai oleh seeder dan oleh perintah `tokobiru seed`.
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:embed demo.json
+var demo []byte
+
+// Fixtures adalah isi file fixture
+type Fixtures struct {
+	Users    []User           `json:"users"`
+	Products []models.Product `json:"products"`
+}
+
+// User adalah akun di file fixture. Password ditulis apa adanya dan di-hash
+// saat dimuat.
+type User struct {
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Password      string `json:"password"`
+	Role          string `json:"role"` // Default "customer"
+	EmailVerified bool   `json:"emailVerified"`
+}
+
+// Result mencatat berapa data yang dibuat dan dilewati karena sudah ada
+type Result struct {
+	UsersCreated    int
+	UsersSkipped    int
+	ProductsCreated int
+	ProductsSkipped int
+}
+
+// Demo mengembalikan fixture bawaan berisi akun admin, akun customer dan
+// beberapa produk contoh
+func Demo() *Fixtures {
+	fixtures, err := Parse(bytes.NewReader(demo))
+	if err != nil {
+		panic(fmt.Sprintf("fixtures: invalid demo.json: %v", err))
+	}
+	return fixtures
+}
+
+// Parse membaca fixture JSON. Field yang tidak dikenal ditolak agar salah
+// ketik tidak diam-diam diabaikan.
+func Parse(r io.Reader) (*Fixtures, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var fixtures Fixtures
+	if err := decoder.Decode(&fixtures); err != nil {
+		return nil, err
+	}
+	for i, user := range fixtures.Users {
+		if user.Email == "" || user.Password == "" {
+			return nil, fmt.Errorf("users[%d]: email and password are required", i)
+		}
+		if user.Role == "" {
+			fixtures.Users[i].Role = models.RoleCustomer
+		}
+	}
+	for i := range fixtures.Products {
+		if err := ValidateProduct(&fixtures.Products[i]); err != nil {
+			return nil, fmt.Errorf("products[%d]: %w", i, err)
+		}
+	}
+	return &fixtures, nil
+}
+
+// ValidateProduct memeriksa aturan yang sama dengan validasi produk di API
+func ValidateProduct(product *models.Product) error {
+	product.Name = strings.TrimSpace(product.Name)
+	switch {
+	case product.Name == "":
+		return errors.New("name is required")
+	case product.Description == "":
+		return errors.New("description is required")
+	case product.Category == "":
+		return errors.New("category is required")
+	case product.Price.Amount <= 0:
+		return errors.New("price must be greater than 0")
+	case product.Stock < 0:
+		return errors.New("stock must not be negative")
+	}
+	return nil
+}
+
+// Apply memuat fixture ke store. User yang emailnya sudah terdaftar dan
+// produk yang namanya sudah ada dilewati, sehingga Apply aman dijalankan
+// berulang kali.
+func Apply(ctx context.Context, store *repositories.Store, fixtures *Fixtures) (Result, error) {
+	var result Result
+	if err := services.NewAuthorizationService(store.Roles, store.Users).EnsureDefaultRoles(ctx); err != nil {
+		return result, fmt.Errorf("create default roles: %w", err)
+	}
+
+	now := time.Now()
+	for _, fixture := range fixtures.Users {
+		exists, err := store.Users.ExistsByEmail(ctx, fixture.Email)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			result.UsersSkipped++
+			continue
+		}
+		hashedPassword, err := services.HashPassword(fixture.Password)
+		if err != nil {
+			return result, err
+		}
+		user := &models.User{
+			ID:            primitive.NewObjectID(),
+			Name:          fixture.Name,
+			Email:         fixture.Email,
+			Password:      hashedPassword,
+			Role:          fixture.Role,
+			EmailVerified: fixture.EmailVerified,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		err = store.Users.Create(ctx, user)
+		if err == repositories.ErrDuplicate {
+			result.UsersSkipped++
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("create user %s: %w", fixture.Email, err)
+		}
+		result.UsersCreated++
+	}
+
+	for _, product := range fixtures.Products {
+		_, err := store.Products.FindByName(ctx, product.Name)
+		if err == nil {
+			result.ProductsSkipped++
+			continue
+		}
+		if err != repositories.ErrNotFound {
+			return result, err
+		}
+		product.ID = primitive.NewObjectID()
+		product.CreatedAt, product.UpdatedAt = now, now
+		// Sertakan embedding lokal agar produk bisa ditemukan oleh retrieval chatbot
+		product.Embedding = services.EmbedProduct(product)
+		if err := store.Products.Create(ctx, &product); err != nil {
+			return result, fmt.Errorf("create product %q: %w", product.Name, err)
+		}
+		result.ProductsCreated++
+	}
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLoggerAddsContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), "user-1")
+	logger.With("component", "checkout").InfoContext(ctx, "order placed", "order_id", "o-1")
+	logger.DebugContext(ctx, "hidden below info level")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{
+		"msg":        "order placed",
+		"level":      "INFO",
+		"request_id": "req-1",
+		"user_id":    "user-1",
+		"component":  "checkout",
+		"order_id":   "o-1",
+	} {
+		if line[key] != want {
+			t.Errorf("%s: got %v, want %q", key, line[key], want)
+		}
+	}
+}
+
+func TestNewLoggerRejectsUnknownSettings(t *testing.T) {
+	if _, err := NewLogger(&bytes.Buffer{}, "verbose", "json"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, err := NewLogger(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
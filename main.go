@@ -1,10 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"tokobiru/config"
 	"tokobiru/database"
+	"tokobiru/logging"
+	"tokobiru/migrations"
+	"tokobiru/repositories"
 	"tokobiru/routes"
+	"tokobiru/services"
+	"tokobiru/tracing"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,21 +29,129 @@ func main() {
 		log.Fatalf("Could not load config: %v", err)
 	}
 
+	// Log JSON terstruktur; paket log standar ikut diarahkan ke slog
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("Could not set up logging: %v", err)
+	}
+
+	// ctx dibatalkan saat menerima SIGINT/SIGTERM untuk memulai graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Tracing OpenTelemetry; tanpa TRACING_ENABLED semua span adalah no-op
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Enabled:     cfg.TracingEnabled,
+		EndpointURL: cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("could not set up tracing", err)
+	}
+	if cfg.TracingEnabled {
+		slog.Info("tracing enabled", "endpoint", cfg.TracingEndpoint, "sample_ratio", cfg.TracingSampleRatio)
+	}
+
 	// Connect to MongoDB
-	dbClient := database.ConnectDB(cfg.MongoURI, cfg.MongoDatabase)
+	client := database.ConnectDB(cfg.MongoURI, cfg.MongoDatabase)
 
 	// Set Gin to release mode for production
 	// gin.SetMode(gin.ReleaseMode)
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Initialize Gin router. Logging dan recovery dipasang di SetupRoutes.
+	router := gin.New()
+
+	// Initialize repositories backed by MongoDB
+	store := repositories.NewMongoStore(database.DB)
+
+	// Index dan perubahan skema dikelola oleh migrasi berversi
+	migrator := migrations.NewMongoMigrator(database.DB)
+	if cfg.MigrateOnStartup {
+		// Jika instance lain sedang menjalankan migrasi, tunggu sampai selesai
+		// agar server tidak melayani request dengan skema yang belum lengkap
+		migrateCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		_, err := migrator.UpWhenUnlocked(migrateCtx, 2*time.Second)
+		cancel()
+		if err != nil {
+			fatal("could not apply database migrations", err)
+		}
+	} else {
+		pendingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		pending, err := migrator.Pending(pendingCtx)
+		cancel()
+		if err != nil {
+			slog.Warn("could not check database migrations", "error", err)
+		} else if len(pending) > 0 {
+			slog.Warn("database has pending migrations, run ./tokobiru migrate up", "pending", len(pending))
+		}
+	}
+
+	// Role bawaan (admin, customer, warehouse, support) dibuat jika belum ada
+	rolesCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	if err := services.NewAuthorizationService(store.Roles, store.Users).EnsureDefaultRoles(rolesCtx); err != nil {
+		fatal("could not create default roles", err)
+	}
+	cancel()
+
+	// Kunci penandatangan JWT dimuat dari disk dan dirotasi di background
+	keys, err := services.NewKeyRing(services.KeyRingOptionsFromConfig(cfg))
+	if err != nil {
+		fatal("could not load JWT signing keys", err)
+	}
+	go keys.Run(time.Minute, ctx.Done())
+
+	// Email verifikasi dan reset password dikirim lewat MAIL_DRIVER
+	mailer, err := services.NewMailer(cfg)
+	if err != nil {
+		fatal("could not create mailer", err)
+	}
 
 	// Setup routes
-	routes.SetupRoutes(router, dbClient)
+	if err := routes.SetupRoutes(router, store, cfg, keys, mailer); err != nil {
+		fatal("could not set up routes", err)
+	}
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.ServerPort)
-	if err := router.Run(":" + cfg.ServerPort); err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+	server := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", cfg.ServerPort)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("failed to run server", err)
+	case <-ctx.Done():
 	}
+
+	// Berhenti menerima koneksi baru dan tunggu request yang sedang berjalan,
+	// termasuk stream chatbot, sampai ShutdownTimeout
+	stop()
+	slog.Info("shutting down server", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("graceful shutdown timed out", "error", err)
+		server.Close()
+	}
+	if err := client.Disconnect(shutdownCtx); err != nil {
+		slog.Warn("could not disconnect from MongoDB", "error", err)
+	}
+	// Span yang masih di buffer dikirim ke collector sebelum proses berhenti
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("could not flush traces", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// fatal mencatat error lalu menghentikan proses
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,109 @@
+// Package metrics mendefinisikan semua metrik Prometheus aplikasi. Metrik
+// didaftarkan di registry default sehingga metrik runtime Go dan proses ikut
+// diekspos di /metrics.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const namespace = "tokobiru"
+
+var (
+	// HTTP, dilabeli dengan pola route Gin (bukan path asli) agar kardinalitas tetap kecil
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// MongoDB, diisi oleh command monitor driver
+	MongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "MongoDB command latency by command name and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "outcome"})
+
+	// LLM chatbot
+	LLMRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_requests_total",
+		Help:      "LLM provider calls by provider and outcome (success or error).",
+	}, []string{"provider", "outcome"})
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "LLM provider call latency.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 20, 40},
+	}, []string{"provider"})
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens reported by the LLM provider, by type (prompt or completion).",
+	}, []string{"provider", "type"})
+
+	// Kejadian bisnis
+	Checkouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checkouts_total",
+		Help:      "Checkout attempts by outcome: success, or the reason it failed.",
+	}, []string{"outcome"})
+	CartAdditions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cart_additions_total",
+		Help:      "Items successfully added to a cart.",
+	})
+	StockOuts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_out_events_total",
+		Help:      "Requests rejected because a product did not have enough stock, by stage (cart or checkout).",
+	}, []string{"stage"})
+)
+
+// Handler mengekspos metrik dalam format Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveLLMCall mencatat satu pemanggilan provider LLM
+func ObserveLLMCall(provider string, duration time.Duration, promptTokens, completionTokens int, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	LLMRequests.WithLabelValues(provider, outcome).Inc()
+	LLMRequestDuration.WithLabelValues(provider).Observe(duration.Seconds())
+	if promptTokens > 0 {
+		LLMTokens.WithLabelValues(provider, "prompt").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		LLMTokens.WithLabelValues(provider, "completion").Add(float64(completionTokens))
+	}
+}
+
+// MongoMonitor mengembalikan command monitor yang mencatat durasi setiap
+// perintah MongoDB. Pasang lewat options.Client().SetMonitor.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			MongoCommandDuration.WithLabelValues(e.CommandName, "success").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			MongoCommandDuration.WithLabelValues(e.CommandName, "failure").Observe(e.Duration.Seconds())
+		},
+	}
+}
@@ -1,9 +1,11 @@
 package middlewares
 
 import (
-	"net/http"
+	"errors"
 	"strings"
-	"tokobiru/config"
+	"tokobiru/apperror"
+	"tokobiru/logging"
+	"tokobiru/repositories"
 	"tokobiru/services"
 
 	"github.com/gin-gonic/gin"
@@ -11,55 +13,82 @@ import (
 
 // AuthMiddleware adalah middleware untuk memeriksa token JWT.
 // Middleware ini harus dijalankan pertama untuk rute yang diproteksi.
-func AuthMiddleware() gin.HandlerFunc {
+// Token yang jti atau session-nya ada di daftar pencabutan ditolak.
+func AuthMiddleware(keys *services.KeyRing, revocations repositories.RevocationRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		cfg, err := config.LoadConfig()
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not load configuration"})
-			return
-		}
-
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			apperror.Abort(c, apperror.New(apperror.CodeAuthRequired))
 			return
 		}
 
 		// Memeriksa format "Bearer <token>"
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format, 'Bearer' prefix not found"})
+			apperror.Abort(c, apperror.New(apperror.CodeTokenInvalid))
 			return
 		}
 
 		// Memanggil fungsi dari paket 'services' untuk validasi
-		claims, err := services.ValidateToken(tokenString, cfg.JWTSecretKey)
+		claims, err := services.ValidateToken(tokenString, keys)
+		if err != nil {
+			apperror.Abort(c, apperror.Wrap(apperror.CodeTokenInvalid, err))
+			return
+		}
+
+		// Token yang sudah logout atau session-nya dicabut tidak boleh dipakai lagi
+		revoked, err := revocations.IsRevoked(c.Request.Context(), claims.ID, claims.SessionID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "details": err.Error()})
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		if revoked {
+			apperror.Abort(c, apperror.New(apperror.CodeTokenRevoked))
 			return
 		}
 
 		// Menyimpan info user ke dalam context untuk digunakan oleh handler lain
 		c.Set("userID", claims.UserID)
 		c.Set("userRole", claims.Role)
+		c.Set("tokenClaims", claims)
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), claims.UserID))
 
 		// Lanjut ke handler/middleware berikutnya
 		c.Next()
 	}
 }
 
-// RoleMiddleware adalah middleware untuk memeriksa role user.
+// OptionalAuthMiddleware sama seperti AuthMiddleware, tetapi request tanpa
+// header Authorization tetap diteruskan sebagai pengguna anonim. Token yang
+// dikirim namun tidak valid tetap ditolak.
+func OptionalAuthMiddleware(keys *services.KeyRing, revocations repositories.RevocationRepository) gin.HandlerFunc {
+	auth := AuthMiddleware(keys, revocations)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// RequirePermission adalah middleware untuk memeriksa permission dari role user.
 // Middleware ini harus dijalankan SETELAH AuthMiddleware.
-func RoleMiddleware(requiredRole string) gin.HandlerFunc {
+func RequirePermission(authz *services.AuthorizationService, permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("userRole")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User role not found in context. Ensure AuthMiddleware runs first."})
+			apperror.Abort(c, apperror.Internal(errors.New("user role not found in context, AuthMiddleware must run first")))
 			return
 		}
 
-		if userRole.(string) != requiredRole {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource"})
+		allowed, err := authz.HasPermissions(c.Request.Context(), userRole.(string), permissions...)
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		if !allowed {
+			apperror.Abort(c, apperror.New(apperror.CodePermissionDenied))
 			return
 		}
 
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"tokobiru/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler menulis respons untuk error yang didaftarkan handler lewat
+// apperror.Abort sebagai application/problem+json. Pesan dipilih dari
+// Accept-Language (id atau en). Penyebab error 5xx hanya dicatat di log.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		problem := apperror.ProblemFor(c, err)
+		ctx := c.Request.Context()
+		if problem.Status >= http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "request failed", "code", problem.Code, "error", err)
+		} else {
+			slog.DebugContext(ctx, "request rejected", "code", problem.Code, "error", err)
+		}
+
+		body, _ := json.Marshal(problem)
+		c.Header("Content-Language", string(apperror.Language(c.GetHeader("Accept-Language"))))
+		c.Header("Vary", "Accept-Language")
+		c.Data(problem.Status, apperror.ContentType, body)
+	}
+}
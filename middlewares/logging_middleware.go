@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader membawa correlation ID dari klien atau proxy
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID membatasi ID dari klien agar aman ditulis ke log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID memakai header X-Request-ID dari klien jika valid, atau membuat
+// yang baru. ID dikirim balik di response dan disimpan di context request
+// agar ikut tercatat di setiap log.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// RequestLogger menulis satu baris log per request setelah handler selesai.
+// Response 5xx dicatat sebagai error dan 4xx sebagai warning.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if errs := c.Errors.String(); errs != "" {
+			attrs = append(attrs, slog.String("errors", errs))
+		}
+		// c.Request.Context() sudah berisi user ID jika AuthMiddleware berjalan
+		slog.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// Recovery menangkap panic di handler, mencatatnya beserta stack trace dan
+// mengembalikan INTERNAL_ERROR lewat ErrorHandler
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.ErrorContext(c.Request.Context(), "panic recovered",
+					"panic", recovered,
+					"stack", string(debug.Stack()),
+				)
+				apperror.Abort(c, apperror.Internal(fmt.Errorf("panic: %v", recovered)))
+			}
+		}()
+		c.Next()
+	}
+}
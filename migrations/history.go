@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lockID adalah _id dokumen lock di koleksi migrations. Catatan migrasi
+// memakai nomor versi sebagai _id, jadi keduanya tidak bertabrakan.
+const lockID = "lock"
+
+// MongoHistory menyimpan catatan migrasi di koleksi "migrations"
+type MongoHistory struct {
+	collection *mongo.Collection
+}
+
+// NewMongoHistory membuat instance baru dari MongoHistory
+func NewMongoHistory(db *mongo.Database) *MongoHistory {
+	return &MongoHistory{collection: db.Collection("migrations")}
+}
+
+func (h *MongoHistory) Applied(ctx context.Context) ([]Record, error) {
+	cursor, err := h.collection.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (h *MongoHistory) Insert(ctx context.Context, record Record) error {
+	_, err := h.collection.InsertOne(ctx, record)
+	return err
+}
+
+func (h *MongoHistory) Delete(ctx context.Context, version int) error {
+	_, err := h.collection.DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
+
+// Lock mengambil lock secara atomik: upsert hanya cocok dengan lock yang
+// sudah kedaluwarsa, sehingga jika lock masih dipegang proses lain upsert
+// mencoba membuat dokumen dengan _id yang sama dan gagal dengan duplicate key.
+func (h *MongoHistory) Lock(ctx context.Context, owner string, ttl time.Duration) error {
+	now := time.Now()
+	_, err := h.collection.UpdateOne(ctx,
+		bson.M{"_id": lockID, "expires_at": bson.M{"$lt": now}},
+		bson.M{"$set": bson.M{"owner": owner, "locked_at": now, "expires_at": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
+
+func (h *MongoHistory) Unlock(ctx context.Context, owner string) error {
+	_, err := h.collection.DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner})
+	return err
+}
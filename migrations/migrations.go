@@ -0,0 +1,264 @@
+// Package migrations menjalankan perubahan skema dan index MongoDB secara
+// berurutan. Setiap migrasi punya nomor versi; versi yang sudah diterapkan
+// dicatat di koleksi "migrations" sehingga setiap migrasi hanya berjalan
+// sekali per database.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrLocked dikembalikan jika proses lain sedang menjalankan migrasi
+	ErrLocked = errors.New("migrations are locked by another process")
+	// ErrIrreversible dikembalikan oleh Down untuk migrasi tanpa fungsi Down
+	ErrIrreversible = errors.New("migration cannot be reverted")
+)
+
+// lockTTL membatasi berapa lama lock dipegang, agar proses yang mati di
+// tengah migrasi tidak mengunci database selamanya
+const lockTTL = 10 * time.Minute
+
+// Migration adalah satu perubahan database. Up dan Down sebaiknya aman
+// dijalankan ulang, karena migrasi yang gagal di tengah jalan tidak dicatat
+// dan akan dicoba lagi dari awal.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// All mengembalikan semua migrasi aplikasi, diurutkan berdasarkan versi.
+// Migrasi baru ditambahkan di akhir dengan versi berikutnya; migrasi yang
+// sudah dirilis tidak boleh diubah.
+func All() []Migration {
+	return []Migration{
+		baselineIndexes,
+		userCartOrderIndexes,
+		moneyMinorUnits,
+		productNameIndex,
+	}
+}
+
+// Record adalah catatan migrasi yang sudah diterapkan
+type Record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// History menyimpan catatan migrasi dan lock agar hanya satu proses yang
+// menjalankan migrasi pada satu waktu
+type History interface {
+	Applied(ctx context.Context) ([]Record, error)
+	Insert(ctx context.Context, record Record) error
+	Delete(ctx context.Context, version int) error
+	// Lock mengembalikan ErrLocked jika lock masih dipegang proses lain
+	Lock(ctx context.Context, owner string, ttl time.Duration) error
+	Unlock(ctx context.Context, owner string) error
+}
+
+// Status adalah keadaan satu migrasi di database
+type Status struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	Applied     bool      `json:"applied"`
+	AppliedAt   time.Time `json:"appliedAt,omitempty"`
+	// Unknown berarti versi ini tercatat di database tetapi tidak dikenal oleh
+	// binary ini, misalnya setelah rollback ke rilis yang lebih lama
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// Migrator menerapkan dan membatalkan migrasi pada sebuah database
+type Migrator struct {
+	db         *mongo.Database
+	history    History
+	migrations []Migration
+	owner      string
+}
+
+// NewMigrator membuat instance baru dari Migrator
+func NewMigrator(db *mongo.Database, history History, migrations []Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		history:    history,
+		migrations: sorted,
+		owner:      fmt.Sprintf("%s/%d", hostname, os.Getpid()),
+	}
+}
+
+// NewMongoMigrator membuat Migrator untuk semua migrasi aplikasi dengan
+// catatan di koleksi "migrations" pada database yang sama
+func NewMongoMigrator(db *mongo.Database) *Migrator {
+	return NewMigrator(db, NewMongoHistory(db), All())
+}
+
+// Status mengembalikan semua migrasi yang dikenal beserta versi yang tercatat
+// di database tetapi tidak dikenal
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.history.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied, status.AppliedAt = true, record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{
+			Version: record.Version, Description: record.Description,
+			Applied: true, AppliedAt: record.AppliedAt, Unknown: true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending mengembalikan migrasi yang belum diterapkan
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up menerapkan semua migrasi yang belum diterapkan secara berurutan dan
+// berhenti pada migrasi pertama yang gagal
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func() error {
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			start := time.Now()
+			if err := migration.Up(ctx, m.db); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+			record := Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+			if err := m.history.Insert(ctx, record); err != nil {
+				return err
+			}
+			slog.Info("migration applied", "version", migration.Version, "description", migration.Description,
+				"duration_ms", time.Since(start).Milliseconds())
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// UpWhenUnlocked seperti Up, tetapi jika proses lain sedang memegang lock
+// migrasi, Up dicoba lagi setiap interval sampai lock dilepas. Dengan begitu
+// instance yang start bersamaan tidak melayani request sebelum semua migrasi
+// selesai, dan jika migrasi di proses lain gagal, proses ini mencobanya
+// sendiri. Mengembalikan error ctx jika ctx habis sebelum lock didapat.
+func (m *Migrator) UpWhenUnlocked(ctx context.Context, interval time.Duration) ([]Migration, error) {
+	for {
+		done, err := m.Up(ctx)
+		if !errors.Is(err, ErrLocked) {
+			return done, err
+		}
+		slog.Info("waiting for migrations running in another process")
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrLocked, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Down membatalkan sejumlah steps migrasi terakhir yang sudah diterapkan,
+// dimulai dari versi tertinggi
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func() error {
+		records, err := m.history.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].Version > records[j].Version })
+		if steps < len(records) {
+			records = records[:steps]
+		}
+		for _, record := range records {
+			migration, ok := m.find(record.Version)
+			if !ok {
+				return fmt.Errorf("migration %d (%s) is not known to this binary", record.Version, record.Description)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, ErrIrreversible)
+			}
+			if err := migration.Down(ctx, m.db); err != nil {
+				return fmt.Errorf("revert migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+			if err := m.history.Delete(ctx, migration.Version); err != nil {
+				return err
+			}
+			slog.Info("migration reverted", "version", migration.Version, "description", migration.Description)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	if err := m.history.Lock(ctx, m.owner, lockTTL); err != nil {
+		return err
+	}
+	// Lock tetap dilepas walaupun ctx sudah dibatalkan
+	defer m.history.Unlock(context.WithoutCancel(ctx), m.owner)
+	return fn()
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	records, err := m.history.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool, len(records))
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
@@ -0,0 +1,208 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryHistory adalah History in-memory untuk pengujian Migrator
+type memoryHistory struct {
+	mu      sync.Mutex
+	records map[int]Record
+	owner   string
+}
+
+func newMemoryHistory() *memoryHistory {
+	return &memoryHistory{records: make(map[int]Record)}
+}
+
+func (h *memoryHistory) Applied(ctx context.Context) ([]Record, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var records []Record
+	for _, record := range h.records {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (h *memoryHistory) Insert(ctx context.Context, record Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records[record.Version] = record
+	return nil
+}
+
+func (h *memoryHistory) Delete(ctx context.Context, version int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.records, version)
+	return nil
+}
+
+func (h *memoryHistory) Lock(ctx context.Context, owner string, ttl time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.owner != "" {
+		return ErrLocked
+	}
+	h.owner = owner
+	return nil
+}
+
+func (h *memoryHistory) Unlock(ctx context.Context, owner string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.owner == owner {
+		h.owner = ""
+	}
+	return nil
+}
+
+// recorder membuat migrasi yang mencatat urutan Up dan Down
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) migration(version int, reversible bool) Migration {
+	name := string(rune('a' + version - 1))
+	migration := Migration{
+		Version:     version,
+		Description: "migration " + name,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			r.calls = append(r.calls, "up "+name)
+			return nil
+		},
+	}
+	if reversible {
+		migration.Down = func(ctx context.Context, db *mongo.Database) error {
+			r.calls = append(r.calls, "down "+name)
+			return nil
+		}
+	}
+	return migration
+}
+
+func TestUpAppliesPendingMigrationsInOrderOnce(t *testing.T) {
+	ctx := context.Background()
+	var r recorder
+	history := newMemoryHistory()
+	migrator := NewMigrator(nil, history, []Migration{r.migration(2, true), r.migration(1, true)})
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("up: got %d applied, err %v", len(applied), err)
+	}
+	if !slices.Equal(r.calls, []string{"up a", "up b"}) {
+		t.Fatalf("migrations should run by version, got %v", r.calls)
+	}
+
+	// Migrasi baru di rilis berikutnya hanya menjalankan versi yang belum ada
+	migrator = NewMigrator(nil, history, []Migration{r.migration(1, true), r.migration(2, true), r.migration(3, true)})
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 1 || applied[0].Version != 3 {
+		t.Fatalf("second up: got %+v, err %v", applied, err)
+	}
+	if pending, _ := migrator.Pending(ctx); len(pending) != 0 {
+		t.Fatalf("nothing should be pending, got %+v", pending)
+	}
+	if history.owner != "" {
+		t.Fatal("lock should be released")
+	}
+}
+
+func TestUpStopsAtFailingMigration(t *testing.T) {
+	ctx := context.Background()
+	var r recorder
+	failing := errors.New("duplicate key")
+	broken := Migration{Version: 2, Description: "broken", Up: func(ctx context.Context, db *mongo.Database) error { return failing }}
+	history := newMemoryHistory()
+	migrator := NewMigrator(nil, history, []Migration{r.migration(1, true), broken, r.migration(3, true)})
+
+	applied, err := migrator.Up(ctx)
+	if !errors.Is(err, failing) || len(applied) != 1 {
+		t.Fatalf("got %d applied, err %v", len(applied), err)
+	}
+	if pending, _ := migrator.Pending(ctx); len(pending) != 2 || pending[0].Version != 2 {
+		t.Fatalf("failed migration should stay pending, got %+v", pending)
+	}
+}
+
+func TestDownRevertsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	var r recorder
+	history := newMemoryHistory()
+	migrator := NewMigrator(nil, history, []Migration{r.migration(1, false), r.migration(2, true), r.migration(3, true)})
+	migrator.Up(ctx)
+	r.calls = nil
+
+	reverted, err := migrator.Down(ctx, 2)
+	if err != nil || len(reverted) != 2 || !slices.Equal(r.calls, []string{"down c", "down b"}) {
+		t.Fatalf("down: got %v, err %v", r.calls, err)
+	}
+	if _, err := migrator.Down(ctx, 1); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("migration without Down: got %v, want ErrIrreversible", err)
+	}
+
+	statuses, _ := migrator.Status(ctx)
+	if len(statuses) != 3 || !statuses[0].Applied || statuses[1].Applied || statuses[2].Applied {
+		t.Fatalf("unexpected status %+v", statuses)
+	}
+}
+
+func TestMigratorRespectsLockAndReportsUnknownVersions(t *testing.T) {
+	ctx := context.Background()
+	var r recorder
+	history := newMemoryHistory()
+	history.Insert(ctx, Record{Version: 9, Description: "from a newer release"})
+	migrator := NewMigrator(nil, history, []Migration{r.migration(1, true)})
+
+	history.Lock(ctx, "other-instance", time.Minute)
+	if _, err := migrator.Up(ctx); !errors.Is(err, ErrLocked) || len(r.calls) != 0 {
+		t.Fatalf("locked up: got %v, calls %v", err, r.calls)
+	}
+	history.Unlock(ctx, "other-instance")
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil || len(statuses) != 2 || statuses[1].Version != 9 || !statuses[1].Unknown {
+		t.Fatalf("unexpected status %+v, err %v", statuses, err)
+	}
+	if _, err := migrator.Down(ctx, 1); err == nil {
+		t.Fatal("reverting an unknown version should fail")
+	}
+}
+
+func TestUpWhenUnlockedWaitsForOtherProcess(t *testing.T) {
+	var r recorder
+	history := newMemoryHistory()
+	migrator := NewMigrator(nil, history, []Migration{r.migration(1, true)})
+	history.Lock(context.Background(), "other-instance", time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := migrator.UpWhenUnlocked(ctx, time.Millisecond); !errors.Is(err, ErrLocked) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout: got %v", err)
+	}
+
+	time.AfterFunc(10*time.Millisecond, func() { history.Unlock(context.Background(), "other-instance") })
+	applied, err := migrator.UpWhenUnlocked(context.Background(), time.Millisecond)
+	if err != nil || len(applied) != 1 {
+		t.Fatalf("after unlock: got %+v, err %v", applied, err)
+	}
+}
+
+func TestAllMigrationsAreOrderedAndReversible(t *testing.T) {
+	for i, migration := range All() {
+		if migration.Version != i+1 {
+			t.Errorf("migration %q has version %d, want %d", migration.Description, migration.Version, i+1)
+		}
+		if migration.Description == "" || migration.Up == nil || migration.Down == nil {
+			t.Errorf("migration %d is incomplete", migration.Version)
+		}
+	}
+}
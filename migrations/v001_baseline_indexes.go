@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// baselineIndexes membuat index yang sebelumnya dibuat setiap kali server
+// start: text index produk, riwayat chatbot, session dan token login,
+// undangan, percobaan login dan audit log. Database yang sudah punya index
+// ini tidak berubah karena pembuatan index bersifat idempoten.
+var baselineIndexes = Migration{
+	Version:     1,
+	Description: "baseline indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		steps := []func(context.Context) error{
+			repositories.NewMongoProductRepository(db).EnsureSearchIndex,
+			repositories.NewMongoConversationRepository(db).EnsureIndexes,
+			repositories.NewMongoSessionRepository(db).EnsureIndexes,
+			repositories.NewMongoRevocationRepository(db).EnsureIndexes,
+			repositories.NewMongoInvitationRepository(db).EnsureIndexes,
+			repositories.NewMongoUserTokenRepository(db).EnsureIndexes,
+			repositories.NewMongoLoginAttemptRepository(db).EnsureIndexes,
+			repositories.NewMongoAuditRepository(db).EnsureIndexes,
+		}
+		for _, step := range steps {
+			if err := step(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		if err := dropIndexes(ctx, db.Collection("products"), "product_text"); err != nil {
+			return err
+		}
+		// Koleksi lain hanya punya index dari migrasi ini
+		for _, name := range []string{"conversations", "sessions", "token_revocations", "invitations", "user_tokens", "login_attempts", "audit_logs"} {
+			if _, err := db.Collection(name).Indexes().DropAll(ctx); err != nil && !isNamespaceNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// userCartOrderIndexes menambahkan index untuk query yang paling sering
+// dipakai. Index unik pada users.email dan carts.userId membuat database
+// menolak email ganda dan keranjang ganda saat dua request berjalan
+// bersamaan; jika data lama sudah berisi duplikat, migrasi ini gagal dan
+// duplikatnya harus dibereskan lebih dulu.
+var userCartOrderIndexes = Migration{
+	Version:     2,
+	Description: "user, cart, order and product indexes",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		indexes := map[string][]mongo.IndexModel{
+			"users": {
+				{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName("email_unique").SetUnique(true)},
+			},
+			"carts": {
+				{Keys: bson.D{{Key: "userId", Value: 1}}, Options: options.Index().SetName("userId_unique").SetUnique(true)},
+			},
+			"orders": {
+				// Riwayat pesanan pelanggan, terbaru lebih dulu
+				{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("userId_created_at")},
+				// Rentang tanggal laporan penjualan
+				{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "status", Value: 1}}, Options: options.Index().SetName("created_at_status")},
+			},
+			"products": {
+				{Keys: bson.D{{Key: "category", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("category_id")},
+			},
+		}
+		for _, name := range []string{"users", "carts", "orders", "products"} {
+			if _, err := db.Collection(name).Indexes().CreateMany(ctx, indexes[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		drops := []struct {
+			collection string
+			indexes    []string
+		}{
+			{"users", []string{"email_unique"}},
+			{"carts", []string{"userId_unique"}},
+			{"orders", []string{"userId_created_at", "created_at_status"}},
+			{"products", []string{"category_id"}},
+		}
+		for _, drop := range drops {
+			if err := dropIndexes(ctx, db.Collection(drop.collection), drop.indexes...); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
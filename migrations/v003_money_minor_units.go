@@ -0,0 +1,83 @@
+package migrations
+
+import (
+	"context"
+	"math"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// moneyMinorUnits mengubah harga dan total yang masih tersimpan sebagai angka
+// (rupiah penuh) menjadi dokumen Money dalam minor unit, yaitu
+// products.price, carts.items.price, orders.total dan orders.items.price.
+// Nilai yang sudah berbentuk Money tidak diubah. Down mengembalikannya ke
+// angka dalam satuan utama.
+var moneyMinorUnits = Migration{
+	Version:     3,
+	Description: "store prices and totals as integer minor units",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		return convertMoneyFields(ctx, db, bson.M{"$type": "number"}, toMoneyExpr)
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		return convertMoneyFields(ctx, db, bson.M{"$type": "object"}, toNumberExpr)
+	},
+}
+
+// convertMoneyFields menjalankan update pipeline pada setiap field uang yang
+// cocok dengan match, memakai convert untuk membentuk nilai barunya
+func convertMoneyFields(ctx context.Context, db *mongo.Database, match bson.M, convert func(field string) bson.M) error {
+	items := bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+		"as":    "item",
+		"in": bson.M{"$mergeObjects": bson.A{
+			"$$item",
+			bson.M{"price": convert("$$item.price")},
+		}},
+	}}
+	steps := []struct {
+		collection string
+		filter     bson.M
+		set        bson.M
+	}{
+		{"products", bson.M{"price": match}, bson.M{"price": convert("$price")}},
+		{"carts", bson.M{"items.price": match}, bson.M{"items": items}},
+		{"orders", bson.M{"$or": bson.A{bson.M{"total": match}, bson.M{"items.price": match}}}, bson.M{
+			"total": convert("$total"),
+			"items": items,
+		}},
+	}
+	for _, step := range steps {
+		update := mongo.Pipeline{{{Key: "$set", Value: step.set}}}
+		if _, err := db.Collection(step.collection).UpdateMany(ctx, step.filter, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func minorUnitFactor() float64 {
+	return math.Pow10(models.CurrencyExponent(models.DefaultCurrency))
+}
+
+// toMoneyExpr mengubah angka menjadi dokumen Money dan membiarkan nilai lain
+func toMoneyExpr(field string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isNumber": field},
+		bson.M{
+			"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, minorUnitFactor()}}, 0}}},
+			"currency": models.DefaultCurrency,
+		},
+		field,
+	}}
+}
+
+// toNumberExpr mengubah dokumen Money kembali menjadi angka dalam satuan utama
+func toNumberExpr(field string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": field}, "object"}},
+		bson.M{"$divide": bson.A{field + ".amount", minorUnitFactor()}},
+		field,
+	}}
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// productNameIndex mendukung pencarian produk berdasarkan nama persis yang
+// dipakai oleh seed dan import produk. Pencarian nama di daftar produk juga
+// diarahkan ke index ini agar regex dievaluasi pada key index, bukan pada
+// setiap dokumen. Index ini tidak unik karena nama produk lama belum tentu unik.
+var productNameIndex = Migration{
+	Version:     4,
+	Description: "product name index",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("products").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetName("name"),
+		})
+		return err
+	},
+	Down: func(ctx context.Context, db *mongo.Database) error {
+		return dropIndexes(ctx, db.Collection("products"), "name")
+	},
+}
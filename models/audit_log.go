@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Aksi yang dicatat di audit log
+const (
+	AuditAccountLocked   = "account_locked"
+	AuditAccountUnlocked = "account_unlocked"
+	AuditIPLocked        = "ip_locked"
+	AuditPasswordReset   = "password_reset" // Password diganti operator lewat CLI admin
+)
+
+// AuditLog adalah catatan kejadian keamanan
+type AuditLog struct {
+	ID     primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Action string              `bson:"action" json:"action"`
+	UserID *primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"`
+	Email  string              `bson:"email,omitempty" json:"email,omitempty"`
+	IP     string              `bson:"ip,omitempty" json:"ip,omitempty"`
+	// ActorID adalah admin yang melakukan aksi, kosong jika dilakukan sistem
+	ActorID   *primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"`
+	Details   string              `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
@@ -11,7 +11,7 @@ type CartItem struct {
 	ProductID primitive.ObjectID `bson:"productId" json:"productId"`
 	Quantity  int                `bson:"quantity" json:"quantity"`
 	Name      string             `bson:"name" json:"name"`
-	Price     float64            `bson:"price" json:"price"`
+	Price     Money              `bson:"price" json:"price"`
 	ImageURL  string             `bson:"image_url" json:"image_url"`
 }
 
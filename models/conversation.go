@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Peran pengirim pesan dalam percakapan chatbot
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage adalah satu giliran dalam percakapan chatbot
+type ChatMessage struct {
+	Role       string    `bson:"role" json:"role"`
+	Content    string    `bson:"content" json:"content"`
+	ProductIDs []string  `bson:"productIds,omitempty" json:"productIds,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Conversation menyimpan riwayat percakapan chatbot. Pemiliknya adalah user
+// yang login (UserID) atau sesi anonim (SessionID).
+type Conversation struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID       *primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"`
+	SessionID    string              `bson:"sessionId,omitempty" json:"sessionId,omitempty"`
+	Title        string              `bson:"title" json:"title"`
+	Messages     []ChatMessage       `bson:"messages" json:"messages,omitempty"`
+	MessageCount int                 `bson:"messageCount" json:"messageCount"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updated_at"`
+}
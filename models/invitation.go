@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation adalah undangan sekali pakai untuk membuat akun admin.
+// Hanya hash token yang disimpan.
+type Invitation struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email      string             `bson:"email" json:"email"`
+	Role       string             `bson:"role" json:"role"`
+	TokenHash  string             `bson:"tokenHash" json:"-"`
+	InvitedBy  primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+	AcceptedAt *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginAttempt menghitung login gagal untuk satu kunci ("email:<email>" atau
+// "ip:<alamat>"). Dokumen dihapus otomatis oleh TTL index setelah ExpiresAt.
+type LoginAttempt struct {
+	Key           string     `bson:"_id" json:"key"`
+	Failures      int        `bson:"failures" json:"failures"`
+	LastFailureAt time.Time  `bson:"last_failure_at" json:"last_failure_at"`
+	LockedUntil   *time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	ExpiresAt     time.Time  `bson:"expires_at" json:"expires_at"`
+}
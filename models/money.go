@@ -0,0 +1,243 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// DefaultCurrency dipakai untuk nilai tanpa mata uang, termasuk harga lama
+// yang masih tersimpan sebagai angka float
+const DefaultCurrency = "IDR"
+
+// currencyExponents adalah jumlah digit minor unit per mata uang (ISO 4217)
+var currencyExponents = map[string]int{
+	"IDR": 2,
+	"USD": 2,
+	"EUR": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"JPY": 0,
+}
+
+// Money adalah nominal uang dalam minor unit (misalnya sen untuk IDR) beserta
+// kode mata uang ISO 4217. Semua perhitungan memakai int64 sehingga total dan
+// laporan tidak terkena pembulatan float.
+//
+// Di JSON dan BSON Money berbentuk {"amount": <minor unit>, "currency": "IDR"}.
+// Angka biasa tetap diterima dan dianggap nominal dalam satuan utama mata
+// uang default, agar dokumen lama dan klien yang mengirim "price": 85000
+// tetap bekerja.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// NewMoney membuat Money dari nominal dalam minor unit
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// IDR membuat Money dari nominal dalam rupiah penuh
+func IDR(rupiah int64) Money {
+	return Money{Amount: rupiah * 100, Currency: "IDR"}
+}
+
+// MoneyFromMajor mengubah nominal dalam satuan utama (format lama) menjadi
+// Money, dibulatkan ke minor unit terdekat
+func MoneyFromMajor(value float64, currency string) Money {
+	return Money{Amount: int64(math.Round(value * math.Pow10(CurrencyExponent(currency)))), Currency: currency}
+}
+
+// CurrencyExponent mengembalikan jumlah digit minor unit sebuah mata uang
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Add menjumlahkan dua nominal dengan mata uang yang sama. Money kosong
+// mengikuti mata uang nominal lainnya.
+func (m Money) Add(other Money) Money {
+	if m.Currency == "" {
+		m.Currency = other.Currency
+	}
+	m.Amount += other.Amount
+	return m
+}
+
+// Mul mengalikan nominal, misalnya harga satuan dengan jumlah barang
+func (m Money) Mul(quantity int) Money {
+	m.Amount *= int64(quantity)
+	return m
+}
+
+// Div membagi nominal dan membulatkan ke minor unit terdekat (setengah
+// dibulatkan menjauhi nol), misalnya untuk nilai rata-rata
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		return Money{Currency: m.Currency}
+	}
+	quotient, remainder := m.Amount/n, m.Amount%n
+	if 2*abs(remainder) >= abs(n) {
+		if (m.Amount < 0) != (n < 0) {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return Money{Amount: quotient, Currency: m.Currency}
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Split memisahkan nominal menjadi satuan utama dan sisa minor unit, untuk
+// ditampilkan
+func (m Money) Split() (major, minor int64) {
+	unit := int64(math.Pow10(CurrencyExponent(m.Currency)))
+	return m.Amount / unit, abs(m.Amount % unit)
+}
+
+// String memformat nominal gaya Indonesia, misalnya "Rp 85.000" atau
+// "USD 12,50". Desimal hanya ditulis jika minor unitnya tidak nol.
+func (m Money) String() string {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	prefix := currency + " "
+	if currency == "IDR" {
+		prefix = "Rp "
+	}
+	if m.Amount < 0 {
+		prefix = "-" + prefix
+	}
+
+	major, minor := m.Split()
+	digits := strconv.FormatInt(abs(major), 10)
+	var out strings.Builder
+	out.WriteString(prefix)
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte('.')
+		}
+		out.WriteRune(digit)
+	}
+	if minor != 0 {
+		fmt.Fprintf(&out, ",%0*d", CurrencyExponent(currency), minor)
+	}
+	return out.String()
+}
+
+// Decimal menulis nominal dalam satuan utama tanpa simbol dan pemisah ribuan,
+// misalnya "85000.00", untuk ekspor CSV
+func (m Money) Decimal() string {
+	major, minor := m.Split()
+	exponent := CurrencyExponent(m.Currency)
+	sign := ""
+	if m.Amount < 0 {
+		sign, major = "-", -major
+	}
+	if exponent == 0 {
+		return sign + strconv.FormatInt(major, 10)
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, major, exponent, minor)
+}
+
+// moneyDocument adalah bentuk Money di JSON dan BSON, tanpa method agar tidak
+// memanggil codec Money secara rekursif
+type moneyDocument struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+func (d moneyDocument) money() (Money, error) {
+	if d.Currency == "" {
+		d.Currency = DefaultCurrency
+	}
+	if _, ok := currencyExponents[d.Currency]; !ok {
+		return Money{}, fmt.Errorf("unsupported currency %q", d.Currency)
+	}
+	return Money{Amount: d.Amount, Currency: d.Currency}, nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return json.Marshal(moneyDocument(m))
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] != '{' {
+		var major float64
+		if err := json.Unmarshal(data, &major); err != nil {
+			return err
+		}
+		*m = MoneyFromMajor(major, DefaultCurrency)
+		return nil
+	}
+	var document moneyDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	money, err := document.money()
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return bson.MarshalValue(moneyDocument(m))
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeNull, bson.TypeUndefined:
+		*m = Money{}
+		return nil
+	case bson.TypeDouble:
+		*m = MoneyFromMajor(value.Double(), DefaultCurrency)
+		return nil
+	case bson.TypeInt32:
+		*m = MoneyFromMajor(float64(value.Int32()), DefaultCurrency)
+		return nil
+	case bson.TypeInt64:
+		*m = MoneyFromMajor(float64(value.Int64()), DefaultCurrency)
+		return nil
+	case bson.TypeEmbeddedDocument:
+		var document moneyDocument
+		if err := value.Unmarshal(&document); err != nil {
+			return err
+		}
+		money, err := document.money()
+		if err != nil {
+			return err
+		}
+		*m = money
+		return nil
+	}
+	return fmt.Errorf("cannot decode BSON %s into Money", t)
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMoneyJSONAcceptsLegacyNumbers(t *testing.T) {
+	var product struct {
+		Price Money `json:"price"`
+	}
+	if err := json.Unmarshal([]byte(`{"price": 85000.5}`), &product); err != nil {
+		t.Fatal(err)
+	}
+	if product.Price != NewMoney(8500050, "IDR") {
+		t.Fatalf("legacy number: got %+v", product.Price)
+	}
+
+	if err := json.Unmarshal([]byte(`{"price": {"amount": 1250, "currency": "USD"}}`), &product); err != nil {
+		t.Fatal(err)
+	}
+	if product.Price != NewMoney(1250, "USD") {
+		t.Fatalf("object: got %+v", product.Price)
+	}
+	if err := json.Unmarshal([]byte(`{"price": {"amount": 1, "currency": "XYZ"}}`), &product); err == nil {
+		t.Fatal("unknown currency should be rejected")
+	}
+
+	body, _ := json.Marshal(IDR(85000))
+	if string(body) != `{"amount":8500000,"currency":"IDR"}` {
+		t.Fatalf("marshal: got %s", body)
+	}
+}
+
+func TestMoneyBSONAcceptsLegacyDocuments(t *testing.T) {
+	type order struct {
+		Total Money `bson:"total"`
+	}
+	for name, document := range map[string]bson.M{
+		"double":   {"total": 85000.0},
+		"int32":    {"total": int32(85000)},
+		"int64":    {"total": int64(85000)},
+		"document": {"total": bson.M{"amount": int64(8500000), "currency": "IDR"}},
+	} {
+		raw, _ := bson.Marshal(document)
+		var decoded order
+		if err := bson.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if decoded.Total != IDR(85000) {
+			t.Errorf("%s: got %+v", name, decoded.Total)
+		}
+	}
+
+	raw, _ := bson.Marshal(order{Total: IDR(1)})
+	var stored bson.M
+	bson.Unmarshal(raw, &stored)
+	if total, ok := stored["total"].(bson.M); !ok || total["amount"] != int64(100) || total["currency"] != "IDR" {
+		t.Fatalf("marshal: got %+v", stored)
+	}
+}
+
+func TestMoneyArithmeticAndFormatting(t *testing.T) {
+	total := Money{}.Add(IDR(85000).Mul(2)).Add(IDR(60000))
+	if total != IDR(230000) {
+		t.Fatalf("add: got %+v", total)
+	}
+	if avg := NewMoney(1000, "IDR").Div(3); avg.Amount != 333 {
+		t.Fatalf("div: got %+v", avg)
+	}
+	if avg := NewMoney(1001, "IDR").Div(2); avg.Amount != 501 {
+		t.Fatalf("div should round half away from zero: got %+v", avg)
+	}
+
+	for money, want := range map[Money]string{
+		IDR(85000):               "Rp 85.000",
+		IDR(1250000):             "Rp 1.250.000",
+		NewMoney(8500050, "IDR"): "Rp 85.000,50",
+		NewMoney(1205, "USD"):    "USD 12,05",
+		NewMoney(1500, "JPY"):    "JPY 1.500",
+		NewMoney(-250000, "IDR"): "-Rp 2.500",
+	} {
+		if got := money.String(); got != want {
+			t.Errorf("String(%+v) = %q, want %q", money, got, want)
+		}
+	}
+	if got := NewMoney(-8500050, "IDR").Decimal(); got != "-85000.50" {
+		t.Errorf("Decimal = %q", got)
+	}
+}
@@ -6,11 +6,23 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Order statuses
+const (
+	OrderStatusNew        = "baru"
+	OrderStatusProcessing = "diproses"
+	OrderStatusShipped    = "dikirim"
+	OrderStatusCompleted  = "selesai"
+	OrderStatusCancelled  = "dibatalkan"
+)
+
+// OrderStatuses lists every valid order status in lifecycle order
+var OrderStatuses = []string{OrderStatusNew, OrderStatusProcessing, OrderStatusShipped, OrderStatusCompleted, OrderStatusCancelled}
+
 // OrderItem represents a single item within an order
 type OrderItem struct {
 	ProductID primitive.ObjectID `bson:"productId" json:"productId"`
 	Quantity  int                `bson:"quantity" json:"quantity"`
-	Price     float64            `bson:"price" json:"price"` // Price at the time of order
+	Price     Money              `bson:"price" json:"price"` // Price at the time of order
 }
 
 // Order model
@@ -19,8 +31,8 @@ type Order struct {
 	OrderID   string             `bson:"orderId" json:"orderId"` // Custom, more friendly order ID
 	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
 	Items     []OrderItem        `bson:"items" json:"items"`
-	Total     float64            `bson:"total" json:"total"`
-	Status    string             `bson:"status" json:"status"` // "baru", "diproses", "dikirim", "selesai", "dibatalkan"
+	Total     Money              `bson:"total" json:"total"`
+	Status    string             `bson:"status" json:"status"` // One of OrderStatuses
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TopSellingProduct is a best-selling product entry in the sales report
+type TopSellingProduct struct {
+	ProductID      primitive.ObjectID `bson:"_id" json:"_id"`
+	TotalSold      int                `bson:"totalSold" json:"totalSold"`
+	TotalRevenue   Money              `bson:"totalRevenue" json:"totalRevenue"`
+	ProductDetails Product            `bson:"productDetails" json:"productDetails"`
+}
+
+// StatusBreakdown groups orders by their status
+type StatusBreakdown struct {
+	Status  string `bson:"_id" json:"status"`
+	Count   int64  `bson:"count" json:"count"`
+	Revenue Money  `bson:"revenue" json:"revenue"`
+}
+
+// SalesBucket is the revenue of a single day/week/month period (WIB)
+type SalesBucket struct {
+	Period  string `json:"period"` // Start of the period, YYYY-MM-DD
+	Revenue Money  `json:"revenue"`
+	Orders  int64  `json:"orders"`
+}
+
+// SalesReport is the response of the admin sales report endpoint
+type SalesReport struct {
+	From               string              `json:"from,omitempty"`
+	To                 string              `json:"to,omitempty"`
+	Interval           string              `json:"interval"` // "day", "week" or "month"
+	Timezone           string              `json:"timezone"`
+	TotalRevenue       Money               `json:"totalRevenue"`
+	TotalOrders        int64               `json:"totalOrders"`
+	AverageOrderValue  Money               `json:"averageOrderValue"`
+	TopSellingProducts []TopSellingProduct `json:"topSellingProducts"`
+	StatusBreakdown    []StatusBreakdown   `json:"statusBreakdown"`
+	TimeSeries         []SalesBucket       `json:"timeSeries"`
+}
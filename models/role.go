@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Permission yang dipakai oleh middleware RequirePermission
+const (
+	PermProductsWrite      = "products:write"
+	PermCartManage         = "cart:manage"
+	PermOrdersOwn          = "orders:own"
+	PermOrdersRead         = "orders:read"
+	PermOrdersUpdateStatus = "orders:update_status"
+	PermUsersRead          = "users:read"
+	PermReportsRead        = "reports:read"
+	PermConversationsRead  = "conversations:read"
+	PermInvitationsCreate  = "invitations:create"
+	PermRolesManage        = "roles:manage"
+	PermUsersUnlock        = "users:unlock"
+	PermAuditRead          = "audit:read"
+)
+
+// Permissions adalah daftar semua permission yang dikenal
+var Permissions = []string{
+	PermProductsWrite,
+	PermCartManage,
+	PermOrdersOwn,
+	PermOrdersRead,
+	PermOrdersUpdateStatus,
+	PermUsersRead,
+	PermReportsRead,
+	PermConversationsRead,
+	PermInvitationsCreate,
+	PermRolesManage,
+	PermUsersUnlock,
+	PermAuditRead,
+}
+
+// Role memetakan nama role ke kumpulan permission. Nama role dipakai sebagai
+// _id dan disimpan di field role milik User.
+type Role struct {
+	Name        string    `bson:"_id" json:"name"`
+	Description string    `bson:"description" json:"description"`
+	Permissions []string  `bson:"permissions" json:"permissions"`
+	BuiltIn     bool      `bson:"builtIn" json:"builtIn"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session adalah satu refresh token. Setiap refresh menghasilkan Session baru
+// dalam keluarga (FamilyID) yang sama, dan Session lama ditandai RotatedAt.
+// Hanya hash token yang disimpan.
+type Session struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	FamilyID  string             `bson:"familyId" json:"familyId"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	IP        string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	RotatedAt *time.Time         `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -6,13 +6,34 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Role yang dimiliki user
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+)
+
 // User model
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name      string             `bson:"name" json:"name" binding:"required"`
-	Email     string             `bson:"email" json:"email" binding:"required,email"`
-	Password  string             `bson:"password" json:"password,omitempty" binding:"required"`
-	Role      string             `bson:"role" json:"role"` // "admin" or "customer"
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name     string             `bson:"name" json:"name" binding:"required"`
+	Email    string             `bson:"email" json:"email" binding:"required,email"`
+	Password string             `bson:"password" json:"password,omitempty" binding:"required"`
+	Role     string             `bson:"role" json:"role"` // "admin" or "customer"
+	// EmailVerified bernilai true setelah user membuka link verifikasi email
+	EmailVerified bool `bson:"emailVerified" json:"emailVerified"`
+	// MFAEnabled bernilai true jika login membutuhkan kode TOTP
+	MFAEnabled bool      `bson:"mfaEnabled" json:"mfaEnabled"`
+	MFA        *UserMFA  `bson:"mfa,omitempty" json:"-"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// UserMFA menyimpan konfigurasi TOTP milik user. Tidak pernah dikirim ke klien.
+type UserMFA struct {
+	Secret        string   `bson:"secret,omitempty"`
+	PendingSecret string   `bson:"pendingSecret,omitempty"` // Secret yang belum dikonfirmasi saat enrollment
+	RecoveryCodes []string `bson:"recoveryCodes,omitempty"` // Hash SHA-256 dari recovery code yang belum dipakai
+	// LastUsedStep adalah langkah waktu TOTP terakhir yang dipakai, untuk mencegah replay
+	LastUsedStep int64      `bson:"lastUsedStep,omitempty"`
+	EnabledAt    *time.Time `bson:"enabled_at,omitempty"`
 }
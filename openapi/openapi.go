@@ -0,0 +1,210 @@
+// Package openapi membangun dokumen OpenAPI 3 untuk API Toko Biru. Skema
+// request dan response dibuat dari tipe Go (models.*, input controller) lewat
+// reflection, sehingga dokumen selalu mengikuti tag json dan binding yang
+// benar-benar dipakai saat validasi.
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Version adalah versi OpenAPI yang dihasilkan
+const Version = "3.0.3"
+
+// Document adalah dokumen OpenAPI lengkap
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem memetakan method HTTP (huruf kecil) ke operasinya
+type PathItem map[string]*Operation
+
+// Operation adalah satu endpoint. ErrorCodes dan Permissions adalah ekstensi
+// (x-*) untuk klien yang ingin membaca kode error dan permission tanpa
+// menelusuri skema response.
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Permissions []string              `json:"x-permissions,omitempty"`
+	ErrorCodes  []string              `json:"x-error-codes,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query atau header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Schema adalah subset JSON Schema yang dipakai OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// Spec menyusun Document. Tipe Go yang sama selalu menghasilkan komponen
+// skema yang sama.
+type Spec struct {
+	doc   Document
+	names map[reflect.Type]string
+}
+
+// New membuat dokumen kosong
+func New(info Info) *Spec {
+	return &Spec{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas:         make(map[string]*Schema),
+				SecuritySchemes: make(map[string]SecurityScheme),
+			},
+		},
+		names: make(map[reflect.Type]string),
+	}
+}
+
+// AddTag menambahkan grup endpoint
+func (s *Spec) AddTag(tag Tag) {
+	s.doc.Tags = append(s.doc.Tags, tag)
+}
+
+// AddSecurityScheme mendaftarkan skema autentikasi yang bisa dirujuk operasi
+func (s *Spec) AddSecurityScheme(name string, scheme SecurityScheme) {
+	s.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// Define mendaftarkan skema yang tidak berasal dari tipe Go dan
+// mengembalikan referensinya
+func (s *Spec) Define(name string, schema *Schema) *Schema {
+	s.doc.Components.Schemas[name] = schema
+	return Ref(name)
+}
+
+// Ref adalah referensi ke komponen skema
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// ginParam mencocokkan parameter path Gin (:id) dan wildcard (*path)
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Path mengubah path Gin menjadi path OpenAPI, misalnya /products/:id
+// menjadi /products/{id}
+func Path(ginPath string) string {
+	return ginParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Add mendaftarkan operasi untuk path Gin. Parameter path yang belum
+// didefinisikan di op ditambahkan sebagai string wajib.
+func (s *Spec) Add(method, ginPath string, op Operation) {
+	path := Path(ginPath)
+	for _, match := range ginParam.FindAllStringSubmatch(ginPath, -1) {
+		if !hasParameter(op.Parameters, match[1], "path") {
+			op.Parameters = append([]Parameter{{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}}}, op.Parameters...)
+		}
+	}
+	item, ok := s.doc.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		s.doc.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = &op
+}
+
+func hasParameter(params []Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// Operation mengembalikan operasi untuk method dan path Gin, atau nil jika
+// belum didokumentasikan
+func (s *Spec) Operation(method, ginPath string) *Operation {
+	return s.doc.Paths[Path(ginPath)][strings.ToLower(method)]
+}
+
+// Document mengembalikan dokumen yang sudah disusun
+func (s *Spec) Document() Document {
+	return s.doc
+}
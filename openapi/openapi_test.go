@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type base struct {
+	ID        primitive.ObjectID `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type item struct {
+	base
+	Name     string   `json:"name" binding:"required,min=2,max=32"`
+	Email    string   `json:"email" binding:"omitempty,email"`
+	Status   string   `json:"status" binding:"oneof=new done"`
+	Quantity int      `json:"quantity" binding:"required,gt=0,lte=10"`
+	Tags     []string `json:"tags,omitempty" binding:"max=3"`
+	Parent   *item    `json:"parent,omitempty"`
+	Secret   string   `json:"-"`
+	internal string
+}
+
+func TestSchemaFollowsJSONAndBindingTags(t *testing.T) {
+	spec := New(Info{Title: "test", Version: "1"})
+	ref := spec.Schema(item{})
+	if ref.Ref != "#/components/schemas/item" {
+		t.Fatalf("named struct should be a reference, got %+v", ref)
+	}
+	schema := spec.Document().Components.Schemas["item"]
+
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []string{"created_at", "email", "id", "name", "parent", "quantity", "status", "tags"}; !slices.Equal(names, want) {
+		t.Fatalf("got properties %v, want %v", names, want)
+	}
+	if !slices.Equal(schema.Required, []string{"name", "quantity"}) {
+		t.Fatalf("unexpected required fields %v", schema.Required)
+	}
+
+	props := schema.Properties
+	if props["id"].Pattern == "" || props["created_at"].Format != "date-time" {
+		t.Fatalf("ObjectID and time.Time should be strings, got %+v %+v", props["id"], props["created_at"])
+	}
+	if *props["name"].MinLength != 2 || *props["name"].MaxLength != 32 {
+		t.Fatalf("unexpected name length %+v", props["name"])
+	}
+	if props["email"].Format != "email" || !slices.Equal(props["status"].Enum, []string{"new", "done"}) {
+		t.Fatalf("unexpected email/status %+v %+v", props["email"], props["status"])
+	}
+	quantity := props["quantity"]
+	if *quantity.Minimum != 0 || !quantity.ExclusiveMinimum || *quantity.Maximum != 10 || quantity.ExclusiveMaximum {
+		t.Fatalf("unexpected quantity bounds %+v", quantity)
+	}
+	if *props["tags"].MaxItems != 3 || props["tags"].Items.Type != "string" {
+		t.Fatalf("unexpected tags %+v", props["tags"])
+	}
+	if props["parent"].Ref != ref.Ref {
+		t.Fatalf("recursive field should reference itself, got %+v", props["parent"])
+	}
+}
+
+func TestAddConvertsGinPathsAndAddsPathParameters(t *testing.T) {
+	spec := New(Info{Title: "test", Version: "1"})
+	spec.Add("DELETE", "/carts/:cartId/items/:id", Operation{
+		Parameters: []Parameter{{Name: "id", In: "path", Required: true, Schema: ObjectID()}},
+	})
+
+	op := spec.Operation("DELETE", "/carts/:cartId/items/:id")
+	if op == nil || spec.Document().Paths["/carts/{cartId}/items/{id}"]["delete"] != op {
+		t.Fatalf("operation not stored under the OpenAPI path: %+v", spec.Document().Paths)
+	}
+	if len(op.Parameters) != 2 || op.Parameters[0].Name != "cartId" || op.Parameters[1].Schema.Pattern == "" {
+		t.Fatalf("unexpected parameters %+v", op.Parameters)
+	}
+	if spec.Operation("GET", "/carts/:cartId/items/:id") != nil {
+		t.Fatal("GET should not be documented")
+	}
+}
@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// ObjectID adalah skema ObjectID MongoDB dalam bentuk hex
+func ObjectID() *Schema {
+	return &Schema{Type: "string", Pattern: "^[0-9a-f]{24}$"}
+}
+
+// Schema mengembalikan skema untuk nilai v. Struct bernama didaftarkan
+// sebagai komponen dan dirujuk dengan $ref; *Schema dikembalikan apa adanya.
+// Field mengikuti tag json (termasuk "-" dan struct embedded), dan aturan di
+// tag binding (required, email, min, max, gt, gte, lt, lte, oneof) menjadi
+// batasan skema.
+func (s *Spec) Schema(v any) *Schema {
+	if schema, ok := v.(*Schema); ok {
+		return schema
+	}
+	return s.schemaOf(reflect.TypeOf(v))
+}
+
+func (s *Spec) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return ObjectID()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return Ref(s.register(t))
+	}
+	// interface{} dan tipe lain bisa berisi nilai apa saja
+	return &Schema{}
+}
+
+// register mendaftarkan struct bernama sebagai komponen. Jika namanya sudah
+// dipakai tipe lain, nama package ditambahkan di depan (misalnya models.Role).
+func (s *Spec) register(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := s.doc.Components.Schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	// Nama didaftarkan sebelum field dibaca agar tipe rekursif tidak berulang
+	s.names[t] = name
+	s.doc.Components.Schemas[name] = &Schema{}
+	s.doc.Components.Schemas[name] = s.structSchema(t)
+	return name
+}
+
+func (s *Spec) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *Spec) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Struct embedded tanpa nama json digabung seperti encoding/json
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.schemaOf(field.Type)
+		if applyRules(property, field.Type, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyRules menerjemahkan tag binding ke batasan skema dan melaporkan
+// apakah field wajib diisi
+func applyRules(schema *Schema, t reflect.Type, tag string) (required bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(rule, "=")
+		if rule == "required" {
+			required = true
+			continue
+		}
+		// Skema $ref tidak boleh punya batasan tambahan di OpenAPI 3.0
+		if schema.Ref != "" {
+			continue
+		}
+		switch rule {
+		case "email":
+			schema.Format = "email"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "min", "max", "len":
+			applyLength(schema, t.Kind(), rule, param)
+		case "gt", "gte", "lt", "lte":
+			value, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			if rule == "gt" || rule == "gte" {
+				schema.Minimum, schema.ExclusiveMinimum = &value, rule == "gt"
+			} else {
+				schema.Maximum, schema.ExclusiveMaximum = &value, rule == "lt"
+			}
+		}
+	}
+	return required
+}
+
+// applyLength menerapkan min, max dan len. Seperti validator, artinya
+// panjang untuk string dan slice, dan nilai untuk angka.
+func applyLength(schema *Schema, kind reflect.Kind, rule, param string) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	n := int(value)
+	switch kind {
+	case reflect.String:
+		if rule != "max" {
+			schema.MinLength = &n
+		}
+		if rule != "min" {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if rule != "max" {
+			schema.MinItems = &n
+		}
+		if rule != "min" {
+			schema.MaxItems = &n
+		}
+	default:
+		if rule != "max" {
+			schema.Minimum = &value
+		}
+		if rule != "min" {
+			schema.Maximum = &value
+		}
+	}
+}
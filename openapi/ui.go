@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"html/template"
+	"io"
+)
+
+// uiTemplate adalah halaman Swagger UI yang dimuat dari CDN, sama seperti
+// Vue dan Tailwind di index.html
+var uiTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        window.ui = SwaggerUIBundle({
+            url: {{.SpecURL}},
+            dom_id: "#swagger-ui",
+            deepLinking: true,
+            persistAuthorization: true,
+        });
+    </script>
+</body>
+</html>
+`))
+
+// WriteUI menulis halaman Swagger UI yang membaca dokumen dari specURL
+func WriteUI(w io.Writer, title, specURL string) error {
+	return uiTemplate.Execute(w, struct{ Title, SpecURL string }{title, specURL})
+}
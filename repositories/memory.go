@@ -0,0 +1,1171 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryDB adalah penyimpanan in-memory yang dipakai bersama oleh semua
+// repository in-memory. Satu mutex menjaga semua koleksi agar operasi
+// seperti DecrementStock tetap atomik.
+type memoryDB struct {
+	mu            sync.RWMutex
+	products      map[primitive.ObjectID]models.Product
+	carts         map[primitive.ObjectID]models.Cart
+	orders        map[primitive.ObjectID]models.Order
+	users         map[primitive.ObjectID]models.User
+	conversations map[primitive.ObjectID]models.Conversation
+	sessions      map[primitive.ObjectID]models.Session
+	revocations   map[string]time.Time
+	invitations   map[primitive.ObjectID]models.Invitation
+	roles         map[string]models.Role
+	userTokens    map[primitive.ObjectID]models.UserToken
+	loginAttempts map[string]models.LoginAttempt
+	auditLogs     []models.AuditLog
+}
+
+// NewMemoryStore membuat Store in-memory, berguna untuk pengujian tanpa MongoDB.
+// Transaksi tidak didukung, sehingga checkout berjalan lewat jalur saga.
+func NewMemoryStore() *Store {
+	db := &memoryDB{
+		products:      make(map[primitive.ObjectID]models.Product),
+		carts:         make(map[primitive.ObjectID]models.Cart),
+		orders:        make(map[primitive.ObjectID]models.Order),
+		users:         make(map[primitive.ObjectID]models.User),
+		conversations: make(map[primitive.ObjectID]models.Conversation),
+		sessions:      make(map[primitive.ObjectID]models.Session),
+		revocations:   make(map[string]time.Time),
+		invitations:   make(map[primitive.ObjectID]models.Invitation),
+		roles:         make(map[string]models.Role),
+		userTokens:    make(map[primitive.ObjectID]models.UserToken),
+		loginAttempts: make(map[string]models.LoginAttempt),
+	}
+	return &Store{
+		Products:      &MemoryProductRepository{db: db},
+		Carts:         &MemoryCartRepository{db: db},
+		Orders:        &MemoryOrderRepository{db: db},
+		Users:         &MemoryUserRepository{db: db},
+		Conversations: &MemoryConversationRepository{db: db},
+		Sessions:      &MemorySessionRepository{db: db},
+		Revocations:   &MemoryRevocationRepository{db: db},
+		Invitations:   &MemoryInvitationRepository{db: db},
+		Roles:         &MemoryRoleRepository{db: db},
+		UserTokens:    &MemoryUserTokenRepository{db: db},
+		LoginAttempts: &MemoryLoginAttemptRepository{db: db},
+		Audit:         &MemoryAuditRepository{db: db},
+		Tx:            memoryTransactor{},
+		Health:        memoryHealth{},
+	}
+}
+
+type memoryHealth struct{}
+
+func (memoryHealth) Ping(ctx context.Context) error {
+	return nil
+}
+
+type memoryTransactor struct{}
+
+func (memoryTransactor) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return ErrTransactionsNotSupported
+}
+
+func copyCart(cart models.Cart) models.Cart {
+	cart.Items = append([]models.CartItem(nil), cart.Items...)
+	return cart
+}
+
+func copyOrder(order models.Order) models.Order {
+	order.Items = append([]models.OrderItem(nil), order.Items...)
+	return order
+}
+
+// MemoryProductRepository adalah implementasi ProductRepository in-memory
+type MemoryProductRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryProductRepository) Create(ctx context.Context, product *models.Product) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if product.ID.IsZero() {
+		product.ID = primitive.NewObjectID()
+	}
+	r.db.products[product.ID] = *product
+	return nil
+}
+
+func (r *MemoryProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	product, ok := r.db.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) FindByName(ctx context.Context, name string) (*models.Product, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for _, product := range r.db.products {
+		if product.Name == name {
+			return &product, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryProductRepository) List(ctx context.Context, filter ProductFilter) ([]models.Product, int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var matched []models.Product
+	for _, product := range r.db.products {
+		if filter.Name != "" && !strings.Contains(strings.ToLower(product.Name), strings.ToLower(filter.Name)) {
+			continue
+		}
+		if filter.Category != "" && product.Category != filter.Category {
+			continue
+		}
+		matched = append(matched, product)
+	}
+	// Urutkan berdasarkan ID (waktu pembuatan) agar paginasi stabil
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID.Hex() < matched[j].ID.Hex() })
+
+	total := int64(len(matched))
+	if filter.Skip > 0 {
+		if filter.Skip >= total {
+			return nil, total, nil
+		}
+		matched = matched[filter.Skip:]
+	}
+	if filter.Limit > 0 && int64(len(matched)) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	return matched, total, nil
+}
+
+func (r *MemoryProductRepository) Update(ctx context.Context, id primitive.ObjectID, product *models.Product) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	existing, ok := r.db.products[id]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name = product.Name
+	existing.Description = product.Description
+	existing.Price = product.Price
+	existing.Stock = product.Stock
+	existing.Category = product.Category
+	existing.ImageURL = product.ImageURL
+	existing.Embedding = product.Embedding
+	existing.UpdatedAt = product.UpdatedAt
+	r.db.products[id] = existing
+	return nil
+}
+
+func (r *MemoryProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.products[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.products, id)
+	return nil
+}
+
+func (r *MemoryProductRepository) DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	product, ok := r.db.products[id]
+	if !ok || product.Stock < quantity {
+		return ErrInsufficientStock
+	}
+	product.Stock -= quantity
+	product.UpdatedAt = time.Now()
+	r.db.products[id] = product
+	return nil
+}
+
+func (r *MemoryProductRepository) IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	product, ok := r.db.products[id]
+	if !ok {
+		return ErrNotFound
+	}
+	product.Stock += quantity
+	product.UpdatedAt = time.Now()
+	r.db.products[id] = product
+	return nil
+}
+
+// Search memberi skor produk berdasarkan jumlah kata kunci yang muncul,
+// dengan bobot yang meniru text index MongoDB (nama > kategori > deskripsi).
+func (r *MemoryProductRepository) Search(ctx context.Context, query string, limit int64) ([]models.Product, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	keywords := strings.Fields(strings.ToLower(query))
+	type scored struct {
+		product models.Product
+		score   int
+	}
+	var results []scored
+	for _, product := range r.db.products {
+		score := 0
+		for _, keyword := range keywords {
+			if strings.Contains(strings.ToLower(product.Name), keyword) {
+				score += 10
+			}
+			if strings.Contains(strings.ToLower(product.Category), keyword) {
+				score += 5
+			}
+			if strings.Contains(strings.ToLower(product.Description), keyword) {
+				score++
+			}
+		}
+		if score > 0 {
+			results = append(results, scored{product, score})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].product.ID.Hex() < results[j].product.ID.Hex()
+	})
+
+	var products []models.Product
+	for i, result := range results {
+		if limit > 0 && int64(i) >= limit {
+			break
+		}
+		products = append(products, result.product)
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Product, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var products []models.Product
+	for _, id := range ids {
+		if product, ok := r.db.products[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) FindEmbeddings(ctx context.Context) ([]ProductEmbedding, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var embeddings []ProductEmbedding
+	for _, product := range r.db.products {
+		if len(product.Embedding) > 0 {
+			embeddings = append(embeddings, ProductEmbedding{ID: product.ID, Embedding: product.Embedding})
+		}
+	}
+	return embeddings, nil
+}
+
+func (r *MemoryProductRepository) SetEmbedding(ctx context.Context, id primitive.ObjectID, embedding []float32) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	product, ok := r.db.products[id]
+	if !ok {
+		return ErrNotFound
+	}
+	product.Embedding = embedding
+	r.db.products[id] = product
+	return nil
+}
+
+// MemoryCartRepository adalah implementasi CartRepository in-memory
+type MemoryCartRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryCartRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for _, cart := range r.db.carts {
+		if cart.UserID == userID {
+			cart = copyCart(cart)
+			return &cart, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryCartRepository) Create(ctx context.Context, cart *models.Cart) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, existing := range r.db.carts {
+		if existing.UserID == cart.UserID {
+			return ErrDuplicate
+		}
+	}
+	if cart.ID.IsZero() {
+		cart.ID = primitive.NewObjectID()
+	}
+	r.db.carts[cart.ID] = copyCart(*cart)
+	return nil
+}
+
+func (r *MemoryCartRepository) UpdateItems(ctx context.Context, cartID primitive.ObjectID, items []models.CartItem) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	cart, ok := r.db.carts[cartID]
+	if !ok {
+		return ErrNotFound
+	}
+	cart.Items = append([]models.CartItem(nil), items...)
+	r.db.carts[cartID] = cart
+	return nil
+}
+
+// findItem mencari keranjang milik user yang berisi produk tertentu; caller harus memegang lock
+func (r *MemoryCartRepository) findItem(userID, productID primitive.ObjectID) (models.Cart, int) {
+	for _, cart := range r.db.carts {
+		if cart.UserID != userID {
+			continue
+		}
+		for i, item := range cart.Items {
+			if item.ProductID == productID {
+				return cart, i
+			}
+		}
+	}
+	return models.Cart{}, -1
+}
+
+func (r *MemoryCartRepository) SetItemQuantity(ctx context.Context, userID, productID primitive.ObjectID, quantity int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	cart, index := r.findItem(userID, productID)
+	if index == -1 {
+		return ErrNotFound
+	}
+	cart = copyCart(cart)
+	cart.Items[index].Quantity = quantity
+	r.db.carts[cart.ID] = cart
+	return nil
+}
+
+func (r *MemoryCartRepository) RemoveItem(ctx context.Context, userID, productID primitive.ObjectID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	cart, index := r.findItem(userID, productID)
+	if index == -1 {
+		return ErrNotFound
+	}
+	items := make([]models.CartItem, 0, len(cart.Items)-1)
+	items = append(items, cart.Items[:index]...)
+	cart.Items = append(items, cart.Items[index+1:]...)
+	r.db.carts[cart.ID] = cart
+	return nil
+}
+
+func (r *MemoryCartRepository) Delete(ctx context.Context, cartID primitive.ObjectID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.carts[cartID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.carts, cartID)
+	return nil
+}
+
+// MemoryOrderRepository adalah implementasi OrderRepository in-memory
+type MemoryOrderRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	r.db.orders[order.ID] = copyOrder(*order)
+	return nil
+}
+
+func (r *MemoryOrderRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.orders[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.orders, id)
+	return nil
+}
+
+func (r *MemoryOrderRepository) FindAll(ctx context.Context) ([]models.Order, error) {
+	return r.find(func(models.Order) bool { return true }), nil
+}
+
+func (r *MemoryOrderRepository) FindByFilter(ctx context.Context, filter OrderFilter) ([]models.Order, error) {
+	orders := r.find(func(order models.Order) bool {
+		return (filter.Start.IsZero() || !order.CreatedAt.Before(filter.Start)) &&
+			(filter.End.IsZero() || order.CreatedAt.Before(filter.End)) &&
+			(filter.Status == "" || order.Status == filter.Status)
+	})
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+		}
+		return orders[i].ID.Hex() < orders[j].ID.Hex()
+	})
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error) {
+	return r.find(func(order models.Order) bool { return order.UserID == userID }), nil
+}
+
+func (r *MemoryOrderRepository) find(match func(models.Order) bool) []models.Order {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var orders []models.Order
+	for _, order := range r.db.orders {
+		if match(order) {
+			orders = append(orders, copyOrder(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID.Hex() < orders[j].ID.Hex() })
+	return orders
+}
+
+func (r *MemoryOrderRepository) FindByIDForUser(ctx context.Context, id, userID primitive.ObjectID) (*models.Order, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	order, ok := r.db.orders[id]
+	if !ok || order.UserID != userID {
+		return nil, ErrNotFound
+	}
+	order = copyOrder(order)
+	return &order, nil
+}
+
+func (r *MemoryOrderRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	order, ok := r.db.orders[id]
+	if !ok {
+		return ErrNotFound
+	}
+	order.Status = status
+	order.UpdatedAt = time.Now()
+	r.db.orders[id] = order
+	return nil
+}
+
+// SalesReport menghitung laporan yang sama dengan pipeline agregasi MongoDB
+func (r *MemoryOrderRepository) SalesReport(ctx context.Context, filter SalesReportFilter) (*models.SalesReport, error) {
+	orders := r.find(func(order models.Order) bool {
+		if !filter.Start.IsZero() && order.CreatedAt.Before(filter.Start) {
+			return false
+		}
+		return filter.End.IsZero() || order.CreatedAt.Before(filter.End)
+	})
+
+	report := &models.SalesReport{Interval: filter.Interval}
+	byStatus := make(map[string]*models.StatusBreakdown)
+	byProduct := make(map[primitive.ObjectID]*models.TopSellingProduct)
+	byPeriod := make(map[string]*models.SalesBucket)
+
+	for _, order := range orders {
+		status, ok := byStatus[order.Status]
+		if !ok {
+			status = &models.StatusBreakdown{Status: order.Status}
+			byStatus[order.Status] = status
+		}
+		status.Count++
+		status.Revenue = status.Revenue.Add(order.Total)
+
+		if order.Status == models.OrderStatusCancelled {
+			continue
+		}
+		report.TotalRevenue = report.TotalRevenue.Add(order.Total)
+		report.TotalOrders++
+
+		for _, item := range order.Items {
+			product, ok := byProduct[item.ProductID]
+			if !ok {
+				product = &models.TopSellingProduct{ProductID: item.ProductID}
+				byProduct[item.ProductID] = product
+			}
+			product.TotalSold += item.Quantity
+			product.TotalRevenue = product.TotalRevenue.Add(item.Price.Mul(item.Quantity))
+		}
+
+		period := truncateToInterval(order.CreatedAt.In(filter.Location), filter.Interval).Format("2006-01-02")
+		bucket, ok := byPeriod[period]
+		if !ok {
+			bucket = &models.SalesBucket{Period: period}
+			byPeriod[period] = bucket
+		}
+		bucket.Revenue = bucket.Revenue.Add(order.Total)
+		bucket.Orders++
+	}
+	if report.TotalOrders > 0 {
+		report.AverageOrderValue = report.TotalRevenue.Div(report.TotalOrders)
+	}
+
+	for _, status := range byStatus {
+		report.StatusBreakdown = append(report.StatusBreakdown, *status)
+	}
+	sort.Slice(report.StatusBreakdown, func(i, j int) bool {
+		return report.StatusBreakdown[i].Status < report.StatusBreakdown[j].Status
+	})
+
+	for _, bucket := range byPeriod {
+		report.TimeSeries = append(report.TimeSeries, *bucket)
+	}
+	sort.Slice(report.TimeSeries, func(i, j int) bool {
+		return report.TimeSeries[i].Period < report.TimeSeries[j].Period
+	})
+
+	for _, product := range byProduct {
+		report.TopSellingProducts = append(report.TopSellingProducts, *product)
+	}
+	sort.Slice(report.TopSellingProducts, func(i, j int) bool {
+		a, b := report.TopSellingProducts[i], report.TopSellingProducts[j]
+		if a.TotalSold != b.TotalSold {
+			return a.TotalSold > b.TotalSold
+		}
+		return a.ProductID.Hex() < b.ProductID.Hex()
+	})
+	if len(report.TopSellingProducts) > 5 {
+		report.TopSellingProducts = report.TopSellingProducts[:5]
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for i, top := range report.TopSellingProducts {
+		report.TopSellingProducts[i].ProductDetails = r.db.products[top.ProductID]
+	}
+	return report, nil
+}
+
+// truncateToInterval memotong waktu ke awal hari, minggu (Senin) atau bulan
+func truncateToInterval(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case "week":
+		offset := (int(day.Weekday()) + 6) % 7 // Senin = 0
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+// MemoryUserRepository adalah implementasi UserRepository in-memory
+type MemoryUserRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, existing := range r.db.users {
+		if existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.FindByEmail(ctx, email)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for _, user := range r.db.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	user, ok := r.db.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var count int64
+	for _, user := range r.db.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryUserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	r.db.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var users []models.User
+	for _, user := range r.db.users {
+		user.Password = ""
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID.Hex() < users[j].ID.Hex() })
+	return users, nil
+}
+
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, name, hashedPassword string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if name != "" {
+		user.Name = name
+	}
+	if hashedPassword != "" {
+		user.Password = hashedPassword
+	}
+	user.UpdatedAt = time.Now()
+	r.db.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.EmailVerified = true
+	user.UpdatedAt = time.Now()
+	r.db.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) UpdateMFA(ctx context.Context, id primitive.ObjectID, enabled bool, mfa *models.UserMFA) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.MFAEnabled = enabled
+	user.MFA = copyUserMFA(mfa)
+	user.UpdatedAt = time.Now()
+	r.db.users[id] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[id]
+	if !ok || user.MFA == nil {
+		return false, nil
+	}
+	for i, hash := range user.MFA.RecoveryCodes {
+		if hash == codeHash {
+			mfa := copyUserMFA(user.MFA)
+			mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i:i], mfa.RecoveryCodes[i+1:]...)
+			user.MFA = mfa
+			r.db.users[id] = user
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryUserRepository) MarkTOTPUsed(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[id]
+	if !ok || user.MFA == nil || user.MFA.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa := copyUserMFA(user.MFA)
+	mfa.LastUsedStep = step
+	user.MFA = mfa
+	r.db.users[id] = user
+	return true, nil
+}
+
+// copyUserMFA menyalin konfigurasi MFA agar data di store tidak ikut berubah
+func copyUserMFA(mfa *models.UserMFA) *models.UserMFA {
+	if mfa == nil {
+		return nil
+	}
+	copied := *mfa
+	copied.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	return &copied
+}
+
+// MemoryConversationRepository adalah implementasi ConversationRepository in-memory
+type MemoryConversationRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryConversationRepository) Create(ctx context.Context, conversation *models.Conversation) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if conversation.ID.IsZero() {
+		conversation.ID = primitive.NewObjectID()
+	}
+	stored := *conversation
+	stored.Messages = append([]models.ChatMessage(nil), conversation.Messages...)
+	r.db.conversations[conversation.ID] = stored
+	return nil
+}
+
+func (r *MemoryConversationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Conversation, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	conversation, ok := r.db.conversations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	conversation.Messages = append([]models.ChatMessage(nil), conversation.Messages...)
+	return &conversation, nil
+}
+
+func (r *MemoryConversationRepository) List(ctx context.Context, filter ConversationFilter) ([]models.Conversation, int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var conversations []models.Conversation
+	for _, conversation := range r.db.conversations {
+		if !filter.UserID.IsZero() && (conversation.UserID == nil || *conversation.UserID != filter.UserID) {
+			continue
+		}
+		if filter.SessionID != "" && conversation.SessionID != filter.SessionID {
+			continue
+		}
+		conversation.Messages = nil
+		conversations = append(conversations, conversation)
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		return conversations[i].UpdatedAt.After(conversations[j].UpdatedAt)
+	})
+
+	total := int64(len(conversations))
+	start := filter.Skip
+	if start > total {
+		start = total
+	}
+	end := total
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	return conversations[start:end], total, nil
+}
+
+func (r *MemoryConversationRepository) AppendMessages(ctx context.Context, id primitive.ObjectID, messages ...models.ChatMessage) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	conversation, ok := r.db.conversations[id]
+	if !ok {
+		return ErrNotFound
+	}
+	conversation.Messages = append(append([]models.ChatMessage(nil), conversation.Messages...), messages...)
+	conversation.MessageCount += len(messages)
+	conversation.UpdatedAt = time.Now()
+	r.db.conversations[id] = conversation
+	return nil
+}
+
+func (r *MemoryConversationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.conversations[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.conversations, id)
+	return nil
+}
+
+// MemorySessionRepository adalah implementasi SessionRepository in-memory
+type MemorySessionRepository struct {
+	db *memoryDB
+}
+
+func (r *MemorySessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	r.db.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *MemorySessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for _, session := range r.db.sessions {
+		if session.TokenHash == tokenHash {
+			return &session, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemorySessionRepository) MarkRotated(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	session, ok := r.db.sessions[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if session.RotatedAt != nil {
+		return false, nil
+	}
+	session.RotatedAt = &at
+	r.db.sessions[id] = session
+	return true, nil
+}
+
+func (r *MemorySessionRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for id, session := range r.db.sessions {
+		if session.FamilyID == familyID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.db.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for id, session := range r.db.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.db.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+// MemoryRevocationRepository adalah implementasi RevocationRepository in-memory
+type MemoryRevocationRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryRevocationRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.revocations[id] = expiresAt
+	return nil
+}
+
+func (r *MemoryRevocationRepository) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	now := time.Now()
+	for _, id := range ids {
+		if expiresAt, ok := r.db.revocations[id]; ok && id != "" && now.Before(expiresAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MemoryInvitationRepository adalah implementasi InvitationRepository in-memory
+type MemoryInvitationRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if invitation.ID.IsZero() {
+		invitation.ID = primitive.NewObjectID()
+	}
+	r.db.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+func (r *MemoryInvitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for _, invitation := range r.db.invitations {
+		if invitation.TokenHash == tokenHash {
+			return &invitation, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryInvitationRepository) MarkAccepted(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	invitation, ok := r.db.invitations[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if invitation.AcceptedAt != nil {
+		return false, nil
+	}
+	invitation.AcceptedAt = &at
+	r.db.invitations[id] = invitation
+	return true, nil
+}
+
+// MemoryUserTokenRepository adalah implementasi UserTokenRepository in-memory
+type MemoryUserTokenRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryUserTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	r.db.userTokens[token.ID] = *token
+	return nil
+}
+
+func (r *MemoryUserTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.UserToken, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	for _, token := range r.db.userTokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryUserTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	token, ok := r.db.userTokens[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &at
+	r.db.userTokens[id] = token
+	return true, nil
+}
+
+func (r *MemoryUserTokenRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, purpose string, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for id, token := range r.db.userTokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &at
+			r.db.userTokens[id] = token
+		}
+	}
+	return nil
+}
+
+// MemoryLoginAttemptRepository adalah implementasi LoginAttemptRepository
+// in-memory. Penghitung yang kedaluwarsa diperlakukan seperti sudah dihapus TTL index.
+type MemoryLoginAttemptRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	attempt, ok := r.db.loginAttempts[key]
+	if !ok || !attempt.ExpiresAt.After(at) {
+		attempt = models.LoginAttempt{Key: key}
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = at
+	attempt.ExpiresAt = at.Add(window)
+	r.db.loginAttempts[key] = attempt
+	return &attempt, nil
+}
+
+func (r *MemoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	attempt := r.db.loginAttempts[key]
+	attempt.Key = key
+	attempt.Failures = 0
+	attempt.LockedUntil = &until
+	attempt.ExpiresAt = until
+	r.db.loginAttempts[key] = attempt
+	return nil
+}
+
+func (r *MemoryLoginAttemptRepository) Find(ctx context.Context, keys ...string) ([]models.LoginAttempt, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	now := time.Now()
+	var attempts []models.LoginAttempt
+	for _, key := range keys {
+		if attempt, ok := r.db.loginAttempts[key]; ok && attempt.ExpiresAt.After(now) {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *MemoryLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	delete(r.db.loginAttempts, key)
+	return nil
+}
+
+// MemoryAuditRepository adalah implementasi AuditRepository in-memory
+type MemoryAuditRepository struct {
+	db *memoryDB
+}
+
+func (r *MemoryAuditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	r.db.auditLogs = append(r.db.auditLogs, *entry)
+	return nil
+}
+
+func (r *MemoryAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var entries []models.AuditLog
+	// Urutan terbalik: entri terbaru lebih dulu
+	for i := len(r.db.auditLogs) - 1; i >= 0; i-- {
+		entry := r.db.auditLogs[i]
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if !filter.UserID.IsZero() && (entry.UserID == nil || *entry.UserID != filter.UserID) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	total := int64(len(entries))
+	start := filter.Skip
+	if start > total {
+		start = total
+	}
+	end := total
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	return entries[start:end], total, nil
+}
+
+// MemoryRoleRepository adalah implementasi RoleRepository in-memory
+type MemoryRoleRepository struct {
+	db *memoryDB
+}
+
+func copyRole(role models.Role) models.Role {
+	role.Permissions = append([]string(nil), role.Permissions...)
+	return role
+}
+
+func (r *MemoryRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var roles []models.Role
+	for _, role := range r.db.roles {
+		roles = append(roles, copyRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (r *MemoryRoleRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	role, ok := r.db.roles[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	role = copyRole(role)
+	return &role, nil
+}
+
+func (r *MemoryRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.roles[role.Name]; ok {
+		return ErrDuplicate
+	}
+	r.db.roles[role.Name] = copyRole(*role)
+	return nil
+}
+
+func (r *MemoryRoleRepository) Update(ctx context.Context, role *models.Role) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.roles[role.Name]; !ok {
+		return ErrNotFound
+	}
+	r.db.roles[role.Name] = copyRole(*role)
+	return nil
+}
+
+func (r *MemoryRoleRepository) Delete(ctx context.Context, name string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.roles[name]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.roles, name)
+	return nil
+}
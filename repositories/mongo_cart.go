@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoCartRepository adalah implementasi CartRepository untuk MongoDB
+type MongoCartRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCartRepository membuat instance baru dari MongoCartRepository
+func NewMongoCartRepository(db *mongo.Database) *MongoCartRepository {
+	return &MongoCartRepository{collection: db.Collection("carts")}
+}
+
+func (r *MongoCartRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error) {
+	var cart models.Cart
+	err := r.collection.FindOne(ctx, bson.M{"userId": userID}).Decode(&cart)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *MongoCartRepository) Create(ctx context.Context, cart *models.Cart) error {
+	_, err := r.collection.InsertOne(ctx, cart)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *MongoCartRepository) UpdateItems(ctx context.Context, cartID primitive.ObjectID, items []models.CartItem) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": cartID}, bson.M{"$set": bson.M{"items": items}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoCartRepository) SetItemQuantity(ctx context.Context, userID, productID primitive.ObjectID, quantity int) error {
+	filter := bson.M{"userId": userID, "items.productId": productID}
+	update := bson.M{"$set": bson.M{"items.$.quantity": quantity}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoCartRepository) RemoveItem(ctx context.Context, userID, productID primitive.ObjectID) error {
+	filter := bson.M{"userId": userID, "items.productId": productID}
+	update := bson.M{"$pull": bson.M{"items": bson.M{"productId": productID}}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoCartRepository) Delete(ctx context.Context, cartID primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": cartID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
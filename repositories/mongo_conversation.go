@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoConversationRepository adalah implementasi ConversationRepository untuk MongoDB
+type MongoConversationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoConversationRepository membuat instance baru dari MongoConversationRepository
+func NewMongoConversationRepository(db *mongo.Database) *MongoConversationRepository {
+	return &MongoConversationRepository{collection: db.Collection("conversations")}
+}
+
+// EnsureIndexes membuat index untuk daftar percakapan per user dan per sesi
+func (r *MongoConversationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "sessionId", Value: 1}, {Key: "updated_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *MongoConversationRepository) Create(ctx context.Context, conversation *models.Conversation) error {
+	if conversation.ID.IsZero() {
+		conversation.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, conversation)
+	return err
+}
+
+func (r *MongoConversationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&conversation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (r *MongoConversationRepository) List(ctx context.Context, filter ConversationFilter) ([]models.Conversation, int64, error) {
+	query := bson.M{}
+	if !filter.UserID.IsZero() {
+		query["userId"] = filter.UserID
+	}
+	if filter.SessionID != "" {
+		query["sessionId"] = filter.SessionID
+	}
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"messages": 0}).
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetSkip(filter.Skip)
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var conversations []models.Conversation
+	if err = cursor.All(ctx, &conversations); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+func (r *MongoConversationRepository) AppendMessages(ctx context.Context, id primitive.ObjectID, messages ...models.ChatMessage) error {
+	update := bson.M{
+		"$push": bson.M{"messages": bson.M{"$each": messages}},
+		"$inc":  bson.M{"messageCount": len(messages)},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoConversationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
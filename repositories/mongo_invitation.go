@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoInvitationRepository adalah implementasi InvitationRepository untuk MongoDB
+type MongoInvitationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoInvitationRepository membuat instance baru dari MongoInvitationRepository
+func NewMongoInvitationRepository(db *mongo.Database) *MongoInvitationRepository {
+	return &MongoInvitationRepository{collection: db.Collection("invitations")}
+}
+
+// EnsureIndexes membuat index unik untuk hash token undangan
+func (r *MongoInvitationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *MongoInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	if invitation.ID.IsZero() {
+		invitation.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, invitation)
+	return err
+}
+
+func (r *MongoInvitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *MongoInvitationRepository) MarkAccepted(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	// Filter accepted_at kosong membuat undangan hanya bisa dipakai sekali
+	filter := bson.M{"_id": id, "accepted_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"accepted_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
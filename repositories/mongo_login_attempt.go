@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLoginAttemptRepository adalah implementasi LoginAttemptRepository untuk MongoDB
+type MongoLoginAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoLoginAttemptRepository membuat instance baru dari MongoLoginAttemptRepository
+func NewMongoLoginAttemptRepository(db *mongo.Database) *MongoLoginAttemptRepository {
+	return &MongoLoginAttemptRepository{collection: db.Collection("login_attempts")}
+}
+
+// EnsureIndexes membuat TTL index agar penghitung dihapus otomatis setelah
+// jendela waktunya (atau masa kunciannya) berakhir
+func (r *MongoLoginAttemptRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoLoginAttemptRepository) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	// TTL monitor MongoDB berjalan tiap ~60 detik, jadi dokumen yang sudah
+	// kedaluwarsa tapi belum terhapus dihitung ulang dari 1
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"failures": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$expires_at", at}},
+			bson.M{"$add": bson.A{"$failures", 1}},
+			1,
+		}},
+		"last_failure_at": at,
+		"expires_at":      at.Add(window),
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempt models.LoginAttempt
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&attempt); err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *MongoLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	update := bson.M{"$set": bson.M{"failures": 0, "locked_until": until, "expires_at": until}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": key}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *MongoLoginAttemptRepository) Find(ctx context.Context, keys ...string) ([]models.LoginAttempt, error) {
+	filter := bson.M{"_id": bson.M{"$in": keys}, "expires_at": bson.M{"$gt": time.Now()}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []models.LoginAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *MongoLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
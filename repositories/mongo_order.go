@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoOrderRepository adalah implementasi OrderRepository untuk MongoDB
+type MongoOrderRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoOrderRepository membuat instance baru dari MongoOrderRepository
+func NewMongoOrderRepository(db *mongo.Database) *MongoOrderRepository {
+	return &MongoOrderRepository{collection: db.Collection("orders")}
+}
+
+func (r *MongoOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	_, err := r.collection.InsertOne(ctx, order)
+	return err
+}
+
+func (r *MongoOrderRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoOrderRepository) FindAll(ctx context.Context) ([]models.Order, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// FindByFilter memakai index created_at_status (migrasi 2)
+func (r *MongoOrderRepository) FindByFilter(ctx context.Context, filter OrderFilter) ([]models.Order, error) {
+	query := bson.M{}
+	createdAt := bson.M{}
+	if !filter.Start.IsZero() {
+		createdAt["$gte"] = filter.Start
+	}
+	if !filter.End.IsZero() {
+		createdAt["$lt"] = filter.End
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var orders []models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *MongoOrderRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error) {
+	return r.find(ctx, bson.M{"userId": userID})
+}
+
+func (r *MongoOrderRepository) find(ctx context.Context, filter bson.M) ([]models.Order, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []models.Order
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *MongoOrderRepository) FindByIDForUser(ctx context.Context, id, userID primitive.ObjectID) (*models.Order, error) {
+	var order models.Order
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "userId": userID}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *MongoOrderRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SalesReport menjalankan satu pipeline agregasi ($facet) untuk ringkasan,
+// produk terlaris, rincian per status, dan deret waktu per interval.
+func (r *MongoOrderRepository) SalesReport(ctx context.Context, filter SalesReportFilter) (*models.SalesReport, error) {
+	createdAt := bson.M{}
+	if !filter.Start.IsZero() {
+		createdAt["$gte"] = filter.Start
+	}
+	if !filter.End.IsZero() {
+		createdAt["$lt"] = filter.End
+	}
+
+	match := bson.M{}
+	if len(createdAt) > 0 {
+		match["created_at"] = createdAt
+	}
+
+	// Pesanan yang dibatalkan tidak dihitung sebagai pendapatan
+	successful := bson.M{"status": bson.M{"$ne": models.OrderStatusCancelled}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"summary": bson.A{
+				bson.M{"$match": successful},
+				bson.M{"$group": bson.M{
+					"_id":          nil,
+					"totalRevenue": bson.M{"$sum": amountExpr("$total")},
+					"totalOrders":  bson.M{"$sum": 1},
+				}},
+			},
+			"topSellingProducts": bson.A{
+				bson.M{"$match": successful},
+				bson.M{"$unwind": "$items"},
+				bson.M{"$group": bson.M{
+					"_id":          "$items.productId",
+					"totalSold":    bson.M{"$sum": "$items.quantity"},
+					"totalRevenue": bson.M{"$sum": bson.M{"$multiply": bson.A{amountExpr("$items.price"), "$items.quantity"}}},
+				}},
+				bson.M{"$addFields": bson.M{"totalRevenue": moneyExpr("$totalRevenue")}},
+				bson.M{"$sort": bson.D{{Key: "totalSold", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": 5},
+				bson.M{"$lookup": bson.M{
+					"from":         "products",
+					"localField":   "_id",
+					"foreignField": "_id",
+					"as":           "productDetails",
+				}},
+				bson.M{"$unwind": bson.M{"path": "$productDetails", "preserveNullAndEmptyArrays": true}},
+			},
+			"statusBreakdown": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":     "$status",
+					"count":   bson.M{"$sum": 1},
+					"revenue": bson.M{"$sum": amountExpr("$total")},
+				}},
+				bson.M{"$addFields": bson.M{"revenue": moneyExpr("$revenue")}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"timeSeries": bson.A{
+				bson.M{"$match": successful},
+				bson.M{"$group": bson.M{
+					"_id": bson.M{"$dateTrunc": bson.M{
+						"date":        "$created_at",
+						"unit":        filter.Interval,
+						"timezone":    filter.Location.String(),
+						"startOfWeek": "monday",
+					}},
+					"revenue": bson.M{"$sum": amountExpr("$total")},
+					"orders":  bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Summary []struct {
+			TotalRevenue int64 `bson:"totalRevenue"`
+			TotalOrders  int64 `bson:"totalOrders"`
+		} `bson:"summary"`
+		TopSellingProducts []models.TopSellingProduct `bson:"topSellingProducts"`
+		StatusBreakdown    []models.StatusBreakdown   `bson:"statusBreakdown"`
+		TimeSeries         []struct {
+			Period  time.Time `bson:"_id"`
+			Revenue int64     `bson:"revenue"`
+			Orders  int64     `bson:"orders"`
+		} `bson:"timeSeries"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	report := &models.SalesReport{Interval: filter.Interval}
+	if len(results) == 0 {
+		return report, nil
+	}
+	facet := results[0]
+
+	report.TopSellingProducts = facet.TopSellingProducts
+	report.StatusBreakdown = facet.StatusBreakdown
+	if len(facet.Summary) > 0 {
+		report.TotalRevenue = models.NewMoney(facet.Summary[0].TotalRevenue, models.DefaultCurrency)
+		report.TotalOrders = facet.Summary[0].TotalOrders
+		// Rata-rata dihitung di sini agar dibulatkan ke minor unit, bukan $avg
+		report.AverageOrderValue = report.TotalRevenue.Div(report.TotalOrders)
+	}
+	for _, bucket := range facet.TimeSeries {
+		report.TimeSeries = append(report.TimeSeries, models.SalesBucket{
+			Period:  bucket.Period.In(filter.Location).Format("2006-01-02"),
+			Revenue: models.NewMoney(bucket.Revenue, models.DefaultCurrency),
+			Orders:  bucket.Orders,
+		})
+	}
+	return report, nil
+}
+
+// amountExpr mengembalikan nominal field Money dalam minor unit. Dokumen lama
+// yang harganya masih berupa angka (sebelum migrasi 3) dikonversi dengan cara
+// yang sama seperti migrasi tersebut, agar laporan tidak diam-diam kurang
+// walaupun migrasi belum dijalankan.
+func amountExpr(field string) bson.M {
+	factor := math.Pow10(models.CurrencyExponent(models.DefaultCurrency))
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isNumber": field},
+		bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, factor}}, 0}}},
+		bson.M{"$ifNull": bson.A{field + ".amount", 0}},
+	}}
+}
+
+// moneyExpr membungkus hasil $sum dalam minor unit menjadi dokumen Money
+// agar bisa di-decode langsung ke field models.Money
+func moneyExpr(amount string) bson.M {
+	return bson.M{"amount": amount, "currency": models.DefaultCurrency}
+}
@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoProductRepository adalah implementasi ProductRepository untuk MongoDB
+type MongoProductRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoProductRepository membuat instance baru dari MongoProductRepository
+func NewMongoProductRepository(db *mongo.Database) *MongoProductRepository {
+	return &MongoProductRepository{collection: db.Collection("products")}
+}
+
+func (r *MongoProductRepository) Create(ctx context.Context, product *models.Product) error {
+	_, err := r.collection.InsertOne(ctx, product)
+	return err
+}
+
+func (r *MongoProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	var product models.Product
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *MongoProductRepository) FindByName(ctx context.Context, name string) (*models.Product, error) {
+	var product models.Product
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// List mencocokkan filter nama sebagai substring tanpa membedakan huruf
+// besar/kecil. Regex substring tidak bisa memakai batas index, jadi untuk
+// pencarian nama saja query diarahkan ke index "name" (migrasi 4): regex
+// dievaluasi pada key index dan hanya dokumen yang cocok yang dibaca. Jika
+// index belum ada, query diulang tanpa hint.
+func (r *MongoProductRepository) List(ctx context.Context, filter ProductFilter) ([]models.Product, int64, error) {
+	useNameIndex := filter.Name != "" && filter.Category == ""
+	products, total, err := r.list(ctx, filter, useNameIndex)
+	if err != nil && useNameIndex && isBadHint(err) {
+		return r.list(ctx, filter, false)
+	}
+	return products, total, err
+}
+
+func (r *MongoProductRepository) list(ctx context.Context, filter ProductFilter, useNameIndex bool) ([]models.Product, int64, error) {
+	query := bson.M{}
+	if filter.Name != "" {
+		query["name"] = bson.M{"$regex": regexp.QuoteMeta(filter.Name), "$options": "i"}
+	}
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+
+	// Urutkan berdasarkan _id agar paginasi stabil
+	findOptions := options.Find().SetSort(bson.M{"_id": 1})
+	findOptions.SetSkip(filter.Skip)
+	if filter.Limit > 0 {
+		findOptions.SetLimit(filter.Limit)
+	}
+	countOptions := options.Count()
+	if useNameIndex {
+		findOptions.SetHint("name")
+		countOptions.SetHint("name")
+	}
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query, countOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r *MongoProductRepository) Update(ctx context.Context, id primitive.ObjectID, product *models.Product) error {
+	update := bson.M{
+		"$set": bson.M{
+			"name":        product.Name,
+			"description": product.Description,
+			"price":       product.Price,
+			"stock":       product.Stock,
+			"category":    product.Category,
+			"image_url":   product.ImageURL,
+			"embedding":   product.Embedding,
+			"updated_at":  product.UpdatedAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoProductRepository) DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "stock": bson.M{"$gte": quantity}},
+		bson.M{"$inc": bson.M{"stock": -quantity}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+func (r *MongoProductRepository) IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"stock": quantity}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnsureSearchIndex membuat text index yang dipakai oleh Search. Bahasa default
+// "none" dipakai karena MongoDB tidak mendukung stemming Bahasa Indonesia.
+func (r *MongoProductRepository) EnsureSearchIndex(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "category", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName("product_text").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "category", Value: 5}, {Key: "description", Value: 1}}),
+	})
+	return err
+}
+
+// Search memakai text index MongoDB. Jika index belum ada, fallback ke regex
+// per kata kunci pada nama, kategori dan deskripsi.
+func (r *MongoProductRepository) Search(ctx context.Context, query string, limit int64) ([]models.Product, error) {
+	textOptions := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(limit)
+	products, err := r.findMany(ctx, bson.M{"$text": bson.M{"$search": query}}, textOptions)
+	if err == nil || !isIndexNotFound(err) {
+		return products, err
+	}
+
+	var conditions bson.A
+	for _, keyword := range strings.Fields(query) {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(keyword), "$options": "i"}
+		conditions = append(conditions, bson.M{"name": pattern}, bson.M{"category": pattern}, bson.M{"description": pattern})
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	return r.findMany(ctx, bson.M{"$or": conditions}, options.Find().SetLimit(limit))
+}
+
+func (r *MongoProductRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return r.findMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *MongoProductRepository) FindEmbeddings(ctx context.Context) ([]ProductEmbedding, error) {
+	filter := bson.M{"embedding.0": bson.M{"$exists": true}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"embedding": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var embeddings []ProductEmbedding
+	if err = cursor.All(ctx, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+func (r *MongoProductRepository) SetEmbedding(ctx context.Context, id primitive.ObjectID, embedding []float32) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"embedding": embedding}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoProductRepository) findMany(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Product, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// isIndexNotFound mendeteksi query $text tanpa text index
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 27 // IndexNotFound
+}
+
+// isBadHint mendeteksi hint ke index yang belum dibuat, misalnya sebelum
+// migrasi dijalankan
+func isBadHint(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 2 && strings.Contains(cmdErr.Message, "hint") // BadValue
+}
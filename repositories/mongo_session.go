@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSessionRepository adalah implementasi SessionRepository untuk MongoDB
+type MongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSessionRepository membuat instance baru dari MongoSessionRepository
+func NewMongoSessionRepository(db *mongo.Database) *MongoSessionRepository {
+	return &MongoSessionRepository{collection: db.Collection("sessions")}
+}
+
+// EnsureIndexes membuat index pencarian hash token, keluarga session dan user, serta
+// TTL index agar session yang kedaluwarsa dihapus otomatis oleh MongoDB.
+func (r *MongoSessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "familyId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (r *MongoSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *MongoSessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *MongoSessionRepository) MarkRotated(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	// Filter rotated_at kosong membuat penukaran bersamaan hanya berhasil sekali
+	filter := bson.M{"_id": id, "rotated_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"rotated_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoSessionRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	filter := bson.M{"familyId": familyID, "revoked_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	return err
+}
+
+func (r *MongoSessionRepository) RevokeUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"userId": userID, "revoked_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	return err
+}
+
+// MongoRevocationRepository adalah implementasi RevocationRepository untuk MongoDB
+type MongoRevocationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRevocationRepository membuat instance baru dari MongoRevocationRepository
+func NewMongoRevocationRepository(db *mongo.Database) *MongoRevocationRepository {
+	return &MongoRevocationRepository{collection: db.Collection("token_revocations")}
+}
+
+// EnsureIndexes membuat TTL index sehingga entri dihapus setelah access token
+// yang dicabut kedaluwarsa dengan sendirinya.
+func (r *MongoRevocationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (r *MongoRevocationRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoRevocationRepository) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	var nonEmpty []string
+	for _, id := range ids {
+		if id != "" {
+			nonEmpty = append(nonEmpty, id)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return false, nil
+	}
+	// TTL monitor MongoDB berjalan per menit, jadi expires_at tetap diperiksa
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"_id":        bson.M{"$in": nonEmpty},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
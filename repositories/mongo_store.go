@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// NewMongoStore membuat Store yang didukung oleh database MongoDB
+func NewMongoStore(db *mongo.Database) *Store {
+	return &Store{
+		Products:      NewMongoProductRepository(db),
+		Carts:         NewMongoCartRepository(db),
+		Orders:        NewMongoOrderRepository(db),
+		Users:         NewMongoUserRepository(db),
+		Conversations: NewMongoConversationRepository(db),
+		Sessions:      NewMongoSessionRepository(db),
+		Revocations:   NewMongoRevocationRepository(db),
+		Invitations:   NewMongoInvitationRepository(db),
+		Roles:         NewMongoRoleRepository(db),
+		UserTokens:    NewMongoUserTokenRepository(db),
+		LoginAttempts: NewMongoLoginAttemptRepository(db),
+		Audit:         NewMongoAuditRepository(db),
+		Tx:            NewMongoTransactor(db.Client()),
+		Health:        &MongoHealthChecker{client: db.Client()},
+	}
+}
+
+// MongoHealthChecker melakukan ping ke primary MongoDB
+type MongoHealthChecker struct {
+	client *mongo.Client
+}
+
+func (h *MongoHealthChecker) Ping(ctx context.Context) error {
+	return h.client.Ping(ctx, readpref.Primary())
+}
+
+// MongoTransactor menjalankan fungsi di dalam session transaction MongoDB
+type MongoTransactor struct {
+	client *mongo.Client
+}
+
+// NewMongoTransactor membuat instance baru dari MongoTransactor
+func NewMongoTransactor(client *mongo.Client) *MongoTransactor {
+	return &MongoTransactor{client: client}
+}
+
+// RunInTransaction menjalankan fn dalam transaksi. Pada deployment standalone
+// (tanpa replica set) fungsi ini mengembalikan ErrTransactionsNotSupported.
+func (t *MongoTransactor) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	if isTransactionNotSupported(err) {
+		return ErrTransactionsNotSupported
+	}
+	return err
+}
+
+// isTransactionNotSupported mendeteksi deployment MongoDB standalone yang tidak mendukung transaksi
+func isTransactionNotSupported(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == 20 { // IllegalOperation
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "Transaction numbers are only allowed")
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserRepository adalah implementasi UserRepository untuk MongoDB
+type MongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoUserRepository membuat instance baru dari MongoUserRepository
+func NewMongoUserRepository(db *mongo.Database) *MongoUserRepository {
+	return &MongoUserRepository{collection: db.Collection("users")}
+}
+
+func (r *MongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *MongoUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"email": email})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *MongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *MongoUserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"role": role})
+}
+
+func (r *MongoUserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	update := bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	// Projection to exclude password field
+	projection := options.Find().SetProjection(bson.M{"password": 0})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, projection)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *MongoUserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, name, hashedPassword string) error {
+	updateFields := bson.M{"updated_at": time.Now()}
+	if name != "" {
+		updateFields["name"] = name
+	}
+	if hashedPassword != "" {
+		updateFields["password"] = hashedPassword
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"emailVerified": true, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) UpdateMFA(ctx context.Context, id primitive.ObjectID, enabled bool, mfa *models.UserMFA) error {
+	update := bson.M{"$set": bson.M{"mfaEnabled": enabled, "mfa": mfa, "updated_at": time.Now()}}
+	if mfa == nil {
+		update = bson.M{
+			"$set":   bson.M{"mfaEnabled": false, "updated_at": time.Now()},
+			"$unset": bson.M{"mfa": ""},
+		}
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{"_id": id, "mfa.recoveryCodes": codeHash}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"mfa.recoveryCodes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoUserRepository) MarkTOTPUsed(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	filter := bson.M{"_id": id, "$or": bson.A{
+		bson.M{"mfa.lastUsedStep": bson.M{"$lt": step}},
+		bson.M{"mfa.lastUsedStep": bson.M{"$exists": false}},
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"mfa.lastUsedStep": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserTokenRepository adalah implementasi UserTokenRepository untuk MongoDB
+type MongoUserTokenRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoUserTokenRepository membuat instance baru dari MongoUserTokenRepository
+func NewMongoUserTokenRepository(db *mongo.Database) *MongoUserTokenRepository {
+	return &MongoUserTokenRepository{collection: db.Collection("user_tokens")}
+}
+
+// EnsureIndexes membuat index unik untuk hash token, index per user, dan TTL
+// index agar token yang kedaluwarsa dihapus otomatis oleh MongoDB.
+func (r *MongoUserTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (r *MongoUserTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+func (r *MongoUserTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *MongoUserTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	// Filter used_at kosong membuat token hanya bisa dipakai sekali
+	filter := bson.M{"_id": id, "used_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"used_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoUserTokenRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, purpose string, at time.Time) error {
+	filter := bson.M{"userId": userID, "purpose": purpose, "used_at": bson.M{"$exists": false}}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"used_at": at}})
+	return err
+}
@@ -0,0 +1,251 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+	"tokobiru/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrNotFound dikembalikan ketika dokumen yang dicari tidak ada
+	ErrNotFound = errors.New("document not found")
+	// ErrInsufficientStock dikembalikan ketika stok produk tidak mencukupi
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrDuplicate dikembalikan ketika dokumen dengan kunci yang sama sudah ada
+	ErrDuplicate = errors.New("duplicate document")
+	// ErrTransactionsNotSupported dikembalikan oleh Transactor jika backend tidak mendukung transaksi
+	ErrTransactionsNotSupported = errors.New("transactions are not supported")
+)
+
+// ProductFilter holds the search and pagination options for listing products
+type ProductFilter struct {
+	Name     string // Case-insensitive substring match
+	Category string
+	Skip     int64
+	Limit    int64 // 0 means no limit
+}
+
+// SalesReportFilter holds the options for building the sales report
+type SalesReportFilter struct {
+	Start    time.Time // Inclusive, zero means unbounded
+	End      time.Time // Exclusive, zero means unbounded
+	Interval string    // "day", "week" or "month"
+	Location *time.Location
+}
+
+// OrderFilter holds the options for listing orders, e.g. for exports.
+// Zero values mean no filter on that field.
+type OrderFilter struct {
+	Start  time.Time // Inclusive
+	End    time.Time // Exclusive
+	Status string
+}
+
+// ProductEmbedding is the stored embedding vector of a product
+type ProductEmbedding struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Embedding []float32          `bson:"embedding"`
+}
+
+// ConversationFilter holds the owner and pagination options for listing
+// conversations. Zero values mean no filter on that field.
+type ConversationFilter struct {
+	UserID    primitive.ObjectID
+	SessionID string
+	Skip      int64
+	Limit     int64 // 0 means no limit
+}
+
+// AuditFilter holds the filter and pagination options for listing audit logs.
+// Zero values mean no filter on that field.
+type AuditFilter struct {
+	Action string
+	UserID primitive.ObjectID
+	Skip   int64
+	Limit  int64 // 0 means no limit
+}
+
+// ProductRepository abstracts access to the products collection
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.Product) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
+	// FindByName mencari produk dengan nama yang sama persis, dipakai oleh seed
+	// dan import produk agar tidak membuat produk ganda
+	FindByName(ctx context.Context, name string) (*models.Product, error)
+	List(ctx context.Context, filter ProductFilter) ([]models.Product, int64, error)
+	Update(ctx context.Context, id primitive.ObjectID, product *models.Product) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// DecrementStock mengurangi stok secara atomik hanya jika stok >= quantity
+	DecrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error
+	IncrementStock(ctx context.Context, id primitive.ObjectID, quantity int) error
+	// Search mencari produk yang relevan dengan query, diurutkan dari yang paling relevan
+	Search(ctx context.Context, query string, limit int64) ([]models.Product, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Product, error)
+	// FindEmbeddings mengembalikan embedding semua produk yang sudah diindeks
+	FindEmbeddings(ctx context.Context) ([]ProductEmbedding, error)
+	SetEmbedding(ctx context.Context, id primitive.ObjectID, embedding []float32) error
+}
+
+// CartRepository abstracts access to the carts collection
+type CartRepository interface {
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error)
+	// Create mengembalikan ErrDuplicate jika user sudah punya keranjang
+	Create(ctx context.Context, cart *models.Cart) error
+	UpdateItems(ctx context.Context, cartID primitive.ObjectID, items []models.CartItem) error
+	SetItemQuantity(ctx context.Context, userID, productID primitive.ObjectID, quantity int) error
+	RemoveItem(ctx context.Context, userID, productID primitive.ObjectID) error
+	Delete(ctx context.Context, cartID primitive.ObjectID) error
+}
+
+// OrderRepository abstracts access to the orders collection
+type OrderRepository interface {
+	Create(ctx context.Context, order *models.Order) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	FindAll(ctx context.Context) ([]models.Order, error)
+	// FindByFilter mengembalikan order yang cocok dengan filter, dari yang terlama
+	FindByFilter(ctx context.Context, filter OrderFilter) ([]models.Order, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error)
+	FindByIDForUser(ctx context.Context, id, userID primitive.ObjectID) (*models.Order, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error
+	SalesReport(ctx context.Context, filter SalesReportFilter) (*models.SalesReport, error)
+}
+
+// UserRepository abstracts access to the users collection
+type UserRepository interface {
+	// Create mengembalikan ErrDuplicate jika email sudah terdaftar
+	Create(ctx context.Context, user *models.User) error
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	CountByRole(ctx context.Context, role string) (int64, error)
+	UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error
+	// FindAll mengembalikan semua user tanpa field password
+	FindAll(ctx context.Context) ([]models.User, error)
+	// UpdateProfile memperbarui nama dan/atau hash password; string kosong berarti tidak diubah
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, name, hashedPassword string) error
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error
+	// UpdateMFA mengganti konfigurasi TOTP user; mfa nil menghapusnya
+	UpdateMFA(ctx context.Context, id primitive.ObjectID, enabled bool, mfa *models.UserMFA) error
+	// UseRecoveryCode menghapus hash recovery code secara atomik. Mengembalikan
+	// false jika kode tidak ada atau sudah dipakai.
+	UseRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error)
+	// MarkTOTPUsed mencatat langkah TOTP yang dipakai secara atomik. Mengembalikan
+	// false jika langkah tersebut (atau yang lebih baru) sudah pernah dipakai.
+	MarkTOTPUsed(ctx context.Context, id primitive.ObjectID, step int64) (bool, error)
+}
+
+// ConversationRepository abstracts access to the conversations collection
+type ConversationRepository interface {
+	Create(ctx context.Context, conversation *models.Conversation) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Conversation, error)
+	// List mengembalikan percakapan tanpa isi pesan, diurutkan dari yang terbaru
+	List(ctx context.Context, filter ConversationFilter) ([]models.Conversation, int64, error)
+	// AppendMessages menambahkan pesan ke akhir percakapan secara atomik
+	AppendMessages(ctx context.Context, id primitive.ObjectID, messages ...models.ChatMessage) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// SessionRepository abstracts access to the sessions collection (refresh tokens)
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	// MarkRotated menandai session sudah ditukar secara atomik. Mengembalikan
+	// false jika session sudah pernah ditukar sebelumnya.
+	MarkRotated(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error)
+	// RevokeFamily mencabut semua session dalam satu keluarga
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUser mencabut semua session milik user, misalnya setelah reset password
+	RevokeUser(ctx context.Context, userID primitive.ObjectID, at time.Time) error
+}
+
+// RoleRepository abstracts access to the roles collection
+type RoleRepository interface {
+	List(ctx context.Context) ([]models.Role, error)
+	FindByName(ctx context.Context, name string) (*models.Role, error)
+	// Create mengembalikan ErrDuplicate jika nama role sudah dipakai
+	Create(ctx context.Context, role *models.Role) error
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+}
+
+// InvitationRepository abstracts access to the invitations collection
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error)
+	// MarkAccepted menandai undangan sudah dipakai secara atomik. Mengembalikan
+	// false jika undangan sudah pernah dipakai sebelumnya.
+	MarkAccepted(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error)
+}
+
+// UserTokenRepository abstracts access to the user_tokens collection
+// (token reset password dan verifikasi email)
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *models.UserToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.UserToken, error)
+	// MarkUsed menandai token sudah dipakai secara atomik. Mengembalikan false
+	// jika token sudah pernah dipakai sebelumnya.
+	MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error)
+	// InvalidateForUser menandai semua token user dengan tujuan tertentu yang
+	// belum dipakai sebagai sudah dipakai, sehingga hanya token terbaru berlaku.
+	InvalidateForUser(ctx context.Context, userID primitive.ObjectID, purpose string, at time.Time) error
+}
+
+// LoginAttemptRepository menyimpan penghitung login gagal per email dan per IP
+type LoginAttemptRepository interface {
+	// RecordFailure menambah penghitung secara atomik dan memperpanjang masa
+	// berlakunya sampai at+window. Mengembalikan keadaan setelah diperbarui.
+	RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*models.LoginAttempt, error)
+	// Lock mengunci kunci sampai waktu tertentu
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Find mengembalikan penghitung yang ada untuk kunci-kunci tersebut
+	Find(ctx context.Context, keys ...string) ([]models.LoginAttempt, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// AuditRepository abstracts access to the audit_logs collection
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// List mengembalikan audit log dari yang terbaru
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error)
+}
+
+// RevocationRepository menyimpan ID (jti atau session family) dari access token
+// yang dicabut sebelum kedaluwarsa
+type RevocationRepository interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	// IsRevoked bernilai true jika salah satu ID ada di daftar pencabutan
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+}
+
+// Transactor menjalankan fn secara atomik. Context yang diberikan ke fn harus
+// diteruskan ke repository agar operasinya ikut dalam transaksi.
+type Transactor interface {
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// HealthChecker memeriksa apakah database bisa dijangkau, untuk endpoint readiness
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// Store groups all repositories used by the application
+type Store struct {
+	Products ProductRepository
+	Carts    CartRepository
+	Orders   OrderRepository
+	Users    UserRepository
+	// Conversations menyimpan riwayat chatbot
+	Conversations ConversationRepository
+	Sessions      SessionRepository
+	Revocations   RevocationRepository
+	Invitations   InvitationRepository
+	Roles         RoleRepository
+	UserTokens    UserTokenRepository
+	LoginAttempts LoginAttemptRepository
+	Audit         AuditRepository
+	Tx            Transactor
+	Health        HealthChecker
+}
@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var emailTokenRe = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// lastEmailToken returns the token from the latest email sent to the address
+func (s *testServer) lastEmailToken(to string) string {
+	s.t.Helper()
+	files, err := filepath.Glob(filepath.Join(s.mailDir, "*.eml"))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	sort.Strings(files)
+	for i := len(files) - 1; i >= 0; i-- {
+		content, err := os.ReadFile(files[i])
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		if !strings.Contains(string(content), "To: "+to+"\r\n") {
+			continue
+		}
+		if match := emailTokenRe.FindStringSubmatch(string(content)); match != nil {
+			return match[1]
+		}
+	}
+	s.t.Fatalf("no email with token sent to %s", to)
+	return ""
+}
+
+func TestRegisterSendsVerificationEmail(t *testing.T) {
+	t.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+	s := newTestServer(t)
+
+	body := gin.H{"name": "Budi", "email": "budi@example.com", "password": "rahasia123"}
+	if code := s.do(http.MethodPost, "/api/v1/auth/register", "", body, nil); code != http.StatusCreated {
+		t.Fatalf("register: got status %d", code)
+	}
+	login := gin.H{"email": "budi@example.com", "password": "rahasia123"}
+	if code := s.do(http.MethodPost, "/api/v1/auth/login", "", login, nil); code != http.StatusForbidden {
+		t.Fatalf("login before verification: got status %d, want 403", code)
+	}
+
+	// Link lama tidak berlaku setelah link baru diminta
+	first := s.lastEmailToken("budi@example.com")
+	if code := s.do(http.MethodPost, "/api/v1/auth/resend-verification", "", gin.H{"email": "budi@example.com"}, nil); code != http.StatusOK {
+		t.Fatalf("resend verification: got status %d", code)
+	}
+	token := s.lastEmailToken("budi@example.com")
+	if code := s.do(http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": first}, nil); code != http.StatusBadRequest {
+		t.Fatalf("verify with old token: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": token}, nil); code != http.StatusOK {
+		t.Fatalf("verify email: got status %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/verify-email", "", gin.H{"token": token}, nil); code != http.StatusBadRequest {
+		t.Fatalf("reuse verification token: got status %d, want 400", code)
+	}
+	s.login("budi@example.com", "rahasia123")
+}
+
+func TestForgotAndResetPassword(t *testing.T) {
+	s := newTestServer(t)
+	user, _ := s.createUser("customer@example.com", "secret123", "customer")
+	before := s.login(user.Email, "secret123")
+
+	// Email yang tidak terdaftar mendapat respons yang sama
+	if code := s.do(http.MethodPost, "/api/v1/auth/forgot-password", "", gin.H{"email": "ghost@example.com"}, nil); code != http.StatusOK {
+		t.Fatalf("forgot password for unknown email: got status %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/forgot-password", "", gin.H{"email": user.Email}, nil); code != http.StatusOK {
+		t.Fatalf("forgot password: got status %d", code)
+	}
+	token := s.lastEmailToken(user.Email)
+
+	if code := s.do(http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": token, "password": "short"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("reset with short password: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": token, "password": "passwordbaru"}, nil); code != http.StatusOK {
+		t.Fatalf("reset password: got status %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/reset-password", "", gin.H{"token": token, "password": "passwordlain"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("reuse reset token: got status %d, want 400", code)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": user.Email, "password": "secret123"}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("login with old password: got status %d, want 401", code)
+	}
+	s.login(user.Email, "passwordbaru")
+
+	// Session yang dibuat sebelum reset tidak bisa di-refresh lagi
+	if code := s.do(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": before.RefreshToken}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("refresh old session: got status %d, want 401", code)
+	}
+	updated, _ := s.store.Users.FindByID(context.Background(), user.ID)
+	if !updated.EmailVerified {
+		t.Fatal("reset password should mark the email verified")
+	}
+}
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAdminUsersAndOrders(t *testing.T) {
+	s := newTestServer(t)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+	_, customerToken := s.createUser("customer@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+
+	var users []models.User
+	if code := s.do(http.MethodGet, "/api/v1/admin/users", adminToken, nil, &users); code != http.StatusOK || len(users) != 2 {
+		t.Fatalf("list users: got status %d, %d users", code, len(users))
+	}
+	for _, user := range users {
+		if user.Password != "" {
+			t.Fatalf("password leaked for %s", user.Email)
+		}
+	}
+
+	s.do(http.MethodPost, "/api/v1/cart", customerToken, gin.H{"productId": kaos.ID.Hex(), "quantity": 1}, nil)
+	var checkout checkoutResponse
+	s.do(http.MethodPost, "/api/v1/orders/checkout", customerToken, nil, &checkout)
+
+	var orders []models.Order
+	if code := s.do(http.MethodGet, "/api/v1/admin/orders", adminToken, nil, &orders); code != http.StatusOK || len(orders) != 1 {
+		t.Fatalf("list orders: got status %d, %d orders", code, len(orders))
+	}
+
+	path := "/api/v1/admin/orders/" + checkout.Order.ID.Hex()
+	if code := s.do(http.MethodPatch, path, adminToken, gin.H{"status": "terbang"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("invalid status: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPatch, path, adminToken, gin.H{"status": models.OrderStatusShipped}, nil); code != http.StatusOK {
+		t.Fatalf("update status: got status %d", code)
+	}
+	if code := s.do(http.MethodPatch, "/api/v1/admin/orders/"+primitive.NewObjectID().Hex(), adminToken, gin.H{"status": models.OrderStatusShipped}, nil); code != http.StatusNotFound {
+		t.Fatalf("update missing order: got status %d, want 404", code)
+	}
+}
+
+func TestSalesReport(t *testing.T) {
+	s := newTestServer(t)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+	kaos := s.createProduct("Kaos", 100000, 50)
+	topi := s.createProduct("Topi", 50000, 50)
+
+	wib := time.FixedZone("+07:00", 7*60*60)
+	newOrder := func(status string, createdAt time.Time, items ...models.OrderItem) {
+		var total models.Money
+		for _, item := range items {
+			total = total.Add(item.Price.Mul(item.Quantity))
+		}
+		order := &models.Order{ID: primitive.NewObjectID(), Items: items, Total: total, Status: status, CreatedAt: createdAt}
+		if err := s.store.Orders.Create(context.Background(), order); err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+	}
+	// 1 Juni 2025 00:30 WIB masih 31 Mei dalam UTC, harus masuk bucket 1 Juni
+	newOrder(models.OrderStatusCompleted, time.Date(2025, 5, 31, 17, 30, 0, 0, time.UTC),
+		models.OrderItem{ProductID: kaos.ID, Quantity: 2, Price: models.IDR(100000)})
+	newOrder(models.OrderStatusNew, time.Date(2025, 6, 3, 10, 0, 0, 0, wib),
+		models.OrderItem{ProductID: topi.ID, Quantity: 5, Price: models.IDR(50000)})
+	newOrder(models.OrderStatusCancelled, time.Date(2025, 6, 3, 11, 0, 0, 0, wib),
+		models.OrderItem{ProductID: kaos.ID, Quantity: 10, Price: models.IDR(100000)})
+	newOrder(models.OrderStatusCompleted, time.Date(2025, 7, 1, 9, 0, 0, 0, wib),
+		models.OrderItem{ProductID: kaos.ID, Quantity: 1, Price: models.IDR(100000)})
+
+	var report models.SalesReport
+	code := s.do(http.MethodGet, "/api/v1/admin/sales-report?from=2025-06-01&to=2025-06-30", adminToken, nil, &report)
+	if code != http.StatusOK {
+		t.Fatalf("sales report: got status %d", code)
+	}
+	if report.TotalOrders != 2 || report.TotalRevenue != models.IDR(450000) || report.AverageOrderValue != models.IDR(225000) {
+		t.Fatalf("summary: got orders=%d revenue=%v avg=%v", report.TotalOrders, report.TotalRevenue, report.AverageOrderValue)
+	}
+	if len(report.TopSellingProducts) != 2 || report.TopSellingProducts[0].ProductID != topi.ID ||
+		report.TopSellingProducts[0].ProductDetails.Name != "Topi" {
+		t.Fatalf("top products: got %+v", report.TopSellingProducts)
+	}
+	if len(report.StatusBreakdown) != 3 {
+		t.Fatalf("status breakdown: got %+v", report.StatusBreakdown)
+	}
+	if len(report.TimeSeries) != 2 || report.TimeSeries[0].Period != "2025-06-01" || report.TimeSeries[1].Period != "2025-06-03" {
+		t.Fatalf("daily buckets: got %+v", report.TimeSeries)
+	}
+
+	s.do(http.MethodGet, "/api/v1/admin/sales-report?interval=month", adminToken, nil, &report)
+	if len(report.TimeSeries) != 2 || report.TimeSeries[0].Period != "2025-06-01" || report.TimeSeries[1].Period != "2025-07-01" {
+		t.Fatalf("monthly buckets: got %+v", report.TimeSeries)
+	}
+
+	s.do(http.MethodGet, "/api/v1/admin/sales-report?interval=week&to=2025-06-30", adminToken, nil, &report)
+	if len(report.TimeSeries) != 2 || report.TimeSeries[0].Period != "2025-05-26" || report.TimeSeries[1].Period != "2025-06-02" {
+		t.Fatalf("weekly buckets: got %+v", report.TimeSeries)
+	}
+
+	if code := s.do(http.MethodGet, "/api/v1/admin/sales-report?interval=year", adminToken, nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("invalid interval: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/admin/sales-report?from=kemarin", adminToken, nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("invalid date: got status %d, want 400", code)
+	}
+}
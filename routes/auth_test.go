@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+func (s *testServer) login(email, password string) tokenPair {
+	s.t.Helper()
+	var tokens tokenPair
+	code := s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": email, "password": password}, &tokens)
+	if code != http.StatusOK || tokens.Token == "" || tokens.RefreshToken == "" {
+		s.t.Fatalf("login: got status %d, body %+v", code, tokens)
+	}
+	return tokens
+}
+
+func TestRefreshTokenRotationAndReuseDetection(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("budi@example.com", "rahasia123", "customer")
+
+	first := s.login("budi@example.com", "rahasia123")
+	if first.ExpiresIn != 15*60 {
+		t.Fatalf("access token should be short-lived, got expiresIn %d", first.ExpiresIn)
+	}
+
+	var second tokenPair
+	if code := s.do(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": first.RefreshToken}, &second); code != http.StatusOK {
+		t.Fatalf("refresh: got status %d", code)
+	}
+	if second.RefreshToken == first.RefreshToken || second.Token == "" {
+		t.Fatalf("refresh must rotate the token pair: %+v", second)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", second.Token, nil, nil); code != http.StatusOK {
+		t.Fatalf("refreshed access token: got status %d", code)
+	}
+
+	// Memakai ulang refresh token lama mematikan seluruh keluarga session
+	if code := s.do(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": first.RefreshToken}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: got status %d, want 401", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": second.RefreshToken}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("refresh token of a revoked family: got status %d, want 401", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", second.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("access token of a revoked family: got status %d, want 401", code)
+	}
+
+	// Session lain milik user yang sama tidak terpengaruh
+	other := s.login("budi@example.com", "rahasia123")
+	if code := s.do(http.MethodGet, "/api/v1/cart", other.Token, nil, nil); code != http.StatusOK {
+		t.Fatalf("independent session: got status %d", code)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": "unknown"}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("unknown refresh token: got status %d, want 401", code)
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("budi@example.com", "rahasia123", "customer")
+	tokens := s.login("budi@example.com", "rahasia123")
+
+	if code := s.do(http.MethodPost, "/api/v1/auth/logout", "", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("logout without tokens: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/logout", tokens.Token, nil, nil); code != http.StatusOK {
+		t.Fatalf("logout: got status %d", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", tokens.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("access token after logout: got status %d, want 401", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refreshToken": tokens.RefreshToken}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("refresh token after logout: got status %d, want 401", code)
+	}
+
+	// Logout hanya dengan refresh token (misalnya access token sudah kedaluwarsa)
+	tokens = s.login("budi@example.com", "rahasia123")
+	if code := s.do(http.MethodPost, "/api/v1/auth/logout", "", gin.H{"refreshToken": tokens.RefreshToken}, nil); code != http.StatusOK {
+		t.Fatalf("logout with refresh token: got status %d", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", tokens.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("access token after refresh-token logout: got status %d, want 401", code)
+	}
+}
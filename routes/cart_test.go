@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"tokobiru/apperror"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCartLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+	topi := s.createProduct("Topi", 60000, 5)
+
+	var cart models.Cart
+	if code := s.do(http.MethodGet, "/api/v1/cart", token, nil, &cart); code != http.StatusOK || len(cart.Items) != 0 {
+		t.Fatalf("empty cart: got status %d, items %v", code, cart.Items)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, &cart); code != http.StatusCreated {
+		t.Fatalf("add first item: got status %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, &cart); code != http.StatusOK {
+		t.Fatalf("add same item: got status %d", code)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 4 {
+		t.Fatalf("add same item: unexpected items %+v", cart.Items)
+	}
+	var problem apperror.Problem
+	if code := s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, &problem); code != http.StatusNotFound || problem.Code != apperror.CodeInsufficientStock {
+		t.Fatalf("exceed stock: got status %d, code %q", code, problem.Code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": topi.ID.Hex(), "quantity": 1}, &cart); code != http.StatusOK || len(cart.Items) != 2 {
+		t.Fatalf("add second item: got status %d, items %+v", code, cart.Items)
+	}
+
+	if code := s.do(http.MethodPut, "/api/v1/cart", token, gin.H{"productId": topi.ID.Hex(), "quantity": 3}, nil); code != http.StatusOK {
+		t.Fatalf("update quantity: got status %d", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/cart", token, gin.H{"productId": topi.ID.Hex(), "quantity": 9}, nil); code != http.StatusNotFound {
+		t.Fatalf("update beyond stock: got status %d, want 404", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 0}, nil); code != http.StatusOK {
+		t.Fatalf("update to zero: got status %d", code)
+	}
+
+	s.do(http.MethodGet, "/api/v1/cart", token, nil, &cart)
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != topi.ID || cart.Items[0].Quantity != 3 {
+		t.Fatalf("after updates: unexpected items %+v", cart.Items)
+	}
+
+	if code := s.do(http.MethodDelete, "/api/v1/cart/"+topi.ID.Hex(), token, nil, nil); code != http.StatusOK {
+		t.Fatalf("remove item: got status %d", code)
+	}
+	if code := s.do(http.MethodDelete, "/api/v1/cart/"+topi.ID.Hex(), token, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("remove missing item: got status %d, want 404", code)
+	}
+}
+
+// Sebelum lapisan repository, binding `required` menolak quantity 0 sehingga
+// cabang penghapusan di UpdateCartItem tidak pernah tercapai.
+func TestUpdateCartItemToZeroRemovesItem(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+
+	if code := s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, nil); code != http.StatusCreated {
+		t.Fatalf("add item: got status %d", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": -1}, nil); code != http.StatusBadRequest {
+		t.Fatalf("negative quantity: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 0}, nil); code != http.StatusOK {
+		t.Fatalf("quantity 0: got status %d, want 200", code)
+	}
+
+	var cart models.Cart
+	s.do(http.MethodGet, "/api/v1/cart", token, nil, &cart)
+	if len(cart.Items) != 0 {
+		t.Fatalf("quantity 0 should remove the item, got %+v", cart.Items)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 0}, nil); code != http.StatusNotFound {
+		t.Fatalf("remove missing item: got status %d, want 404", code)
+	}
+}
+
+// Body tanpa quantity ditolak, bukan dianggap 0 yang menghapus produk
+func TestUpdateCartItemRequiresQuantity(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+
+	if code := s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, nil); code != http.StatusCreated {
+		t.Fatalf("add item: got status %d", code)
+	}
+	for _, body := range []gin.H{
+		{"productId": kaos.ID.Hex()},
+		{"productId": kaos.ID.Hex(), "quantity": nil},
+	} {
+		var problem apperror.Problem
+		if code := s.do(http.MethodPut, "/api/v1/cart", token, body, &problem); code != http.StatusBadRequest {
+			t.Fatalf("%v: got status %d, want 400", body, code)
+		}
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != "quantity" || problem.Errors[0].Rule != "required" {
+			t.Fatalf("%v: unexpected errors %+v", body, problem.Errors)
+		}
+	}
+
+	var cart models.Cart
+	s.do(http.MethodGet, "/api/v1/cart", token, nil, &cart)
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 2 {
+		t.Fatalf("rejected update should keep the item, got %+v", cart.Items)
+	}
+}
@@ -0,0 +1,221 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestChatbotAskReturnsRetrievedProducts(t *testing.T) {
+	s := newTestServer(t)
+	topi := s.createProduct("Topi Baseball", 60000, 20)
+	s.createProduct("Kemeja Flanel", 175000, 10)
+
+	var resp struct {
+		Reply      string   `json:"reply"`
+		ProductIDs []string `json:"productIds"`
+	}
+	code := s.do(http.MethodPost, "/api/v1/chatbot/ask", "", gin.H{"prompt": "Berapa harga topi?"}, &resp)
+	if code != http.StatusOK {
+		t.Fatalf("ask: got status %d", code)
+	}
+	if len(resp.ProductIDs) != 1 || resp.ProductIDs[0] != topi.ID.Hex() {
+		t.Fatalf("productIds: got %v, want [%s]", resp.ProductIDs, topi.ID.Hex())
+	}
+	if !strings.Contains(resp.Reply, "Topi Baseball") {
+		t.Fatalf("reply should mention the product: %q", resp.Reply)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/chatbot/ask", "", gin.H{}, nil); code != http.StatusBadRequest {
+		t.Fatalf("empty prompt: got status %d, want 400", code)
+	}
+}
+
+func TestChatbotOrderStatusUsesToolsScopedToCaller(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	_, otherToken := s.createUser("other@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+
+	s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 1}, nil)
+	var checkout checkoutResponse
+	if code := s.do(http.MethodPost, "/api/v1/orders/checkout", token, nil, &checkout); code != http.StatusCreated {
+		t.Fatalf("checkout: got status %d", code)
+	}
+	orderID := checkout.Order.OrderID
+
+	var resp struct {
+		Reply string `json:"reply"`
+	}
+	prompt := gin.H{"prompt": "Bagaimana status pesanan " + strings.ToLower(orderID) + "?"}
+	if code := s.do(http.MethodPost, "/api/v1/chatbot/ask", token, prompt, &resp); code != http.StatusOK {
+		t.Fatalf("ask: got status %d", code)
+	}
+	if !strings.Contains(resp.Reply, orderID) || !strings.Contains(resp.Reply, "baru") {
+		t.Fatalf("owner should see the order status: %q", resp.Reply)
+	}
+
+	s.do(http.MethodPost, "/api/v1/chatbot/ask", otherToken, prompt, &resp)
+	if strings.Contains(resp.Reply, "Rp 85.000") || !strings.Contains(resp.Reply, "tidak ditemukan") {
+		t.Fatalf("other user must not see the order: %q", resp.Reply)
+	}
+
+	s.do(http.MethodPost, "/api/v1/chatbot/ask", "", prompt, &resp)
+	if !strings.Contains(resp.Reply, "login") {
+		t.Fatalf("anonymous caller should be asked to log in: %q", resp.Reply)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/chatbot/ask", "not-a-token", prompt, nil); code != http.StatusUnauthorized {
+		t.Fatalf("invalid token: got status %d, want 401", code)
+	}
+}
+
+type chatAskResponse struct {
+	Reply          string `json:"reply"`
+	ConversationID string `json:"conversationId"`
+	SessionID      string `json:"sessionId"`
+}
+
+func TestChatbotConversationFollowUp(t *testing.T) {
+	s := newTestServer(t)
+	s.createProduct("Kaos Polos", 85000, 12)
+	s.createProduct("Topi Baseball", 60000, 20)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+
+	var first chatAskResponse
+	if code := s.do(http.MethodPost, "/api/v1/chatbot/ask", "", gin.H{"prompt": "Berapa harga kaos?"}, &first); code != http.StatusOK {
+		t.Fatalf("ask: got status %d", code)
+	}
+	if first.ConversationID == "" || first.SessionID == "" {
+		t.Fatalf("anonymous ask should start a conversation and session: %+v", first)
+	}
+
+	session := map[string]string{"X-Session-ID": first.SessionID}
+	var second chatAskResponse
+	followUp := gin.H{"prompt": "Stoknya masih ada?", "conversationId": first.ConversationID}
+	if code := s.doWithHeaders(http.MethodPost, "/api/v1/chatbot/ask", session, followUp, &second); code != http.StatusOK {
+		t.Fatalf("follow-up: got status %d", code)
+	}
+	if second.ConversationID != first.ConversationID || !strings.Contains(second.Reply, "Kaos Polos") || strings.Contains(second.Reply, "Topi") {
+		t.Fatalf("follow-up should refer to the earlier product: %+v", second)
+	}
+
+	var list struct {
+		Data []models.Conversation `json:"data"`
+	}
+	s.doWithHeaders(http.MethodGet, "/api/v1/chatbot/conversations", session, nil, &list)
+	if len(list.Data) != 1 || list.Data[0].MessageCount != 4 || len(list.Data[0].Messages) != 0 {
+		t.Fatalf("list: unexpected conversations %+v", list.Data)
+	}
+
+	path := "/api/v1/chatbot/conversations/" + first.ConversationID
+	var conversation models.Conversation
+	if code := s.doWithHeaders(http.MethodGet, path, session, nil, &conversation); code != http.StatusOK || len(conversation.Messages) != 4 {
+		t.Fatalf("get: got status %d, %d messages", code, len(conversation.Messages))
+	}
+
+	// Sesi lain tidak boleh membaca, melanjutkan, atau menghapus percakapan
+	stranger := map[string]string{"X-Session-ID": "someone-else"}
+	if code := s.doWithHeaders(http.MethodGet, path, stranger, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("stranger get: got status %d, want 404", code)
+	}
+	if code := s.doWithHeaders(http.MethodPost, "/api/v1/chatbot/ask", stranger, followUp, nil); code != http.StatusNotFound {
+		t.Fatalf("stranger ask: got status %d, want 404", code)
+	}
+	if code := s.do(http.MethodGet, path, "", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("no session get: got status %d, want 404", code)
+	}
+
+	if code := s.do(http.MethodGet, "/api/v1/admin/conversations/"+first.ConversationID, adminToken, nil, &conversation); code != http.StatusOK || conversation.Messages[1].Role != models.ChatRoleAssistant {
+		t.Fatalf("admin transcript: got status %d, %+v", code, conversation.Messages)
+	}
+	s.do(http.MethodGet, "/api/v1/admin/conversations?sessionId="+first.SessionID, adminToken, nil, &list)
+	if len(list.Data) != 1 {
+		t.Fatalf("admin list: got %d conversations", len(list.Data))
+	}
+
+	if code := s.doWithHeaders(http.MethodDelete, path, stranger, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("stranger delete: got status %d, want 404", code)
+	}
+	if code := s.doWithHeaders(http.MethodDelete, path, session, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete: got status %d", code)
+	}
+	if code := s.doWithHeaders(http.MethodGet, path, session, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("deleted conversation: got status %d, want 404", code)
+	}
+}
+
+func TestChatbotStreamSendsTokensThenMetadata(t *testing.T) {
+	s := newTestServer(t)
+	topi := s.createProduct("Topi Baseball", 60000, 20)
+
+	payload, _ := json.Marshal(gin.H{"prompt": "Berapa harga topi?"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/chatbot/stream", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("stream: got status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var reply strings.Builder
+	var done struct {
+		ProductIDs     []string `json:"productIds"`
+		ConversationID string   `json:"conversationId"`
+		SessionID      string   `json:"sessionId"`
+	}
+	tokens := 0
+	for _, event := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		var name, data string
+		for _, line := range strings.Split(event, "\n") {
+			if strings.HasPrefix(line, "event:") {
+				name = strings.TrimPrefix(line, "event:")
+			} else if strings.HasPrefix(line, "data:") {
+				data = strings.TrimPrefix(line, "data:")
+			}
+		}
+		switch name {
+		case "token":
+			var token struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal([]byte(data), &token); err != nil {
+				t.Fatalf("token event %q: %v", data, err)
+			}
+			reply.WriteString(token.Text)
+			tokens++
+		case "done":
+			if err := json.Unmarshal([]byte(data), &done); err != nil {
+				t.Fatalf("done event %q: %v", data, err)
+			}
+		default:
+			t.Fatalf("unexpected event %q", event)
+		}
+	}
+
+	if tokens < 2 || !strings.Contains(reply.String(), "Topi Baseball") {
+		t.Fatalf("expected several tokens forming the reply, got %d: %q", tokens, reply.String())
+	}
+	if len(done.ProductIDs) != 1 || done.ProductIDs[0] != topi.ID.Hex() || done.ConversationID == "" {
+		t.Fatalf("unexpected done metadata %+v", done)
+	}
+
+	var conversation models.Conversation
+	path := "/api/v1/chatbot/conversations/" + done.ConversationID
+	s.doWithHeaders(http.MethodGet, path, map[string]string{"X-Session-ID": done.SessionID}, nil, &conversation)
+	if len(conversation.Messages) != 2 || conversation.Messages[1].Content != reply.String() {
+		t.Fatalf("streamed reply should be persisted: %+v", conversation.Messages)
+	}
+
+	missing := gin.H{"prompt": "Halo", "conversationId": topi.ID.Hex()}
+	if code := s.do(http.MethodPost, "/api/v1/chatbot/stream", "", missing, nil); code != http.StatusNotFound {
+		t.Fatalf("unknown conversation: got status %d, want 404", code)
+	}
+}
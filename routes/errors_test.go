@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tokobiru/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorsUseProblemJSONWithLocalizedMessages(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(`{"email":"bukan-email"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("register: got status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != apperror.ContentType {
+		t.Fatalf("content type: got %q", ct)
+	}
+	if lang := rec.Header().Get("Content-Language"); lang != "en" {
+		t.Fatalf("content language: got %q", lang)
+	}
+	var problem apperror.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Code != apperror.CodeValidationFailed || problem.Title != "Request contains invalid fields" ||
+		problem.Instance != "/api/v1/auth/register" || problem.RequestID != "req-123" {
+		t.Fatalf("unexpected problem %+v", problem)
+	}
+	fields := make(map[string]string)
+	for _, field := range problem.Errors {
+		fields[field.Field] = field.Rule
+	}
+	if fields["email"] != "email" || fields["name"] != "required" || fields["password"] != "required" {
+		t.Fatalf("unexpected field errors %+v", problem.Errors)
+	}
+
+	// Tanpa Accept-Language pesan memakai bahasa Indonesia
+	var idProblem apperror.Problem
+	s.do(http.MethodPost, "/api/v1/auth/register", "", gin.H{"email": "bukan-email"}, &idProblem)
+	if idProblem.Title != "Data yang dikirim tidak valid" {
+		t.Fatalf("default language: got %q", idProblem.Title)
+	}
+}
+
+func TestErrorsUseStableCodes(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	topi := s.createProduct("Topi", 60000, 1)
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		status int
+		code   apperror.Code
+	}{
+		{"empty cart", http.MethodPost, "/api/v1/orders/checkout", token, nil, http.StatusBadRequest, apperror.CodeCartEmpty},
+		{"unknown product", http.MethodGet, "/api/v1/products/64b7f0f0f0f0f0f0f0f0f0f0", "", nil, http.StatusNotFound, apperror.CodeProductNotFound},
+		{"invalid id", http.MethodGet, "/api/v1/products/abc", "", nil, http.StatusBadRequest, apperror.CodeInvalidID},
+		{"insufficient stock", http.MethodPost, "/api/v1/cart", token, gin.H{"productId": topi.ID.Hex(), "quantity": 2}, http.StatusNotFound, apperror.CodeInsufficientStock},
+		{"missing token", http.MethodGet, "/api/v1/cart", "", nil, http.StatusUnauthorized, apperror.CodeAuthRequired},
+		{"bad token", http.MethodGet, "/api/v1/cart", "not-a-jwt", nil, http.StatusUnauthorized, apperror.CodeTokenInvalid},
+		{"permission", http.MethodGet, "/api/v1/admin/users", token, nil, http.StatusForbidden, apperror.CodePermissionDenied},
+		{"unknown route", http.MethodGet, "/api/v1/nope", "", nil, http.StatusNotFound, apperror.CodeRouteNotFound},
+	} {
+		var problem apperror.Problem
+		code := s.do(tc.method, tc.path, tc.token, tc.body, &problem)
+		if code != tc.status || problem.Code != tc.code || problem.Status != tc.status {
+			t.Errorf("%s: got status %d, problem %+v, want %d %s", tc.name, code, problem, tc.status, tc.code)
+		}
+	}
+}
+
+func TestErrorsDoNotLeakInternalMessages(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), string(apperror.CodeInvalidRequestBody)) {
+		t.Fatalf("malformed body: got %d %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "unexpected EOF") {
+		t.Fatalf("decoder error leaked: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/cart", nil)
+	req.Header.Set("Authorization", "Bearer not-a-jwt")
+	s.router.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "token is malformed") || strings.Contains(rec.Body.String(), "details") {
+		t.Fatalf("JWT error leaked: %s", rec.Body.String())
+	}
+}
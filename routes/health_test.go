@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type failingHealth struct{}
+
+func (failingHealth) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	s := newTestServer(t)
+
+	if code := s.do(http.MethodGet, "/healthz", "", nil, nil); code != http.StatusOK {
+		t.Fatalf("healthz: got %d", code)
+	}
+
+	var ready struct {
+		Status string `json:"status"`
+		Checks struct {
+			MongoDB gin.H `json:"mongodb"`
+			LLM     struct {
+				Provider string `json:"provider"`
+				Fallback bool   `json:"fallback"`
+			} `json:"llm"`
+		} `json:"checks"`
+	}
+	if code := s.do(http.MethodGet, "/readyz", "", nil, &ready); code != http.StatusOK || ready.Status != "ok" {
+		t.Fatalf("readyz: got %d %+v", code, ready)
+	}
+	if ready.Checks.LLM.Provider != "rule" || ready.Checks.LLM.Fallback {
+		t.Fatalf("unexpected llm status %+v", ready.Checks.LLM)
+	}
+}
+
+func TestReadinessFailsWithoutDatabase(t *testing.T) {
+	s := newTestServer(t)
+	s.store.Health = failingHealth{}
+	// Router membaca store.Health saat SetupRoutes, jadi pasang ulang routes
+	router := gin.New()
+	if err := SetupRoutes(router, s.store, s.cfg, s.keys, s.mailer); err != nil {
+		t.Fatal(err)
+	}
+	s.router = router
+
+	var ready gin.H
+	if code := s.do(http.MethodGet, "/readyz", "", nil, &ready); code != http.StatusServiceUnavailable || ready["status"] != "unavailable" {
+		t.Fatalf("readyz: got %d %v", code, ready)
+	}
+	// Liveness tidak bergantung pada database
+	if code := s.do(http.MethodGet, "/healthz", "", nil, nil); code != http.StatusOK {
+		t.Fatalf("healthz: got %d", code)
+	}
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterNeverCreatesAdmins(t *testing.T) {
+	s := newTestServer(t)
+
+	var user models.User
+	code := s.do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"name": "Fan", "email": "myadminfan@gmail.com", "password": "rahasia123", "role": "admin",
+	}, &user)
+	if code != http.StatusCreated || user.Role != models.RoleCustomer {
+		t.Fatalf("register: got status %d, role %q", code, user.Role)
+	}
+}
+
+func TestAdminInvitationFlow(t *testing.T) {
+	s := newTestServer(t)
+	_, adminToken := s.createUser("owner@example.com", "secret123", "admin")
+	_, customerToken := s.createUser("customer@example.com", "secret123", "customer")
+
+	invite := gin.H{"email": "new-admin@example.com"}
+	if code := s.do(http.MethodPost, "/api/v1/admin/invitations", customerToken, invite, nil); code != http.StatusForbidden {
+		t.Fatalf("customer invite: got status %d, want 403", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/admin/invitations", adminToken, gin.H{"email": "customer@example.com"}, nil); code != http.StatusConflict {
+		t.Fatalf("invite existing email: got status %d, want 409", code)
+	}
+
+	var created struct {
+		Invitation models.Invitation `json:"invitation"`
+		Token      string            `json:"token"`
+	}
+	if code := s.do(http.MethodPost, "/api/v1/admin/invitations", adminToken, invite, &created); code != http.StatusCreated || created.Token == "" {
+		t.Fatalf("invite: got status %d, body %+v", code, created)
+	}
+
+	accept := gin.H{"token": created.Token, "name": "New Admin", "password": "rahasia123"}
+	var user models.User
+	if code := s.do(http.MethodPost, "/api/v1/auth/accept-invitation", "", accept, &user); code != http.StatusCreated {
+		t.Fatalf("accept: got status %d", code)
+	}
+	if user.Role != models.RoleAdmin || user.Email != "new-admin@example.com" || user.Password != "" {
+		t.Fatalf("accept: unexpected user %+v", user)
+	}
+
+	// Undangan hanya bisa dipakai sekali
+	if code := s.do(http.MethodPost, "/api/v1/auth/accept-invitation", "", accept, nil); code != http.StatusBadRequest {
+		t.Fatalf("reuse invitation: got status %d, want 400", code)
+	}
+
+	tokens := s.login("new-admin@example.com", "rahasia123")
+	if code := s.do(http.MethodGet, "/api/v1/admin/users", tokens.Token, nil, nil); code != http.StatusOK {
+		t.Fatalf("new admin access: got status %d", code)
+	}
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"tokobiru/models"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWKSVerifiesIssuedTokens(t *testing.T) {
+	s := newTestServer(t)
+	s.createUser("budi@example.com", "password123", models.RoleCustomer)
+
+	var pair services.TokenPair
+	if code := s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": "budi@example.com", "password": "password123"}, &pair); code != http.StatusOK {
+		t.Fatalf("login: got %d", code)
+	}
+
+	var set services.JWKSet
+	if code := s.do(http.MethodGet, "/.well-known/jwks.json", "", nil, &set); code != http.StatusOK {
+		t.Fatalf("jwks: got %d", code)
+	}
+
+	// Verifikasi token hanya dengan kunci publik dari JWKS, seperti layanan lain
+	_, err := jwt.Parse(pair.AccessToken, func(token *jwt.Token) (interface{}, error) {
+		for _, key := range set.Keys {
+			if key.Kid == token.Header["kid"] && key.Kty == "OKP" {
+				x, err := base64.RawURLEncoding.DecodeString(key.X)
+				return ed25519.PublicKey(x), err
+			}
+		}
+		return nil, jwt.ErrTokenUnverifiable
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	if err != nil {
+		t.Fatalf("verify with published key: %v", err)
+	}
+}
+
+func TestLegacyHS256TokenStillAccepted(t *testing.T) {
+	s := newTestServer(t)
+	user, _ := s.createUser("budi@example.com", "password123", models.RoleCustomer)
+
+	claims := &services.JWTClaims{UserID: user.ID.Hex(), Role: models.RoleCustomer}
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", legacy, nil, nil); code != http.StatusOK {
+		t.Fatalf("legacy token: got %d", code)
+	}
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("wrong-secret"))
+	if code := s.do(http.MethodGet, "/api/v1/cart", forged, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("forged token: got %d", code)
+	}
+}
@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/models"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type auditLogsResponse struct {
+	Data []models.AuditLog `json:"data"`
+	Meta struct {
+		Total int64 `json:"total"`
+	} `json:"meta"`
+}
+
+func TestAccountLockoutAndUnlock(t *testing.T) {
+	t.Setenv("LOGIN_MAX_FAILURES", "3")
+	s := newTestServer(t)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+	customer, _ := s.createUser("customer@example.com", "secret123", "customer")
+
+	wrong := gin.H{"email": customer.Email, "password": "salah"}
+	for i := 0; i < 3; i++ {
+		if code := s.do(http.MethodPost, "/api/v1/auth/login", "", wrong, nil); code != http.StatusUnauthorized {
+			t.Fatalf("failed attempt %d: got status %d, want 401", i+1, code)
+		}
+	}
+
+	// Password yang benar pun ditolak selama akun dikunci
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"email": "customer@example.com", "password": "secret123"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", body)
+	req.Header.Set("Content-Type", "application/json")
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("login while locked: got status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	var logs auditLogsResponse
+	if code := s.do(http.MethodGet, "/api/v1/admin/audit-logs?action=account_locked", adminToken, nil, &logs); code != http.StatusOK || logs.Meta.Total != 1 {
+		t.Fatalf("audit logs: got status %d, %+v", code, logs)
+	}
+	if entry := logs.Data[0]; entry.UserID == nil || *entry.UserID != customer.ID || entry.Email != customer.Email {
+		t.Fatalf("unexpected audit entry %+v", entry)
+	}
+
+	_, customerToken := s.createUser("other@example.com", "secret123", "customer")
+	if code := s.do(http.MethodPost, "/api/v1/admin/users/"+customer.ID.Hex()+"/unlock", customerToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("unlock as customer: got status %d, want 403", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/admin/users/"+customer.ID.Hex()+"/unlock", adminToken, nil, nil); code != http.StatusOK {
+		t.Fatalf("unlock: got status %d", code)
+	}
+	s.login(customer.Email, "secret123")
+
+	s.do(http.MethodGet, "/api/v1/admin/audit-logs?userId="+customer.ID.Hex(), adminToken, nil, &logs)
+	if logs.Meta.Total != 2 || logs.Data[0].Action != models.AuditAccountUnlocked || logs.Data[0].ActorID == nil {
+		t.Fatalf("unlock not audited: %+v", logs)
+	}
+}
+
+func TestLoginLockoutPerIP(t *testing.T) {
+	t.Setenv("LOGIN_MAX_FAILURES", "100")
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "4")
+	s := newTestServer(t)
+	s.createUser("customer@example.com", "secret123", "customer")
+
+	// Menebak banyak email dari satu IP juga dikunci
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"} {
+		s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": email, "password": "salah"}, nil)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": "customer@example.com", "password": "secret123"}, nil); code != http.StatusTooManyRequests {
+		t.Fatalf("login from locked IP: got status %d, want 429", code)
+	}
+}
+
+// Password yang benar tidak boleh me-reset penghitung sebelum kode TOTP
+// terverifikasi, jika tidak kode bisa ditebak tanpa batas
+func TestMFACodeGuessesStayLockedAfterCorrectPassword(t *testing.T) {
+	t.Setenv("LOGIN_MAX_FAILURES", "3")
+	s := newTestServer(t)
+	user, token := s.createUser("customer@example.com", "secret123", "customer")
+
+	var enrollment services.MFAEnrollment
+	s.do(http.MethodPost, "/api/v1/user/mfa/enroll", token, nil, &enrollment)
+	if code := s.do(http.MethodPost, "/api/v1/user/mfa/confirm", token, gin.H{"code": totpCodeAt(t, enrollment.Secret, time.Now())}, nil); code != http.StatusOK {
+		t.Fatalf("confirm: got status %d", code)
+	}
+
+	credentials := gin.H{"email": user.Email, "password": "secret123"}
+	for cycle := 0; cycle < 3; cycle++ {
+		var login mfaLoginResponse
+		var problem apperror.Problem
+		if code := s.do(http.MethodPost, "/api/v1/auth/login", "", credentials, &login); code != http.StatusOK {
+			if code != http.StatusTooManyRequests {
+				t.Fatalf("cycle %d: login got status %d", cycle+1, code)
+			}
+			s.do(http.MethodPost, "/api/v1/auth/login", "", credentials, &problem)
+			if problem.Code != apperror.CodeLoginLocked {
+				t.Fatalf("cycle %d: got code %q, want %q", cycle+1, problem.Code, apperror.CodeLoginLocked)
+			}
+			return
+		}
+		for i := 0; i < 2; i++ {
+			code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", gin.H{"mfaToken": login.MFAToken, "code": "000000"}, &problem)
+			if code == http.StatusTooManyRequests && problem.Code == apperror.CodeLoginLocked {
+				return
+			}
+			if code != http.StatusUnauthorized {
+				t.Fatalf("cycle %d: bad code got status %d", cycle+1, code)
+			}
+		}
+	}
+	t.Fatal("repeated password and bad code cycles never locked the account")
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tokobiru/logging"
+	"tokobiru/models"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Request-ID", "checkout-debug-42")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "checkout-debug-42" {
+		t.Fatalf("expected client request ID to be echoed, got %q", got)
+	}
+
+	// ID yang tidak valid diganti dengan ID baru
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Request-ID", "bad id\nwith newline")
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); len(got) != 32 {
+		t.Fatalf("expected generated request ID, got %q", got)
+	}
+}
+
+func TestRequestLogIncludesRequestAndUserID(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("budi@example.com", "password123", models.RoleCustomer)
+
+	var buf bytes.Buffer
+	logger, err := logging.NewLogger(&buf, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	s.doWithHeaders(http.MethodGet, "/api/v1/cart", map[string]string{
+		"Authorization": "Bearer " + token,
+		"X-Request-ID":  "trace-cart-1",
+	}, nil, nil)
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %q", scanner.Text())
+		}
+		if line["msg"] != "http request" {
+			continue
+		}
+		if line["request_id"] != "trace-cart-1" || line["user_id"] == nil || line["route"] != "/api/v1/cart" {
+			t.Fatalf("unexpected access log %v", line)
+		}
+		return
+	}
+	t.Fatalf("no access log line in %q", buf.String())
+}
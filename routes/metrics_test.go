@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tokobiru/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsEndpointAndBusinessCounters(t *testing.T) {
+	s := newTestServer(t)
+	_, first := s.createUser("first@example.com", "secret123", "customer")
+	_, second := s.createUser("second@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 2)
+
+	// Metrik bersifat global untuk seluruh test, jadi yang dibandingkan adalah selisihnya
+	additions := testutil.ToFloat64(metrics.CartAdditions)
+	succeeded := testutil.ToFloat64(metrics.Checkouts.WithLabelValues("success"))
+	outOfStock := testutil.ToFloat64(metrics.Checkouts.WithLabelValues("out_of_stock"))
+	cartStockOuts := testutil.ToFloat64(metrics.StockOuts.WithLabelValues("cart"))
+	checkoutStockOuts := testutil.ToFloat64(metrics.StockOuts.WithLabelValues("checkout"))
+
+	s.do(http.MethodPost, "/api/v1/cart", first, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, nil)
+	s.do(http.MethodPost, "/api/v1/cart", second, gin.H{"productId": kaos.ID.Hex(), "quantity": 1}, nil)
+	s.do(http.MethodPost, "/api/v1/cart", second, gin.H{"productId": kaos.ID.Hex(), "quantity": 5}, nil)
+	if code := s.do(http.MethodPost, "/api/v1/orders/checkout", first, nil, nil); code != http.StatusCreated {
+		t.Fatalf("first checkout: got %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/orders/checkout", second, nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("second checkout: got %d", code)
+	}
+
+	for _, check := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"cart additions", testutil.ToFloat64(metrics.CartAdditions) - additions, 2},
+		{"checkouts succeeded", testutil.ToFloat64(metrics.Checkouts.WithLabelValues("success")) - succeeded, 1},
+		{"checkouts out of stock", testutil.ToFloat64(metrics.Checkouts.WithLabelValues("out_of_stock")) - outOfStock, 1},
+		{"cart stock-outs", testutil.ToFloat64(metrics.StockOuts.WithLabelValues("cart")) - cartStockOuts, 1},
+		{"checkout stock-outs", testutil.ToFloat64(metrics.StockOuts.WithLabelValues("checkout")) - checkoutStockOuts, 1},
+	} {
+		if check.got != check.want {
+			t.Errorf("%s: got delta %v, want %v", check.name, check.got, check.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics: got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`tokobiru_http_requests_total{method="POST",route="/api/v1/orders/checkout",status="201"}`,
+		`tokobiru_http_request_duration_seconds_bucket{method="POST",route="/api/v1/cart",le="0.005"}`,
+		`tokobiru_checkouts_total{outcome="success"}`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mfaLoginResponse struct {
+	Token       string `json:"token"`
+	MFARequired bool   `json:"mfaRequired"`
+	MFAEnrolled bool   `json:"mfaEnrolled"`
+	MFAToken    string `json:"mfaToken"`
+}
+
+type mfaVerifyResponse struct {
+	Token         string   `json:"token"`
+	RefreshToken  string   `json:"refreshToken"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func totpCodeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := services.GenerateTOTPCode(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestOptionalMFALogin(t *testing.T) {
+	s := newTestServer(t)
+	user, token := s.createUser("customer@example.com", "secret123", "customer")
+
+	var enrollment services.MFAEnrollment
+	if code := s.do(http.MethodPost, "/api/v1/user/mfa/enroll", token, nil, &enrollment); code != http.StatusOK || enrollment.Secret == "" {
+		t.Fatalf("enroll: got status %d, %+v", code, enrollment)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/user/mfa/confirm", token, gin.H{"code": "000000"}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("confirm with wrong code: got status %d, want 401", code)
+	}
+	var confirmed struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+	now := time.Now()
+	if code := s.do(http.MethodPost, "/api/v1/user/mfa/confirm", token, gin.H{"code": totpCodeAt(t, enrollment.Secret, now)}, &confirmed); code != http.StatusOK || len(confirmed.RecoveryCodes) != 10 {
+		t.Fatalf("confirm: got status %d, %d recovery codes", code, len(confirmed.RecoveryCodes))
+	}
+
+	// Password saja tidak menghasilkan access token
+	var login mfaLoginResponse
+	s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": user.Email, "password": "secret123"}, &login)
+	if !login.MFARequired || !login.MFAEnrolled || login.Token != "" || login.MFAToken == "" {
+		t.Fatalf("login: unexpected response %+v", login)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", login.MFAToken, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("mfa token as access token: got status %d, want 401", code)
+	}
+
+	// Kode yang sudah dipakai saat konfirmasi tidak bisa dipakai lagi
+	used := totpCodeAt(t, enrollment.Secret, now)
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", gin.H{"mfaToken": login.MFAToken, "code": used}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("replayed code: got status %d, want 401", code)
+	}
+	next := totpCodeAt(t, enrollment.Secret, now.Add(30*time.Second))
+	var verified mfaVerifyResponse
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", gin.H{"mfaToken": login.MFAToken, "code": next}, &verified); code != http.StatusOK || verified.Token == "" {
+		t.Fatalf("verify: got status %d, %+v", code, verified)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", verified.Token, nil, nil); code != http.StatusOK {
+		t.Fatalf("cart after mfa login: got status %d", code)
+	}
+
+	// Recovery code hanya bisa dipakai sekali
+	recovery := confirmed.RecoveryCodes[0]
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", gin.H{"mfaToken": login.MFAToken, "code": recovery}, nil); code != http.StatusOK {
+		t.Fatalf("verify with recovery code: got status %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", gin.H{"mfaToken": login.MFAToken, "code": recovery}, nil); code != http.StatusUnauthorized {
+		t.Fatalf("reuse recovery code: got status %d, want 401", code)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/user/mfa/disable", verified.Token, gin.H{"code": confirmed.RecoveryCodes[1]}, nil); code != http.StatusOK {
+		t.Fatalf("disable: got status %d", code)
+	}
+	s.login(user.Email, "secret123")
+}
+
+func TestMFARequiredForRole(t *testing.T) {
+	t.Setenv("MFA_REQUIRED_ROLES", "admin")
+	s := newTestServer(t)
+	admin, _ := s.createUser("admin@example.com", "secret123", "admin")
+
+	var login mfaLoginResponse
+	s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"email": admin.Email, "password": "secret123"}, &login)
+	if !login.MFARequired || login.MFAEnrolled || login.Token != "" {
+		t.Fatalf("login: unexpected response %+v", login)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", gin.H{"mfaToken": login.MFAToken, "code": "123456"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("verify before enrollment: got status %d, want 400", code)
+	}
+
+	var enrollment services.MFAEnrollment
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/enroll", "", gin.H{"mfaToken": login.MFAToken}, &enrollment); code != http.StatusOK || enrollment.OTPAuthURI == "" {
+		t.Fatalf("enroll during login: got status %d, %+v", code, enrollment)
+	}
+	var verified mfaVerifyResponse
+	body := gin.H{"mfaToken": login.MFAToken, "code": totpCodeAt(t, enrollment.Secret, time.Now())}
+	if code := s.do(http.MethodPost, "/api/v1/auth/mfa/verify", "", body, &verified); code != http.StatusOK || verified.Token == "" || len(verified.RecoveryCodes) != 10 {
+		t.Fatalf("verify enrollment: got status %d, %+v", code, verified)
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/user/mfa/disable", verified.Token, gin.H{"code": verified.RecoveryCodes[0]}, nil); code != http.StatusConflict {
+		t.Fatalf("disable required mfa: got status %d, want 409", code)
+	}
+}
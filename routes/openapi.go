@@ -0,0 +1,448 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"tokobiru/apperror"
+	"tokobiru/controllers"
+	"tokobiru/models"
+	"tokobiru/openapi"
+	"tokobiru/services"
+)
+
+// Lokasi dokumen OpenAPI dan halaman Swagger UI
+const (
+	openAPIPath = "/api/v1/openapi.json"
+	docsPath    = "/api/v1/docs"
+)
+
+// authMode adalah middleware autentikasi yang dipasang di sebuah rute
+type authMode int
+
+const (
+	public authMode = iota
+	optionalAuth
+	requiredAuth
+)
+
+// endpoint mendokumentasikan satu rute di SetupRoutes. Kode error dari
+// middleware (autentikasi, permission, binding body) dan INTERNAL_ERROR
+// ditambahkan otomatis; errors hanya berisi kode dari handler.
+type endpoint struct {
+	method, path string
+	id           string
+	tag          string
+	summary      string
+	description  string
+	auth         authMode
+	permission   string
+	params       []openapi.Parameter
+	body         any
+	optionalBody bool
+	replies      []reply
+	errors       []apperror.Code
+	// statuses mengganti status bawaan kode error di rute ini
+	statuses map[apperror.Code]int
+}
+
+// reply adalah satu response sukses. contentType kosong berarti JSON.
+type reply struct {
+	status      int
+	description string
+	contentType string
+	body        any
+}
+
+func ok(body any) reply      { return reply{status: http.StatusOK, body: body} }
+func created(body any) reply { return reply{status: http.StatusCreated, body: body} }
+
+func pathID(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "path", Required: true, Description: description, Schema: openapi.ObjectID()}
+}
+
+func query(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// pageParams adalah parameter paginasi yang dipakai semua endpoint daftar
+func pageParams(defaultLimit int) []openapi.Parameter {
+	one := 1.0
+	return []openapi.Parameter{
+		query("page", "Halaman, mulai dari 1", &openapi.Schema{Type: "integer", Minimum: &one, Default: 1}),
+		query("limit", "Jumlah data per halaman", &openapi.Schema{Type: "integer", Minimum: &one, Default: defaultLimit}),
+	}
+}
+
+func object(required []string, properties map[string]*openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{Type: "object", Required: required, Properties: properties}
+}
+
+var (
+	stringSchema  = &openapi.Schema{Type: "string"}
+	booleanSchema = &openapi.Schema{Type: "boolean"}
+	integerSchema = &openapi.Schema{Type: "integer", Format: "int64"}
+
+	cartStockStatus = map[apperror.Code]int{apperror.CodeInsufficientStock: controllers.CartInsufficientStockStatus}
+)
+
+// apiSpec membangun dokumen OpenAPI untuk semua rute di SetupRoutes. Setiap
+// rute baru harus ditambahkan di sini; TestOpenAPICoversEveryRoute gagal jika
+// ada rute yang belum didokumentasikan, dan TestOpenAPISecurityMatchesMiddleware
+// gagal jika auth atau permission berbeda dari middleware rutenya.
+func apiSpec() *openapi.Spec {
+	spec := openapi.New(openapi.Info{
+		Title:   "Toko Biru API",
+		Version: "1.0.0",
+		Description: "REST API Toko Biru. Semua error dikirim sebagai application/problem+json (RFC 7807) " +
+			"dengan `code` yang stabil; pesan mengikuti header Accept-Language (id atau en). " +
+			"Setiap response membawa header X-Request-ID.",
+	})
+	spec.AddSecurityScheme("bearerAuth", openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Access token dari /api/v1/auth/login atau /api/v1/auth/refresh",
+	})
+	for _, tag := range []openapi.Tag{
+		{Name: "auth", Description: "Registrasi, login, refresh token dan pemulihan akun"},
+		{Name: "products", Description: "Katalog produk"},
+		{Name: "cart", Description: "Keranjang belanja milik user"},
+		{Name: "orders", Description: "Checkout dan riwayat pesanan milik user"},
+		{Name: "user", Description: "Profil dan two-factor authentication milik user"},
+		{Name: "chatbot", Description: "Asisten belanja; bisa dipakai tanpa login dengan header X-Session-ID"},
+		{Name: "admin", Description: "Dashboard admin, setiap rute membutuhkan permission masing-masing"},
+		{Name: "system", Description: "Health check, metrik, kunci publik dan dokumentasi"},
+	} {
+		spec.AddTag(tag)
+	}
+
+	problem := spec.Schema(apperror.Problem{})
+	message := spec.Define("Message", object([]string{"message"}, map[string]*openapi.Schema{"message": stringSchema}))
+	pageMeta := spec.Define("PageMeta", object([]string{"total", "page", "limit", "total_pages"}, map[string]*openapi.Schema{
+		"total":       integerSchema,
+		"page":        integerSchema,
+		"limit":       integerSchema,
+		"total_pages": integerSchema,
+	}))
+	page := func(item any) *openapi.Schema {
+		return object([]string{"data", "meta"}, map[string]*openapi.Schema{
+			"data": {Type: "array", Items: spec.Schema(item)},
+			"meta": pageMeta,
+		})
+	}
+	tokenPair := spec.Schema(services.TokenPair{})
+	mfaChallenge := spec.Define("MFAChallenge", object([]string{"mfaRequired", "mfaEnrolled", "mfaToken"}, map[string]*openapi.Schema{
+		"mfaRequired": booleanSchema,
+		"mfaEnrolled": booleanSchema,
+		"mfaToken":    {Type: "string", Description: "Token \"mfa pending\" untuk /auth/mfa/enroll dan /auth/mfa/verify"},
+	}))
+	recoveryCodes := object([]string{"recoveryCodes"}, map[string]*openapi.Schema{
+		"recoveryCodes": {Type: "array", Items: stringSchema, Description: "Hanya ditampilkan sekali"},
+	})
+	health := object([]string{"status"}, map[string]*openapi.Schema{"status": stringSchema})
+	readiness := object([]string{"status", "checks"}, map[string]*openapi.Schema{
+		"status": {Type: "string", Enum: []string{"ok", "unavailable"}},
+		"checks": object([]string{"mongodb", "llm"}, map[string]*openapi.Schema{
+			"mongodb": object([]string{"status"}, map[string]*openapi.Schema{
+				"status":    stringSchema,
+				"latencyMs": integerSchema,
+				"error":     stringSchema,
+			}),
+			"llm": spec.Schema(services.LLMStatus{}),
+		}),
+	})
+	sessionHeader := openapi.Parameter{Name: controllers.SessionHeader, In: "header", Description: "ID sesi anonim dari response sebelumnya, untuk melanjutkan percakapan tanpa login", Schema: stringSchema}
+	userID := query("userId", "Filter berdasarkan user", openapi.ObjectID())
+
+	endpoints := []endpoint{
+		// Sistem
+		{method: "GET", path: "/healthz", id: "liveness", tag: "system", summary: "Liveness probe",
+			replies: []reply{ok(health)}},
+		{method: "GET", path: "/readyz", id: "readiness", tag: "system", summary: "Readiness probe (MongoDB dan provider LLM)",
+			replies: []reply{ok(readiness), {status: http.StatusServiceUnavailable, description: "MongoDB tidak bisa dihubungi", body: readiness}}},
+		{method: "GET", path: "/metrics", id: "metrics", tag: "system", summary: "Metrik Prometheus",
+			replies: []reply{{status: http.StatusOK, contentType: "text/plain; version=0.0.4", body: stringSchema}}},
+		{method: "GET", path: "/.well-known/jwks.json", id: "getJWKS", tag: "system", summary: "Kunci publik untuk memverifikasi access token (RFC 7517)",
+			replies: []reply{ok(services.JWKSet{})}},
+		{method: "GET", path: openAPIPath, id: "getOpenAPI", tag: "system", summary: "Dokumen OpenAPI ini",
+			replies: []reply{ok(&openapi.Schema{Type: "object"})}},
+		{method: "GET", path: docsPath, id: "getDocs", tag: "system", summary: "Dokumentasi interaktif (Swagger UI)",
+			replies: []reply{{status: http.StatusOK, contentType: "text/html", body: stringSchema}}},
+
+		// Chatbot
+		{method: "POST", path: "/api/v1/chatbot/ask", id: "askChatbot", tag: "chatbot", summary: "Tanya chatbot", auth: optionalAuth,
+			description: "Tanpa conversationId percakapan baru dibuat. Pemanggil anonim menerima sessionId yang harus dikirim ulang di header X-Session-ID.",
+			params:      []openapi.Parameter{sessionHeader}, body: controllers.ChatInput{},
+			replies: []reply{ok(services.ChatResponse{})}, errors: []apperror.Code{apperror.CodeConversationNotFound}},
+		{method: "POST", path: "/api/v1/chatbot/stream", id: "streamChatbot", tag: "chatbot", summary: "Tanya chatbot dengan jawaban bertahap (Server-Sent Events)", auth: optionalAuth,
+			description: "Event `token` berisi {\"text\"} untuk setiap potongan jawaban, lalu `done` berisi {productIds, conversationId, sessionId}. " +
+				"Jika gagal setelah stream dimulai, dikirim event `error` berisi Problem.",
+			params: []openapi.Parameter{sessionHeader}, body: controllers.ChatInput{},
+			replies: []reply{{status: http.StatusOK, contentType: "text/event-stream", body: stringSchema}}, errors: []apperror.Code{apperror.CodeConversationNotFound}},
+		{method: "GET", path: "/api/v1/chatbot/conversations", id: "listConversations", tag: "chatbot", summary: "Daftar percakapan milik pemanggil", auth: optionalAuth,
+			params: append([]openapi.Parameter{sessionHeader}, pageParams(10)...), replies: []reply{ok(page(models.Conversation{}))}},
+		{method: "GET", path: "/api/v1/chatbot/conversations/:id", id: "getConversation", tag: "chatbot", summary: "Isi lengkap percakapan milik pemanggil", auth: optionalAuth,
+			params: []openapi.Parameter{sessionHeader}, replies: []reply{ok(models.Conversation{})}, errors: []apperror.Code{apperror.CodeConversationNotFound}},
+		{method: "DELETE", path: "/api/v1/chatbot/conversations/:id", id: "deleteConversation", tag: "chatbot", summary: "Hapus percakapan milik pemanggil", auth: optionalAuth,
+			params: []openapi.Parameter{sessionHeader}, replies: []reply{ok(message)}, errors: []apperror.Code{apperror.CodeConversationNotFound}},
+
+		// Autentikasi
+		{method: "POST", path: "/api/v1/auth/register", id: "register", tag: "auth", summary: "Daftar sebagai customer",
+			body: models.User{}, replies: []reply{created(models.User{})}, errors: []apperror.Code{apperror.CodeEmailTaken}},
+		{method: "POST", path: "/api/v1/auth/login", id: "login", tag: "auth", summary: "Login dengan email dan password",
+			description: "Jika role user mewajibkan 2FA, response berisi MFAChallenge dan login dilanjutkan di /auth/mfa/verify. " +
+				"LOGIN_LOCKED disertai header Retry-After.",
+			body: controllers.LoginInput{}, replies: []reply{ok(&openapi.Schema{OneOf: []*openapi.Schema{tokenPair, mfaChallenge}})},
+			errors: []apperror.Code{apperror.CodeInvalidCredentials, apperror.CodeLoginLocked, apperror.CodeEmailNotVerified}},
+		{method: "POST", path: "/api/v1/auth/refresh", id: "refreshToken", tag: "auth", summary: "Tukar refresh token dengan pasangan token baru",
+			description: "Setiap refresh token hanya bisa dipakai sekali. Pemakaian ulang mencabut seluruh sesi.",
+			body:        controllers.RefreshInput{}, replies: []reply{ok(tokenPair)},
+			errors: []apperror.Code{apperror.CodeRefreshTokenInvalid, apperror.CodeRefreshTokenReused}},
+		{method: "POST", path: "/api/v1/auth/logout", id: "logout", tag: "auth", summary: "Cabut sesi saat ini", auth: optionalAuth,
+			description: "Sesi dikenali dari access token dan/atau refresh token di body; salah satunya wajib dikirim.",
+			body:        controllers.LogoutInput{}, optionalBody: true, replies: []reply{ok(message)}},
+		{method: "POST", path: "/api/v1/auth/accept-invitation", id: "acceptInvitation", tag: "auth", summary: "Buat akun dari undangan admin",
+			body: controllers.AcceptInvitationInput{}, replies: []reply{created(models.User{})},
+			errors: []apperror.Code{apperror.CodeInvitationInvalid, apperror.CodeEmailTaken}},
+		{method: "POST", path: "/api/v1/auth/forgot-password", id: "forgotPassword", tag: "auth", summary: "Kirim link reset password",
+			description: "Response selalu sama, terdaftar atau tidak, agar email tidak bisa ditebak.",
+			body:        controllers.EmailInput{}, replies: []reply{ok(message)}},
+		{method: "POST", path: "/api/v1/auth/reset-password", id: "resetPassword", tag: "auth", summary: "Ganti password dengan token reset",
+			body: controllers.ResetPasswordInput{}, replies: []reply{ok(message)}, errors: []apperror.Code{apperror.CodeOneTimeTokenInvalid}},
+		{method: "POST", path: "/api/v1/auth/verify-email", id: "verifyEmail", tag: "auth", summary: "Verifikasi email dengan token dari email",
+			body: controllers.TokenInput{}, replies: []reply{ok(message)}, errors: []apperror.Code{apperror.CodeOneTimeTokenInvalid}},
+		{method: "POST", path: "/api/v1/auth/resend-verification", id: "resendVerification", tag: "auth", summary: "Kirim ulang link verifikasi email",
+			body: controllers.EmailInput{}, replies: []reply{ok(message)}},
+		{method: "POST", path: "/api/v1/auth/mfa/enroll", id: "loginEnrollMFA", tag: "auth", summary: "Mulai enrollment 2FA saat login",
+			body: controllers.MFATokenInput{}, replies: []reply{ok(services.MFAEnrollment{})},
+			errors: []apperror.Code{apperror.CodeMFATokenInvalid, apperror.CodeMFAAlreadyEnabled}},
+		{method: "POST", path: "/api/v1/auth/mfa/verify", id: "loginVerifyMFA", tag: "auth", summary: "Selesaikan login dengan kode TOTP atau recovery code",
+			body: controllers.MFAVerifyInput{}, replies: []reply{ok(controllers.MFAVerifyResponse{})},
+			errors: []apperror.Code{apperror.CodeMFATokenInvalid, apperror.CodeMFACodeInvalid, apperror.CodeMFANotStarted, apperror.CodeLoginLocked}},
+
+		// Produk
+		{method: "GET", path: "/api/v1/products", id: "listProducts", tag: "products", summary: "Daftar produk",
+			params: append([]openapi.Parameter{
+				query("name", "Cari produk yang namanya mengandung teks ini (tidak membedakan huruf besar/kecil)", stringSchema),
+				query("category", "Filter berdasarkan kategori", stringSchema),
+			}, pageParams(10)...),
+			replies: []reply{ok(page(models.Product{}))}},
+		{method: "GET", path: "/api/v1/products/:id", id: "getProduct", tag: "products", summary: "Detail produk",
+			params: []openapi.Parameter{pathID("id", "ID produk")}, replies: []reply{ok(models.Product{})},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeProductNotFound}},
+		{method: "POST", path: "/api/v1/products", id: "createProduct", tag: "products", summary: "Tambah produk", auth: requiredAuth, permission: models.PermProductsWrite,
+			body: models.Product{}, replies: []reply{created(models.Product{})}},
+		{method: "PUT", path: "/api/v1/products/:id", id: "updateProduct", tag: "products", summary: "Ubah produk", auth: requiredAuth, permission: models.PermProductsWrite,
+			params: []openapi.Parameter{pathID("id", "ID produk")}, body: models.Product{}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeProductNotFound}},
+		{method: "DELETE", path: "/api/v1/products/:id", id: "deleteProduct", tag: "products", summary: "Hapus produk", auth: requiredAuth, permission: models.PermProductsWrite,
+			params: []openapi.Parameter{pathID("id", "ID produk")}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeProductNotFound}},
+
+		// Keranjang
+		{method: "GET", path: "/api/v1/cart", id: "getCart", tag: "cart", summary: "Keranjang milik user", auth: requiredAuth, permission: models.PermCartManage,
+			replies: []reply{ok(models.Cart{})}},
+		{method: "POST", path: "/api/v1/cart", id: "addCartItem", tag: "cart", summary: "Tambah produk ke keranjang", auth: requiredAuth, permission: models.PermCartManage,
+			body:     controllers.AddCartItemInput{},
+			replies:  []reply{ok(models.Cart{}), {status: http.StatusCreated, description: "Keranjang baru dibuat", body: models.Cart{}}},
+			errors:   []apperror.Code{apperror.CodeInvalidID, apperror.CodeProductNotFound, apperror.CodeInsufficientStock},
+			statuses: cartStockStatus},
+		{method: "PUT", path: "/api/v1/cart", id: "updateCartItem", tag: "cart", summary: "Ubah jumlah produk di keranjang", auth: requiredAuth, permission: models.PermCartManage,
+			body: controllers.UpdateCartItemInput{}, replies: []reply{ok(message)},
+			errors:   []apperror.Code{apperror.CodeInvalidID, apperror.CodeProductNotFound, apperror.CodeCartItemNotFound, apperror.CodeInsufficientStock},
+			statuses: cartStockStatus},
+		{method: "DELETE", path: "/api/v1/cart/:productId", id: "removeCartItem", tag: "cart", summary: "Hapus produk dari keranjang", auth: requiredAuth, permission: models.PermCartManage,
+			params: []openapi.Parameter{pathID("productId", "ID produk")}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeCartItemNotFound}},
+
+		// Pesanan
+		{method: "POST", path: "/api/v1/orders/checkout", id: "checkout", tag: "orders", summary: "Checkout keranjang menjadi pesanan", auth: requiredAuth, permission: models.PermOrdersOwn,
+			description: "Stok dikurangi dan keranjang dikosongkan secara atomik; jika satu produk gagal, tidak ada yang berubah.",
+			replies: []reply{created(object([]string{"message", "order"}, map[string]*openapi.Schema{
+				"message": stringSchema,
+				"order":   spec.Schema(models.Order{}),
+			}))},
+			errors: []apperror.Code{apperror.CodeCartEmpty, apperror.CodeProductNotFound, apperror.CodeInsufficientStock, apperror.CodeCheckoutConflict}},
+		{method: "GET", path: "/api/v1/orders", id: "listOrders", tag: "orders", summary: "Riwayat pesanan milik user", auth: requiredAuth, permission: models.PermOrdersOwn,
+			replies: []reply{ok([]models.Order{})}},
+		{method: "GET", path: "/api/v1/orders/:id", id: "getOrder", tag: "orders", summary: "Detail pesanan milik user", auth: requiredAuth, permission: models.PermOrdersOwn,
+			params: []openapi.Parameter{pathID("id", "ID pesanan")}, replies: []reply{ok(models.Order{})},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeOrderNotFound}},
+
+		// Admin
+		{method: "GET", path: "/api/v1/admin/users", id: "adminListUsers", tag: "admin", summary: "Daftar semua user", auth: requiredAuth, permission: models.PermUsersRead,
+			replies: []reply{ok([]models.User{})}},
+		{method: "PUT", path: "/api/v1/admin/users/:id/role", id: "adminAssignRole", tag: "admin", summary: "Ganti role user", auth: requiredAuth, permission: models.PermRolesManage,
+			description: "Role baru berlaku pada access token berikutnya.",
+			params:      []openapi.Parameter{pathID("id", "ID user")}, body: controllers.AssignRoleInput{}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeRoleNotFound, apperror.CodeLastAdmin}},
+		{method: "POST", path: "/api/v1/admin/users/:id/unlock", id: "adminUnlockUser", tag: "admin", summary: "Buka kunci akun yang terkunci karena login gagal", auth: requiredAuth, permission: models.PermUsersUnlock,
+			params: []openapi.Parameter{pathID("id", "ID user")}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeUserNotFound}},
+		{method: "GET", path: "/api/v1/admin/audit-logs", id: "adminListAuditLogs", tag: "admin", summary: "Audit log keamanan, terbaru lebih dulu", auth: requiredAuth, permission: models.PermAuditRead,
+			params: append([]openapi.Parameter{
+				query("action", "Filter berdasarkan aksi", &openapi.Schema{Type: "string", Enum: []string{models.AuditAccountLocked, models.AuditAccountUnlocked, models.AuditIPLocked, models.AuditPasswordReset}}),
+				userID,
+			}, pageParams(20)...),
+			replies: []reply{ok(page(models.AuditLog{}))}, errors: []apperror.Code{apperror.CodeInvalidID}},
+		{method: "GET", path: "/api/v1/admin/orders", id: "adminListOrders", tag: "admin", summary: "Semua pesanan", auth: requiredAuth, permission: models.PermOrdersRead,
+			replies: []reply{ok([]models.Order{})}},
+		{method: "PATCH", path: "/api/v1/admin/orders/:id", id: "adminUpdateOrderStatus", tag: "admin", summary: "Ubah status pesanan", auth: requiredAuth, permission: models.PermOrdersUpdateStatus,
+			description: "Status yang valid: " + strings.Join(models.OrderStatuses, ", ") + ".",
+			params:      []openapi.Parameter{pathID("id", "ID pesanan")}, body: controllers.OrderStatusInput{}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeOrderNotFound}},
+		{method: "GET", path: "/api/v1/admin/sales-report", id: "adminSalesReport", tag: "admin", summary: "Laporan penjualan (WIB)", auth: requiredAuth, permission: models.PermReportsRead,
+			params: []openapi.Parameter{
+				query("from", "Tanggal awal, inklusif (YYYY-MM-DD)", &openapi.Schema{Type: "string", Format: "date"}),
+				query("to", "Tanggal akhir, inklusif (YYYY-MM-DD)", &openapi.Schema{Type: "string", Format: "date"}),
+				query("interval", "Pengelompokan time series", &openapi.Schema{Type: "string", Enum: []string{"day", "week", "month"}, Default: "day"}),
+			},
+			replies: []reply{ok(models.SalesReport{})}},
+		{method: "GET", path: "/api/v1/admin/conversations", id: "adminListConversations", tag: "admin", summary: "Daftar percakapan chatbot untuk review", auth: requiredAuth, permission: models.PermConversationsRead,
+			params:  append([]openapi.Parameter{userID, query("sessionId", "Filter berdasarkan sesi anonim", stringSchema)}, pageParams(20)...),
+			replies: []reply{ok(page(models.Conversation{}))}, errors: []apperror.Code{apperror.CodeInvalidID}},
+		{method: "GET", path: "/api/v1/admin/conversations/:id", id: "adminGetConversation", tag: "admin", summary: "Isi lengkap percakapan chatbot", auth: requiredAuth, permission: models.PermConversationsRead,
+			params: []openapi.Parameter{pathID("id", "ID percakapan")}, replies: []reply{ok(models.Conversation{})},
+			errors: []apperror.Code{apperror.CodeInvalidID, apperror.CodeConversationNotFound}},
+		{method: "POST", path: "/api/v1/admin/invitations", id: "adminCreateInvitation", tag: "admin", summary: "Undang admin baru", auth: requiredAuth, permission: models.PermInvitationsCreate,
+			description: "Token undangan hanya ditampilkan di response ini.",
+			body:        controllers.EmailInput{},
+			replies: []reply{created(object([]string{"invitation", "token"}, map[string]*openapi.Schema{
+				"invitation": spec.Schema(models.Invitation{}),
+				"token":      stringSchema,
+			}))},
+			errors: []apperror.Code{apperror.CodeEmailTaken}},
+		{method: "GET", path: "/api/v1/admin/permissions", id: "adminListPermissions", tag: "admin", summary: "Semua permission yang bisa diberikan ke role", auth: requiredAuth, permission: models.PermRolesManage,
+			replies: []reply{ok(&openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string", Enum: models.Permissions}})}},
+		{method: "GET", path: "/api/v1/admin/roles", id: "adminListRoles", tag: "admin", summary: "Daftar role beserta permission", auth: requiredAuth, permission: models.PermRolesManage,
+			replies: []reply{ok([]models.Role{})}},
+		{method: "POST", path: "/api/v1/admin/roles", id: "adminCreateRole", tag: "admin", summary: "Buat role custom", auth: requiredAuth, permission: models.PermRolesManage,
+			body: controllers.RoleInput{}, replies: []reply{created(models.Role{})},
+			errors: []apperror.Code{apperror.CodeRoleNameInvalid, apperror.CodePermissionUnknown, apperror.CodeRoleExists}},
+		{method: "PUT", path: "/api/v1/admin/roles/:name", id: "adminUpdateRole", tag: "admin", summary: "Ubah deskripsi dan permission role", auth: requiredAuth, permission: models.PermRolesManage,
+			params: []openapi.Parameter{{Name: "name", In: "path", Required: true, Description: "Nama role", Schema: stringSchema}},
+			body:   controllers.RoleInput{}, replies: []reply{ok(models.Role{})},
+			errors: []apperror.Code{apperror.CodePermissionUnknown, apperror.CodeRoleNotFound, apperror.CodeRoleBuiltIn}},
+		{method: "DELETE", path: "/api/v1/admin/roles/:name", id: "adminDeleteRole", tag: "admin", summary: "Hapus role custom yang tidak dipakai", auth: requiredAuth, permission: models.PermRolesManage,
+			params:  []openapi.Parameter{{Name: "name", In: "path", Required: true, Description: "Nama role", Schema: stringSchema}},
+			replies: []reply{ok(message)},
+			errors:  []apperror.Code{apperror.CodeRoleNotFound, apperror.CodeRoleBuiltIn, apperror.CodeRoleInUse}},
+
+		// Profil user
+		{method: "PUT", path: "/api/v1/user/profile", id: "updateProfile", tag: "user", summary: "Ubah nama dan/atau password sendiri", auth: requiredAuth,
+			body: controllers.ProfileInput{}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeNothingToUpdate, apperror.CodeUserNotFound}},
+		{method: "GET", path: "/api/v1/user/mfa", id: "getMFAStatus", tag: "user", summary: "Status 2FA", auth: requiredAuth,
+			replies: []reply{ok(object([]string{"enabled", "required", "recoveryCodesRemaining"}, map[string]*openapi.Schema{
+				"enabled":                booleanSchema,
+				"required":               {Type: "boolean", Description: "2FA wajib untuk role user"},
+				"recoveryCodesRemaining": integerSchema,
+			}))},
+			errors: []apperror.Code{apperror.CodeUserNotFound}},
+		{method: "POST", path: "/api/v1/user/mfa/enroll", id: "enrollMFA", tag: "user", summary: "Buat secret TOTP baru", auth: requiredAuth,
+			description: "2FA baru aktif setelah dikonfirmasi di /user/mfa/confirm.",
+			replies:     []reply{ok(services.MFAEnrollment{})}, errors: []apperror.Code{apperror.CodeUserNotFound, apperror.CodeMFAAlreadyEnabled}},
+		{method: "POST", path: "/api/v1/user/mfa/confirm", id: "confirmMFA", tag: "user", summary: "Aktifkan 2FA dengan kode dari aplikasi authenticator", auth: requiredAuth,
+			body: controllers.MFACodeInput{}, replies: []reply{ok(recoveryCodes)},
+			errors: []apperror.Code{apperror.CodeUserNotFound, apperror.CodeMFAAlreadyEnabled, apperror.CodeMFANotStarted, apperror.CodeMFACodeInvalid}},
+		{method: "POST", path: "/api/v1/user/mfa/disable", id: "disableMFA", tag: "user", summary: "Matikan 2FA", auth: requiredAuth,
+			body: controllers.MFACodeInput{}, replies: []reply{ok(message)},
+			errors: []apperror.Code{apperror.CodeUserNotFound, apperror.CodeMFANotEnabled, apperror.CodeMFACodeInvalid, apperror.CodeMFARequiredForRole}},
+		{method: "POST", path: "/api/v1/user/mfa/recovery-codes", id: "regenerateRecoveryCodes", tag: "user", summary: "Ganti semua recovery code", auth: requiredAuth,
+			body: controllers.MFACodeInput{}, replies: []reply{ok(recoveryCodes)},
+			errors: []apperror.Code{apperror.CodeUserNotFound, apperror.CodeMFANotEnabled, apperror.CodeMFACodeInvalid}},
+	}
+
+	for _, e := range endpoints {
+		spec.Add(e.method, e.path, e.operation(spec, problem))
+	}
+	return spec
+}
+
+// operation mengubah endpoint menjadi operasi OpenAPI
+func (e endpoint) operation(spec *openapi.Spec, problem *openapi.Schema) openapi.Operation {
+	op := openapi.Operation{
+		Tags:        []string{e.tag},
+		Summary:     e.summary,
+		Description: e.description,
+		OperationID: e.id,
+		Parameters:  e.params,
+		Responses:   make(map[string]*openapi.Response),
+	}
+
+	var codes []apperror.Code
+	switch e.auth {
+	case requiredAuth:
+		op.Security = []map[string][]string{{"bearerAuth": {}}}
+		codes = append(codes, apperror.CodeAuthRequired, apperror.CodeTokenInvalid, apperror.CodeTokenRevoked)
+	case optionalAuth:
+		// Objek kosong berarti boleh dipanggil tanpa token
+		op.Security = []map[string][]string{{"bearerAuth": {}}, {}}
+		codes = append(codes, apperror.CodeTokenInvalid, apperror.CodeTokenRevoked)
+	}
+	if e.permission != "" {
+		op.Permissions = []string{e.permission}
+		codes = append(codes, apperror.CodePermissionDenied)
+	}
+	if e.body != nil {
+		op.RequestBody = &openapi.RequestBody{
+			Required: !e.optionalBody,
+			Content:  map[string]openapi.MediaType{"application/json": {Schema: spec.Schema(e.body)}},
+		}
+		codes = append(codes, apperror.CodeInvalidRequestBody, apperror.CodeValidationFailed)
+	}
+	codes = append(codes, e.errors...)
+	codes = append(codes, apperror.CodeInternal)
+
+	for _, r := range e.replies {
+		contentType := r.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		description := r.description
+		if description == "" {
+			description = http.StatusText(r.status)
+		}
+		op.Responses[strconv.Itoa(r.status)] = &openapi.Response{
+			Description: description,
+			Content:     map[string]openapi.MediaType{contentType: {Schema: spec.Schema(r.body)}},
+		}
+	}
+
+	// Kode error dikelompokkan per status HTTP; skema code dibatasi ke kode
+	// yang memang bisa muncul di status tersebut
+	byStatus := make(map[int][]string)
+	var statuses []int
+	seen := make(map[apperror.Code]bool)
+	for _, code := range codes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		op.ErrorCodes = append(op.ErrorCodes, string(code))
+		status := apperror.New(code).Status()
+		if override, ok := e.statuses[code]; ok {
+			status = override
+		}
+		if _, ok := byStatus[status]; !ok {
+			statuses = append(statuses, status)
+		}
+		byStatus[status] = append(byStatus[status], string(code))
+	}
+	for _, status := range statuses {
+		group := byStatus[status]
+		titles := make([]string, len(group))
+		for i, code := range group {
+			titles[i] = code + ": " + apperror.New(apperror.Code(code)).Problem(apperror.LangEN).Title
+		}
+		op.Responses[strconv.Itoa(status)] = &openapi.Response{
+			Description: strings.Join(titles, "; "),
+			Content: map[string]openapi.MediaType{apperror.ContentType: {Schema: &openapi.Schema{AllOf: []*openapi.Schema{
+				problem,
+				object(nil, map[string]*openapi.Schema{"code": {Type: "string", Enum: group}}),
+			}}}},
+		}
+	}
+	return op
+}
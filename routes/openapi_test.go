@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"tokobiru/apperror"
+	"tokobiru/models"
+	"tokobiru/openapi"
+	"tokobiru/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	s := newTestServer(t)
+	document := apiSpec().Document()
+
+	registered := make(map[string]bool)
+	for _, route := range s.router.Routes() {
+		path := openapi.Path(route.Path)
+		registered[route.Method+" "+path] = true
+		op := document.Paths[path][strings.ToLower(route.Method)]
+		if op == nil {
+			t.Errorf("%s %s is not documented in apiSpec", route.Method, route.Path)
+			continue
+		}
+		if len(op.Responses) == 0 || op.OperationID == "" {
+			t.Errorf("%s %s has no operationId or responses", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range document.Paths {
+		for method := range item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// TestOpenAPISecurityMatchesMiddleware memanggil setiap operasi yang
+// didokumentasikan dan memastikan autentikasi serta permission di dokumen sama
+// dengan middleware yang benar-benar terpasang di rutenya
+func TestOpenAPISecurityMatchesMiddleware(t *testing.T) {
+	s := newTestServer(t)
+	document := apiSpec().Document()
+	ctx := context.Background()
+
+	// Role tanpa permission, dan satu role untuk setiap permission yang
+	// didokumentasikan. Dibuat sebelum request pertama agar cache permission
+	// router sudah memuatnya.
+	if err := s.store.Roles.Create(ctx, &models.Role{Name: "nobody"}); err != nil {
+		t.Fatal(err)
+	}
+	roleFor := make(map[string]string)
+	for _, item := range document.Paths {
+		for _, op := range item {
+			for _, permission := range op.Permissions {
+				if _, ok := roleFor[permission]; !ok {
+					roleFor[permission] = "only" + strconv.Itoa(len(roleFor))
+					if err := s.store.Roles.Create(ctx, &models.Role{Name: roleFor[permission], Permissions: []string{permission}}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+		}
+	}
+
+	pathParam := regexp.MustCompile(`\{[^}]+\}`)
+	call := func(method, path, authorization string) (int, apperror.Code) {
+		req := httptest.NewRequest(method, path, strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		var problem apperror.Problem
+		json.Unmarshal(rec.Body.Bytes(), &problem)
+		return rec.Code, problem.Code
+	}
+	// Token baru untuk setiap request, karena logout mencabut token yang dipakai
+	bearer := func(role string) string {
+		token, err := services.GenerateToken(s.keys, primitive.NewObjectID().Hex(), role, "", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+
+	for docPath, item := range document.Paths {
+		path := pathParam.ReplaceAllLiteralString(docPath, primitive.NewObjectID().Hex())
+		for method, op := range item {
+			method = strings.ToUpper(method)
+			name := method + " " + docPath
+			required := len(op.Security) == 1
+			authenticated := len(op.Security) > 0
+
+			if _, code := call(method, path, ""); (code == apperror.CodeAuthRequired) != required {
+				t.Errorf("%s: documented required auth %v, got %q without token", name, required, code)
+			}
+			if _, code := call(method, path, "Bearer not-a-token"); (code == apperror.CodeTokenInvalid) != authenticated {
+				t.Errorf("%s: documented auth %v, got %q with an invalid token", name, authenticated, code)
+			}
+			if !authenticated {
+				continue
+			}
+			if _, code := call(method, path, bearer("nobody")); (code == apperror.CodePermissionDenied) != (len(op.Permissions) > 0) {
+				t.Errorf("%s: documented permissions %v, got %q for a role without permissions", name, op.Permissions, code)
+			}
+			if len(op.Permissions) == 1 {
+				if _, code := call(method, path, bearer(roleFor[op.Permissions[0]])); code == apperror.CodePermissionDenied {
+					t.Errorf("%s: role with %s was denied, the route checks a different permission", name, op.Permissions[0])
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAPIDocumentAndDocsAreServed(t *testing.T) {
+	s := newTestServer(t)
+
+	var document openapi.Document
+	if code := s.do(http.MethodGet, openAPIPath, "", nil, &document); code != http.StatusOK {
+		t.Fatalf("openapi.json: got %d", code)
+	}
+	if document.OpenAPI != openapi.Version {
+		t.Fatalf("unexpected openapi version %q", document.OpenAPI)
+	}
+
+	// Skema request mengikuti tag binding handler
+	login := document.Components.Schemas["LoginInput"]
+	if login == nil || !slices.Equal(login.Required, []string{"email"}) {
+		t.Fatalf("unexpected LoginInput schema %+v", login)
+	}
+	quantity := document.Components.Schemas["AddCartItemInput"].Properties["quantity"]
+	if quantity.Minimum == nil || *quantity.Minimum != 0 || !quantity.ExclusiveMinimum {
+		t.Fatalf("quantity should be > 0, got %+v", quantity)
+	}
+
+	// Autentikasi, permission dan kode error per endpoint
+	checkout := document.Paths["/api/v1/orders/checkout"]["post"]
+	if len(checkout.Security) != 1 || checkout.Security[0]["bearerAuth"] == nil {
+		t.Fatalf("checkout should require a bearer token, got %+v", checkout.Security)
+	}
+	if !slices.Equal(checkout.Permissions, []string{"orders:own"}) {
+		t.Fatalf("unexpected checkout permissions %v", checkout.Permissions)
+	}
+	for _, code := range []string{"AUTH_REQUIRED", "PERMISSION_DENIED", "INSUFFICIENT_STOCK", "INTERNAL_ERROR"} {
+		if !slices.Contains(checkout.ErrorCodes, code) {
+			t.Errorf("checkout should document %s, got %v", code, checkout.ErrorCodes)
+		}
+	}
+	codesFor := func(op *openapi.Operation, status string) []string {
+		return op.Responses[status].Content["application/problem+json"].Schema.AllOf[1].Properties["code"].Enum
+	}
+	if codes := codesFor(checkout, "400"); !slices.Equal(codes, []string{"CART_EMPTY", "INSUFFICIENT_STOCK"}) {
+		t.Fatalf("unexpected checkout 400 codes %v", codes)
+	}
+	// Rute keranjang mempertahankan status 404 untuk stok yang tidak cukup
+	if codes := codesFor(document.Paths["/api/v1/cart"]["post"], "404"); !slices.Equal(codes, []string{"PRODUCT_NOT_FOUND", "INSUFFICIENT_STOCK"}) {
+		t.Fatalf("unexpected cart 404 codes %v", codes)
+	}
+	if products := document.Paths["/api/v1/products"]["get"]; products.Security != nil {
+		t.Fatalf("listing products should be public, got %+v", products.Security)
+	}
+	if chat := document.Paths["/api/v1/chatbot/ask"]["post"]; len(chat.Security) != 2 {
+		t.Fatalf("chatbot should accept optional auth, got %+v", chat.Security)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, docsPath, nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("docs: got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), openAPIPath) {
+		t.Fatalf("docs page should load %s", openAPIPath)
+	}
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type checkoutResponse struct {
+	Message string       `json:"message"`
+	Order   models.Order `json:"order"`
+	Code    string       `json:"code"`
+}
+
+func TestCheckoutCreatesOrderAndClearsCart(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	_, otherToken := s.createUser("other@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+	topi := s.createProduct("Topi", 60000, 5)
+
+	if code := s.do(http.MethodPost, "/api/v1/orders/checkout", token, nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("checkout empty cart: got status %d, want 400", code)
+	}
+
+	s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, nil)
+	s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": topi.ID.Hex(), "quantity": 1}, nil)
+
+	var resp checkoutResponse
+	if code := s.do(http.MethodPost, "/api/v1/orders/checkout", token, nil, &resp); code != http.StatusCreated {
+		t.Fatalf("checkout: got status %d, body %+v", code, resp)
+	}
+	if resp.Order.Total != models.IDR(2*85000+60000) || resp.Order.Status != models.OrderStatusNew {
+		t.Fatalf("checkout: unexpected order %+v", resp.Order)
+	}
+
+	ctx := context.Background()
+	if p, _ := s.store.Products.FindByID(ctx, kaos.ID); p.Stock != 3 {
+		t.Fatalf("kaos stock: got %d, want 3", p.Stock)
+	}
+	if p, _ := s.store.Products.FindByID(ctx, topi.ID); p.Stock != 4 {
+		t.Fatalf("topi stock: got %d, want 4", p.Stock)
+	}
+
+	var cart models.Cart
+	s.do(http.MethodGet, "/api/v1/cart", token, nil, &cart)
+	if len(cart.Items) != 0 {
+		t.Fatalf("cart not cleared: %+v", cart.Items)
+	}
+
+	var orders []models.Order
+	if code := s.do(http.MethodGet, "/api/v1/orders", token, nil, &orders); code != http.StatusOK || len(orders) != 1 {
+		t.Fatalf("list orders: got status %d, %d orders", code, len(orders))
+	}
+	if code := s.do(http.MethodGet, "/api/v1/orders/"+resp.Order.ID.Hex(), token, nil, nil); code != http.StatusOK {
+		t.Fatalf("get order: got status %d", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/orders/"+resp.Order.ID.Hex(), otherToken, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("get other user's order: got status %d, want 404", code)
+	}
+	s.do(http.MethodGet, "/api/v1/orders", otherToken, nil, &orders)
+	if orders == nil || len(orders) != 0 {
+		t.Fatalf("other user's orders: expected empty array, got %v", orders)
+	}
+}
+
+func TestCheckoutRollsBackOnInsufficientStock(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.createUser("customer@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+	topi := s.createProduct("Topi", 60000, 5)
+
+	s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": kaos.ID.Hex(), "quantity": 2}, nil)
+	s.do(http.MethodPost, "/api/v1/cart", token, gin.H{"productId": topi.ID.Hex(), "quantity": 3}, nil)
+
+	// Stok topi habis setelah item masuk ke keranjang
+	ctx := context.Background()
+	if err := s.store.Products.DecrementStock(ctx, topi.ID, 4); err != nil {
+		t.Fatalf("decrement stock: %v", err)
+	}
+
+	var resp checkoutResponse
+	if code := s.do(http.MethodPost, "/api/v1/orders/checkout", token, nil, &resp); code != http.StatusBadRequest || resp.Code != "INSUFFICIENT_STOCK" {
+		t.Fatalf("checkout: got status %d, code %q, want 400 INSUFFICIENT_STOCK", code, resp.Code)
+	}
+
+	if p, _ := s.store.Products.FindByID(ctx, kaos.ID); p.Stock != 5 {
+		t.Fatalf("kaos stock not restored: got %d, want 5", p.Stock)
+	}
+	if orders, _ := s.store.Orders.FindAll(ctx); len(orders) != 0 {
+		t.Fatalf("order should not be created: %+v", orders)
+	}
+	var cart models.Cart
+	s.do(http.MethodGet, "/api/v1/cart", token, nil, &cart)
+	if len(cart.Items) != 2 {
+		t.Fatalf("cart should be untouched: %+v", cart.Items)
+	}
+}
@@ -0,0 +1,216 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"tokobiru/apperror"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProductCRUD(t *testing.T) {
+	s := newTestServer(t)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+	_, customerToken := s.createUser("customer@example.com", "secret123", "customer")
+
+	payload := gin.H{
+		"name": "Kaos Biru", "description": "Kaos katun", "price": 85000,
+		"stock": 10, "category": "Pakaian",
+	}
+	if code := s.do(http.MethodPost, "/api/v1/products", customerToken, payload, nil); code != http.StatusForbidden {
+		t.Fatalf("create as customer: got status %d, want 403", code)
+	}
+
+	var created models.Product
+	if code := s.do(http.MethodPost, "/api/v1/products", adminToken, payload, &created); code != http.StatusCreated {
+		t.Fatalf("create: got status %d", code)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/products", adminToken, gin.H{"name": "Tanpa harga"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("create invalid: got status %d, want 400", code)
+	}
+	if created.Price != models.IDR(85000) {
+		t.Fatalf("create: legacy price should be read as rupiah, got %+v", created.Price)
+	}
+
+	var fetched models.Product
+	if code := s.do(http.MethodGet, "/api/v1/products/"+created.ID.Hex(), "", nil, &fetched); code != http.StatusOK {
+		t.Fatalf("get: got status %d", code)
+	}
+	if fetched.Name != "Kaos Biru" {
+		t.Fatalf("get: unexpected product %+v", fetched)
+	}
+
+	payload["price"] = gin.H{"amount": 0, "currency": "IDR"}
+	if code := s.do(http.MethodPut, "/api/v1/products/"+created.ID.Hex(), adminToken, payload, nil); code != http.StatusBadRequest {
+		t.Fatalf("update with zero price: got status %d, want 400", code)
+	}
+	payload["price"] = gin.H{"amount": 9000000, "currency": "IDR"}
+	if code := s.do(http.MethodPut, "/api/v1/products/"+created.ID.Hex(), adminToken, payload, nil); code != http.StatusOK {
+		t.Fatalf("update: got status %d", code)
+	}
+	s.do(http.MethodGet, "/api/v1/products/"+created.ID.Hex(), "", nil, &fetched)
+	if fetched.Price != models.IDR(90000) {
+		t.Fatalf("update: price not changed, got %v", fetched.Price)
+	}
+
+	if code := s.do(http.MethodDelete, "/api/v1/products/"+created.ID.Hex(), adminToken, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete: got status %d", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/products/"+created.ID.Hex(), "", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("get deleted: got status %d, want 404", code)
+	}
+	if code := s.do(http.MethodDelete, "/api/v1/products/"+created.ID.Hex(), adminToken, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("delete missing: got status %d, want 404", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/products/bukan-id", "", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("get invalid id: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/products/"+primitive.NewObjectID().Hex(), adminToken, payload, nil); code != http.StatusNotFound {
+		t.Fatalf("update missing: got status %d, want 404", code)
+	}
+}
+
+func TestGetProductsFilterAndPagination(t *testing.T) {
+	s := newTestServer(t)
+	s.createProduct("Kaos Polos Biru", 85000, 10)
+	s.createProduct("Kemeja Flanel", 175000, 10)
+	s.createProduct("Topi Baseball Biru", 60000, 10)
+
+	var resp struct {
+		Data []models.Product `json:"data"`
+		Meta struct {
+			Total      int64 `json:"total"`
+			TotalPages int64 `json:"total_pages"`
+		} `json:"meta"`
+	}
+	if code := s.do(http.MethodGet, "/api/v1/products?name=biru", "", nil, &resp); code != http.StatusOK {
+		t.Fatalf("list: got status %d", code)
+	}
+	if resp.Meta.Total != 2 || len(resp.Data) != 2 {
+		t.Fatalf("name filter: got total %d and %d items, want 2", resp.Meta.Total, len(resp.Data))
+	}
+	// Nama dicocokkan sebagai substring, termasuk di tengah kata
+	s.do(http.MethodGet, "/api/v1/products?name=meja", "", nil, &resp)
+	if resp.Meta.Total != 1 || resp.Data[0].Name != "Kemeja Flanel" {
+		t.Fatalf("substring filter: got %+v", resp.Data)
+	}
+	s.do(http.MethodGet, "/api/v1/products?name=baseball+biru", "", nil, &resp)
+	if resp.Meta.Total != 1 || resp.Data[0].Name != "Topi Baseball Biru" {
+		t.Fatalf("multi-word substring filter: got %+v", resp.Data)
+	}
+	s.do(http.MethodGet, "/api/v1/products?name=biru+topi", "", nil, &resp)
+	if resp.Meta.Total != 0 {
+		t.Fatalf("words in another order should not match, got %+v", resp.Data)
+	}
+	// Karakter regex dicocokkan apa adanya
+	if code := s.do(http.MethodGet, "/api/v1/products?name=kaos+(", "", nil, &resp); code != http.StatusOK || resp.Meta.Total != 0 {
+		t.Fatalf("regex characters: got status %d, %+v", code, resp.Data)
+	}
+
+	s.do(http.MethodGet, "/api/v1/products?page=2&limit=2", "", nil, &resp)
+	if resp.Meta.Total != 3 || resp.Meta.TotalPages != 2 || len(resp.Data) != 1 {
+		t.Fatalf("pagination: got %+v with %d items", resp.Meta, len(resp.Data))
+	}
+}
+
+// Sebelum lapisan repository, page=0 menghasilkan skip negatif (ditolak Mongo)
+// dan limit=0 membuat pembagian total_pages dengan nol.
+func TestGetProductsClampsPagination(t *testing.T) {
+	s := newTestServer(t)
+	s.createProduct("Kaos", 85000, 10)
+	s.createProduct("Topi", 60000, 10)
+
+	for _, query := range []string{"page=0&limit=0", "page=-3&limit=-5", "page=abc&limit=xyz"} {
+		var resp struct {
+			Data []models.Product `json:"data"`
+			Meta struct {
+				Total      int64 `json:"total"`
+				Page       int64 `json:"page"`
+				Limit      int64 `json:"limit"`
+				TotalPages int64 `json:"total_pages"`
+			} `json:"meta"`
+		}
+		if code := s.do(http.MethodGet, "/api/v1/products?"+query, "", nil, &resp); code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want 200", query, code)
+		}
+		if resp.Meta.Page != 1 || resp.Meta.Limit != 10 || resp.Meta.TotalPages != 1 || len(resp.Data) != 2 {
+			t.Fatalf("%s: want page 1 and limit 10, got %+v with %d items", query, resp.Meta, len(resp.Data))
+		}
+	}
+}
+
+// Daftar produk diurutkan berdasarkan _id (urutan pembuatan) supaya halaman
+// berikutnya tidak mengulang atau melewatkan produk.
+func TestGetProductsStableOrder(t *testing.T) {
+	s := newTestServer(t)
+	var want []string
+	for _, name := range []string{"Kaos", "Topi", "Kemeja", "Jaket", "Celana"} {
+		want = append(want, s.createProduct(name, 85000, 10).ID.Hex())
+	}
+
+	var got []string
+	for page := 1; page <= len(want); page++ {
+		var resp struct {
+			Data []models.Product `json:"data"`
+		}
+		s.do(http.MethodGet, fmt.Sprintf("/api/v1/products?page=%d&limit=1", page), "", nil, &resp)
+		if len(resp.Data) != 1 {
+			t.Fatalf("page %d: got %d items, want 1", page, len(resp.Data))
+		}
+		got = append(got, resp.Data[0].ID.Hex())
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("pages should follow creation order: got %v, want %v", got, want)
+	}
+}
+
+// Hasil kosong dikirim sebagai [] dan bukan null, supaya klien bisa langsung
+// melakukan iterasi tanpa pengecekan tambahan.
+func TestGetProductsEmptyResultIsArray(t *testing.T) {
+	s := newTestServer(t)
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if code := s.do(http.MethodGet, "/api/v1/products?category=Elektronik", "", nil, &resp); code != http.StatusOK {
+		t.Fatalf("list: got status %d", code)
+	}
+	if string(resp.Data) != "[]" {
+		t.Fatalf("empty result: got data %s, want []", resp.Data)
+	}
+}
+
+// Total pesanan dan laporan penjualan tidak mengonversi kurs, jadi harga
+// dalam mata uang selain IDR ditolak
+func TestProductRejectsForeignCurrency(t *testing.T) {
+	s := newTestServer(t)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+	kaos := s.createProduct("Kaos", 85000, 10)
+
+	payload := gin.H{
+		"name": "Kaos Impor", "description": "Kaos katun", "price": gin.H{"amount": 1250, "currency": "USD"},
+		"stock": 10, "category": "Pakaian",
+	}
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/products"},
+		{http.MethodPut, "/api/v1/products/" + kaos.ID.Hex()},
+	} {
+		var problem apperror.Problem
+		if code := s.do(req.method, req.path, adminToken, payload, &problem); code != http.StatusBadRequest {
+			t.Fatalf("%s %s: got status %d, want 400", req.method, req.path, code)
+		}
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != "price" || problem.Errors[0].Rule != "currency" {
+			t.Fatalf("%s %s: unexpected errors %+v", req.method, req.path, problem.Errors)
+		}
+	}
+
+	payload["price"] = gin.H{"amount": 8500000, "currency": "IDR"}
+	if code := s.do(http.MethodPost, "/api/v1/products", adminToken, payload, nil); code != http.StatusCreated {
+		t.Fatalf("create in IDR: got status %d", code)
+	}
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"tokobiru/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWarehouseCanUpdateOrdersButNotProducts(t *testing.T) {
+	s := newTestServer(t)
+	_, warehouseToken := s.createUser("gudang@example.com", "secret123", "warehouse")
+	_, customerToken := s.createUser("customer@example.com", "secret123", "customer")
+	kaos := s.createProduct("Kaos", 85000, 5)
+
+	s.do(http.MethodPost, "/api/v1/cart", customerToken, gin.H{"productId": kaos.ID.Hex(), "quantity": 1}, nil)
+	var checkout checkoutResponse
+	s.do(http.MethodPost, "/api/v1/orders/checkout", customerToken, nil, &checkout)
+
+	if code := s.do(http.MethodGet, "/api/v1/admin/orders", warehouseToken, nil, nil); code != http.StatusOK {
+		t.Fatalf("warehouse list orders: got status %d", code)
+	}
+	path := "/api/v1/admin/orders/" + checkout.Order.ID.Hex()
+	if code := s.do(http.MethodPatch, path, warehouseToken, gin.H{"status": models.OrderStatusShipped}, nil); code != http.StatusOK {
+		t.Fatalf("warehouse update status: got status %d", code)
+	}
+
+	if code := s.do(http.MethodPut, "/api/v1/products/"+kaos.ID.Hex(), warehouseToken, gin.H{"price": 1}, nil); code != http.StatusForbidden {
+		t.Fatalf("warehouse update product: got status %d, want 403", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/admin/sales-report", warehouseToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("warehouse sales report: got status %d, want 403", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", warehouseToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("warehouse cart: got status %d, want 403", code)
+	}
+}
+
+func TestManageRoles(t *testing.T) {
+	s := newTestServer(t)
+	_, adminToken := s.createUser("admin@example.com", "secret123", "admin")
+	customer, customerToken := s.createUser("customer@example.com", "secret123", "customer")
+
+	if code := s.do(http.MethodGet, "/api/v1/admin/roles", customerToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("list roles as customer: got status %d, want 403", code)
+	}
+
+	var roles []models.Role
+	if code := s.do(http.MethodGet, "/api/v1/admin/roles", adminToken, nil, &roles); code != http.StatusOK || len(roles) != 4 {
+		t.Fatalf("list roles: got status %d, %d roles", code, len(roles))
+	}
+
+	if code := s.do(http.MethodPost, "/api/v1/admin/roles", adminToken, gin.H{
+		"name": "merchandiser", "permissions": []string{"products:fly"},
+	}, nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown permission: got status %d, want 400", code)
+	}
+	var role models.Role
+	if code := s.do(http.MethodPost, "/api/v1/admin/roles", adminToken, gin.H{
+		"name": "merchandiser", "description": "Kelola katalog", "permissions": []string{models.PermProductsWrite},
+	}, &role); code != http.StatusCreated || role.Name != "merchandiser" {
+		t.Fatalf("create role: got status %d, %+v", code, role)
+	}
+	if code := s.do(http.MethodPost, "/api/v1/admin/roles", adminToken, gin.H{
+		"name": "merchandiser", "permissions": []string{},
+	}, nil); code != http.StatusConflict {
+		t.Fatalf("duplicate role: got status %d, want 409", code)
+	}
+
+	// Role baru berlaku pada token berikutnya
+	path := "/api/v1/admin/users/" + customer.ID.Hex() + "/role"
+	if code := s.do(http.MethodPut, path, adminToken, gin.H{"role": "merchandiser"}, nil); code != http.StatusOK {
+		t.Fatalf("assign role: got status %d", code)
+	}
+	tokens := s.login(customer.Email, "secret123")
+	kaos := s.createProduct("Kaos", 85000, 5)
+	if code := s.do(http.MethodPut, "/api/v1/products/"+kaos.ID.Hex(), tokens.Token, gin.H{
+		"name": "Kaos", "description": "Kaos katun", "price": 90000, "stock": 5, "category": "Pakaian",
+	}, nil); code != http.StatusOK {
+		t.Fatalf("merchandiser update product: got status %d", code)
+	}
+
+	if code := s.do(http.MethodDelete, "/api/v1/admin/roles/merchandiser", adminToken, nil, nil); code != http.StatusConflict {
+		t.Fatalf("delete role in use: got status %d, want 409", code)
+	}
+	if code := s.do(http.MethodDelete, "/api/v1/admin/roles/customer", adminToken, nil, nil); code != http.StatusConflict {
+		t.Fatalf("delete built-in role: got status %d, want 409", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/admin/roles/admin", adminToken, gin.H{"permissions": []string{}}, nil); code != http.StatusConflict {
+		t.Fatalf("update admin role: got status %d, want 409", code)
+	}
+
+	s.do(http.MethodPut, path, adminToken, gin.H{"role": "customer"}, nil)
+	if code := s.do(http.MethodDelete, "/api/v1/admin/roles/merchandiser", adminToken, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete role: got status %d", code)
+	}
+	if _, err := s.store.Roles.FindByName(context.Background(), "merchandiser"); err == nil {
+		t.Fatal("role still stored after delete")
+	}
+}
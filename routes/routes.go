@@ -2,40 +2,94 @@ package routes
 
 import (
 	"time"
+	"tokobiru/apperror"
+	"tokobiru/config"
 	"tokobiru/controllers"
+	"tokobiru/metrics"
 	"tokobiru/middlewares"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+	"tokobiru/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func SetupRoutes(router *gin.Engine, db *mongo.Client) {
+func SetupRoutes(router *gin.Engine, store *repositories.Store, cfg config.Config, keys *services.KeyRing, mailer services.Mailer) error {
 	// TERAPKAN MIDDLEWARE CORS DI SINI
-	// Ini harus menjadi salah satu middleware pertama yang diterapkan.
+	// Ini harus menjadi middleware pertama yang diterapkan, supaya preflight
+	// OPTIONS dan semua respons, termasuk error dan panic, membawa header CORS.
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Mengizinkan semua origin (untuk pengembangan)
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", controllers.SessionHeader, middlewares.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middlewares.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Span tracing dibuat setelah CORS agar log dan handler berada di dalamnya,
+	// lalu request ID agar semua log, termasuk panic, membawa ID yang sama.
+	// ErrorHandler berada di dalam logger dan metrik agar status error yang
+	// dicatat sudah final, dan di luar Recovery agar panic juga dibalas problem+json.
+	router.Use(otelgin.Middleware(tracing.ServiceName), middlewares.RequestID(), middlewares.RequestLogger(), middlewares.Metrics(), middlewares.ErrorHandler(), middlewares.Recovery())
+	router.NoRoute(func(c *gin.Context) {
+		apperror.Abort(c, apperror.New(apperror.CodeRouteNotFound))
+	})
+
+	// Middleware autentikasi memeriksa daftar pencabutan token
+	authRequired := middlewares.AuthMiddleware(keys, store.Revocations)
+	authOptional := middlewares.OptionalAuthMiddleware(keys, store.Revocations)
+
+	// Hak akses dicek per permission, bukan per nama role
+	authz := services.NewAuthorizationService(store.Roles, store.Users)
+	can := func(permissions ...string) gin.HandlerFunc {
+		return middlewares.RequirePermission(authz, permissions...)
+	}
+
 	// Inisialisasi semua controller
-	authController := controllers.NewAuthController(db)
-	productController := controllers.NewProductController(db)
-	cartController := controllers.NewCartController(db)
-	orderController := controllers.NewOrderController(db)
-	adminController := controllers.NewAdminController(db)
-	userController := controllers.NewUserController(db)
-	chatController := controllers.NewChatController(db)
+	authController := controllers.NewAuthController(cfg, store.Users, store.Sessions, store.Revocations, store.UserTokens, store.LoginAttempts, store.Audit, keys, mailer)
+	invitationController := controllers.NewInvitationController(cfg, store.Users, store.Invitations)
+	productController := controllers.NewProductController(store.Products)
+	cartController := controllers.NewCartController(store.Carts, store.Products)
+	orderController := controllers.NewOrderController(store.Orders, store.Carts, store.Products, store.Tx)
+	adminController := controllers.NewAdminController(store.Users, store.Orders, store.Conversations)
+	userController := controllers.NewUserController(store.Users)
+	mfaController := controllers.NewMFAController(cfg, store.Users)
+	roleController := controllers.NewRoleController(authz)
+	securityController := controllers.NewSecurityController(cfg, store.Users, store.LoginAttempts, store.Audit)
+	chatController := controllers.NewChatController(cfg, store.Products, store.Orders, store.Carts, store.Conversations)
+	jwksController := controllers.NewJWKSController(keys)
+	healthController := controllers.NewHealthController(store.Health, chatController.LLMStatus)
+	docsController, err := controllers.NewDocsController(apiSpec(), openAPIPath)
+	if err != nil {
+		return err
+	}
+
+	// Liveness, readiness dan metrik Prometheus untuk Docker/orchestrator, tanpa autentikasi
+	router.GET("/healthz", healthController.Liveness)
+	router.GET("/readyz", healthController.Readiness)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Kunci publik untuk memverifikasi access token (RFC 7517)
+	router.GET("/.well-known/jwks.json", jwksController.GetJWKS)
+
+	// Spesifikasi OpenAPI dan Swagger UI, lihat apiSpec di openapi.go
+	router.GET(openAPIPath, docsController.GetSpec)
+	router.GET(docsPath, docsController.GetUI)
+
 	api := router.Group("/api/v1")
 	{
 		// RUTE BARU UNTUK CHATBOT
-		chatbot := api.Group("/chatbot")
+		chatbot := api.Group("/chatbot", authOptional)
 		{
 			chatbot.POST("/ask", chatController.HandleChat)
+			chatbot.POST("/stream", chatController.HandleChatStream)
+			chatbot.GET("/conversations", chatController.GetConversations)
+			chatbot.GET("/conversations/:id", chatController.GetConversation)
+			chatbot.DELETE("/conversations/:id", chatController.DeleteConversation)
 		}
 
 		// Rute untuk autentikasi (Register & Login)
@@ -43,6 +97,15 @@ func SetupRoutes(router *gin.Engine, db *mongo.Client) {
 		{
 			auth.POST("/register", authController.Register)
 			auth.POST("/login", authController.Login)
+			auth.POST("/refresh", authController.Refresh)
+			auth.POST("/logout", authOptional, authController.Logout)
+			auth.POST("/accept-invitation", invitationController.AcceptInvitation)
+			auth.POST("/forgot-password", authController.ForgotPassword)
+			auth.POST("/reset-password", authController.ResetPassword)
+			auth.POST("/verify-email", authController.VerifyEmail)
+			auth.POST("/resend-verification", authController.ResendVerification)
+			auth.POST("/mfa/enroll", authController.EnrollMFA)
+			auth.POST("/mfa/verify", authController.VerifyMFA)
 		}
 
 		// Rute untuk produk
@@ -50,13 +113,13 @@ func SetupRoutes(router *gin.Engine, db *mongo.Client) {
 		{
 			products.GET("", productController.GetProducts)
 			products.GET("/:id", productController.GetProductByID)
-			products.POST("", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("admin"), productController.CreateProduct)
-			products.PUT("/:id", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("admin"), productController.UpdateProduct)
-			products.DELETE("/:id", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("admin"), productController.DeleteProduct)
+			products.POST("", authRequired, can(models.PermProductsWrite), productController.CreateProduct)
+			products.PUT("/:id", authRequired, can(models.PermProductsWrite), productController.UpdateProduct)
+			products.DELETE("/:id", authRequired, can(models.PermProductsWrite), productController.DeleteProduct)
 		}
 
-		// Rute untuk keranjang belanja (hanya untuk customer)
-		cart := api.Group("/cart", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("customer"))
+		// Rute untuk keranjang belanja
+		cart := api.Group("/cart", authRequired, can(models.PermCartManage))
 		{
 			cart.GET("", cartController.GetCart)
 			cart.POST("", cartController.AddItemToCart)
@@ -64,26 +127,44 @@ func SetupRoutes(router *gin.Engine, db *mongo.Client) {
 			cart.DELETE("/:productId", cartController.RemoveItemFromCart)
 		}
 
-		// Rute untuk pemesanan/order (hanya untuk customer)
-		orders := api.Group("/orders", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("customer"))
+		// Rute untuk pemesanan/order milik user sendiri
+		orders := api.Group("/orders", authRequired, can(models.PermOrdersOwn))
 		{
 			orders.POST("/checkout", orderController.Checkout)
 			orders.GET("", orderController.GetUserOrders)
 			orders.GET("/:id", orderController.GetOrderByID)
 		}
 
-		// Rute khusus untuk dashboard admin
-		admin := api.Group("/admin", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("admin"))
+		// Rute dashboard admin, setiap rute membutuhkan permission masing-masing
+		admin := api.Group("/admin", authRequired)
 		{
-			admin.GET("/users", adminController.GetAllUsers)
-			admin.GET("/orders", adminController.GetAllOrders)
-			admin.PATCH("/orders/:id", adminController.UpdateOrderStatus)
+			admin.GET("/users", can(models.PermUsersRead), adminController.GetAllUsers)
+			admin.PUT("/users/:id/role", can(models.PermRolesManage), roleController.AssignUserRole)
+			admin.POST("/users/:id/unlock", can(models.PermUsersUnlock), securityController.UnlockUser)
+			admin.GET("/audit-logs", can(models.PermAuditRead), securityController.GetAuditLogs)
+			admin.GET("/orders", can(models.PermOrdersRead), adminController.GetAllOrders)
+			admin.PATCH("/orders/:id", can(models.PermOrdersUpdateStatus), adminController.UpdateOrderStatus)
+			admin.GET("/sales-report", can(models.PermReportsRead), adminController.GetSalesReport)
+			admin.GET("/conversations", can(models.PermConversationsRead), adminController.GetConversations)
+			admin.GET("/conversations/:id", can(models.PermConversationsRead), adminController.GetConversationByID)
+			admin.POST("/invitations", can(models.PermInvitationsCreate), invitationController.CreateInvitation)
+			admin.GET("/permissions", can(models.PermRolesManage), roleController.GetPermissions)
+			admin.GET("/roles", can(models.PermRolesManage), roleController.GetRoles)
+			admin.POST("/roles", can(models.PermRolesManage), roleController.CreateRole)
+			admin.PUT("/roles/:name", can(models.PermRolesManage), roleController.UpdateRole)
+			admin.DELETE("/roles/:name", can(models.PermRolesManage), roleController.DeleteRole)
 		}
 
 		// Rute untuk manajemen user (profil sendiri)
-		user := api.Group("/user", middlewares.AuthMiddleware())
+		user := api.Group("/user", authRequired)
 		{
 			user.PUT("/profile", userController.UpdateUserProfile)
+			user.GET("/mfa", mfaController.GetStatus)
+			user.POST("/mfa/enroll", mfaController.Enroll)
+			user.POST("/mfa/confirm", mfaController.Confirm)
+			user.POST("/mfa/disable", mfaController.Disable)
+			user.POST("/mfa/recovery-codes", mfaController.RegenerateRecoveryCodes)
 		}
 	}
+	return nil
 }
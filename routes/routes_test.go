@@ -0,0 +1,249 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"tokobiru/config"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/services"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testServer wires the real routes to an in-memory store
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	store  *repositories.Store
+	keys   *services.KeyRing
+	cfg    config.Config
+	mailer services.Mailer
+	// mailDir menampung email yang "dikirim" oleh LogMailer
+	mailDir string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("LLM_PROVIDER", "rule")
+	mailDir := t.TempDir()
+	t.Setenv("MAIL_DRIVER", "log")
+	t.Setenv("MAIL_DIR", mailDir)
+	t.Setenv("LOGIN_DELAY_BASE", "1ms")
+
+	store := repositories.NewMemoryStore()
+	if err := services.NewAuthorizationService(store.Roles, store.Users).EnsureDefaultRoles(context.Background()); err != nil {
+		t.Fatalf("seed roles: %v", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	keys, err := services.NewKeyRing(services.KeyRingOptions{
+		Dir:          t.TempDir(),
+		Algorithm:    services.JWTAlgorithmEdDSA, // Lebih cepat dibuat daripada RSA
+		LegacySecret: "test-secret",
+	})
+	if err != nil {
+		t.Fatalf("create key ring: %v", err)
+	}
+	mailer, err := services.NewMailer(cfg)
+	if err != nil {
+		t.Fatalf("create mailer: %v", err)
+	}
+	router := gin.New()
+	if err := SetupRoutes(router, store, cfg, keys, mailer); err != nil {
+		t.Fatalf("setup routes: %v", err)
+	}
+	return &testServer{t: t, router: router, store: store, keys: keys, cfg: cfg, mailer: mailer, mailDir: mailDir}
+}
+
+// do sends a JSON request and decodes the JSON response into out (if not nil)
+func (s *testServer) do(method, path, token string, body interface{}, out interface{}) int {
+	s.t.Helper()
+	headers := map[string]string{}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return s.doWithHeaders(method, path, headers, body, out)
+}
+
+// doWithHeaders is like do but sends arbitrary request headers
+func (s *testServer) doWithHeaders(method, path string, headers map[string]string, body interface{}, out interface{}) int {
+	s.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode response %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// createUser stores a user with a cheap bcrypt hash and returns it with a valid token
+func (s *testServer) createUser(email, password, role string) (*models.User, string) {
+	s.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		s.t.Fatalf("hash password: %v", err)
+	}
+	user := &models.User{
+		ID:        primitive.NewObjectID(),
+		Name:      "Test " + role,
+		Email:     email,
+		Password:  string(hash),
+		Role:      role,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.store.Users.Create(context.Background(), user); err != nil {
+		s.t.Fatalf("create user: %v", err)
+	}
+	token, err := services.GenerateToken(s.keys, user.ID.Hex(), role, "", time.Hour)
+	if err != nil {
+		s.t.Fatalf("generate token: %v", err)
+	}
+	return user, token
+}
+
+func (s *testServer) createProduct(name string, rupiah int64, stock int) *models.Product {
+	s.t.Helper()
+	product := &models.Product{
+		ID:          primitive.NewObjectID(),
+		Name:        name,
+		Description: name + " description",
+		Price:       models.IDR(rupiah),
+		Stock:       stock,
+		Category:    "Pakaian",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.store.Products.Create(context.Background(), product); err != nil {
+		s.t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestServer(t)
+
+	var user models.User
+	code := s.do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"name": "Budi", "email": "budi@example.com", "password": "rahasia123",
+	}, &user)
+	if code != http.StatusCreated {
+		t.Fatalf("register: got status %d", code)
+	}
+	if user.Role != "customer" || user.Password != "" {
+		t.Fatalf("register: unexpected user %+v", user)
+	}
+
+	code = s.do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"name": "Budi", "email": "budi@example.com", "password": "rahasia123",
+	}, nil)
+	if code != http.StatusConflict {
+		t.Fatalf("duplicate register: got status %d, want 409", code)
+	}
+
+	var login struct {
+		Token string `json:"token"`
+		Role  string `json:"role"`
+	}
+	code = s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"email": "budi@example.com", "password": "rahasia123",
+	}, &login)
+	if code != http.StatusOK || login.Token == "" || login.Role != "customer" {
+		t.Fatalf("login: got status %d, body %+v", code, login)
+	}
+
+	code = s.do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"email": "budi@example.com", "password": "salah",
+	}, nil)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password: got status %d, want 401", code)
+	}
+}
+
+func TestProtectedRoutesRequireAuth(t *testing.T) {
+	s := newTestServer(t)
+	_, customerToken := s.createUser("customer@example.com", "secret123", "customer")
+
+	if code := s.do(http.MethodGet, "/api/v1/cart", "", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("cart without token: got status %d, want 401", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/cart", "not-a-jwt", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("cart with invalid token: got status %d, want 401", code)
+	}
+	if code := s.do(http.MethodGet, "/api/v1/admin/users", customerToken, nil, nil); code != http.StatusForbidden {
+		t.Fatalf("admin route as customer: got status %d, want 403", code)
+	}
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	s := newTestServer(t)
+	user, token := s.createUser("customer@example.com", "secret123", "customer")
+
+	if code := s.do(http.MethodPut, "/api/v1/user/profile", token, gin.H{}, nil); code != http.StatusBadRequest {
+		t.Fatalf("empty update: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/user/profile", token, gin.H{"password": "123"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("short password: got status %d, want 400", code)
+	}
+	if code := s.do(http.MethodPut, "/api/v1/user/profile", token, gin.H{"name": "Nama Baru"}, nil); code != http.StatusOK {
+		t.Fatalf("update name: got status %d", code)
+	}
+
+	updated, err := s.store.Users.FindByEmail(context.Background(), user.Email)
+	if err != nil || updated.Name != "Nama Baru" {
+		t.Fatalf("name not updated: %+v, %v", updated, err)
+	}
+}
+
+func TestCORSAppliesBeforeOtherMiddleware(t *testing.T) {
+	s := newTestServer(t)
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/api/v1/cart", nil)
+	preflight.Header.Set("Origin", "http://localhost:3000")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Fatalf("preflight: got status %d, headers %v", rec.Code, rec.Header())
+	}
+
+	// Respons error dari middleware lain juga harus membawa header CORS
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tidak-ada", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Fatalf("unknown route: got status %d, headers %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"tokobiru/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingSpansCoverRequestAndLLMCall(t *testing.T) {
+	// Setup memasang propagator W3C seperti di main; span direkam in-memory
+	if _, err := tracing.Setup(context.Background(), tracing.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	s := newTestServer(t)
+	s.createProduct("Topi Baseball", 60000, 20)
+
+	// Trace dari upstream diteruskan lewat header traceparent W3C
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	headers := map[string]string{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"}
+	if code := s.doWithHeaders(http.MethodPost, "/api/v1/chatbot/ask", headers, gin.H{"prompt": "Berapa harga topi?"}, nil); code != http.StatusOK {
+		t.Fatalf("ask: got status %d", code)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	request, ok := spans["/api/v1/chatbot/ask"]
+	if !ok {
+		t.Fatalf("missing request span, got %v", spanNames(recorder.Ended()))
+	}
+	if got := request.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("request span trace ID: got %s, want %s", got, traceID)
+	}
+	llm, ok := spans["llm.generate"]
+	if !ok {
+		t.Fatalf("missing llm span, got %v", spanNames(recorder.Ended()))
+	}
+	if llm.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("llm span should be a child of the request span")
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
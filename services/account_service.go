@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidUserToken dikembalikan jika token reset password atau verifikasi
+// email tidak dikenal, kedaluwarsa atau sudah dipakai
+var ErrInvalidUserToken = errors.New("invalid, expired or already used token")
+
+// AccountService mengelola reset password dan verifikasi email. Token dikirim
+// lewat Mailer dan hanya hash-nya yang disimpan.
+type AccountService struct {
+	users     repositories.UserRepository
+	tokens    repositories.UserTokenRepository
+	sessions  repositories.SessionRepository
+	mailer    Mailer
+	baseURL   string
+	resetTTL  time.Duration
+	verifyTTL time.Duration
+}
+
+// NewAccountService membuat instance baru dari AccountService
+func NewAccountService(users repositories.UserRepository, tokens repositories.UserTokenRepository, sessions repositories.SessionRepository, mailer Mailer, baseURL string, resetTTL, verifyTTL time.Duration) *AccountService {
+	return &AccountService{
+		users:     users,
+		tokens:    tokens,
+		sessions:  sessions,
+		mailer:    mailer,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		resetTTL:  resetTTL,
+		verifyTTL: verifyTTL,
+	}
+}
+
+// SendVerificationEmail mengirim link verifikasi ke email user. Link yang
+// dikirim sebelumnya tidak berlaku lagi.
+func (s *AccountService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	if user.EmailVerified {
+		return nil
+	}
+	token, err := s.issue(ctx, user, models.TokenPurposeEmailVerification, s.verifyTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, Email{
+		To:      user.Email,
+		Subject: "Verifikasi email akun Tokobiru",
+		Body: fmt.Sprintf("Halo %s,\n\nBuka link berikut untuk memverifikasi email Anda:\n%s\n\nLink berlaku selama %s.\n",
+			user.Name, s.link("/verify-email", token), s.verifyTTL),
+	})
+}
+
+// ResendVerification mengirim ulang link verifikasi. Email yang tidak
+// terdaftar diabaikan agar endpoint tidak membocorkan akun yang ada.
+func (s *AccountService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, strings.TrimSpace(email))
+	if err == repositories.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.SendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail memakai token verifikasi untuk menandai email user terverifikasi
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	userToken, err := s.consume(ctx, token, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	return s.users.MarkEmailVerified(ctx, userToken.UserID)
+}
+
+// ForgotPassword mengirim link reset password. Seperti ResendVerification,
+// email yang tidak terdaftar tidak menghasilkan error.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, strings.TrimSpace(email))
+	if err == repositories.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token, err := s.issue(ctx, user, models.TokenPurposePasswordReset, s.resetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, Email{
+		To:      user.Email,
+		Subject: "Reset password akun Tokobiru",
+		Body: fmt.Sprintf("Halo %s,\n\nKami menerima permintaan reset password. Buka link berikut untuk membuat password baru:\n%s\n\nLink berlaku selama %s. Abaikan email ini jika Anda tidak memintanya.\n",
+			user.Name, s.link("/reset-password", token), s.resetTTL),
+	})
+}
+
+// ResetPassword mengganti password dengan token reset dan mencabut semua
+// session login user tersebut. Karena link diterima lewat email, email user
+// sekaligus dianggap terverifikasi.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	userToken, err := s.consume(ctx, token, models.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	if err := s.SetPassword(ctx, userToken.UserID, password); err != nil {
+		return err
+	}
+	if err := s.users.MarkEmailVerified(ctx, userToken.UserID); err != nil {
+		slog.WarnContext(ctx, "could not mark email verified", "user_id", userToken.UserID.Hex(), "error", err)
+	}
+	return nil
+}
+
+// SetPassword mengganti password user dan mencabut semua session loginnya.
+// Dipakai oleh ResetPassword dan oleh operator lewat CLI admin.
+func (s *AccountService) SetPassword(ctx context.Context, userID primitive.ObjectID, password string) error {
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdateProfile(ctx, userID, "", hashedPassword); err != nil {
+		return err
+	}
+	return s.sessions.RevokeUser(ctx, userID, time.Now())
+}
+
+// issue membuat token sekali pakai baru dan membatalkan token lama dengan tujuan yang sama
+func (s *AccountService) issue(ctx context.Context, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	if err := s.tokens.InvalidateForUser(ctx, user.ID, purpose, now); err != nil {
+		return "", err
+	}
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	userToken := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := s.tokens.Create(ctx, userToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consume memvalidasi token lalu menandainya sudah dipakai secara atomik
+func (s *AccountService) consume(ctx context.Context, token, purpose string) (*models.UserToken, error) {
+	userToken, err := s.tokens.FindByTokenHash(ctx, hashOpaqueToken(token))
+	if err == repositories.ErrNotFound {
+		return nil, ErrInvalidUserToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if userToken.Purpose != purpose || userToken.UsedAt != nil || !now.Before(userToken.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+	used, err := s.tokens.MarkUsed(ctx, userToken.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidUserToken
+	}
+	return userToken, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
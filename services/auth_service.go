@@ -1,25 +1,46 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
-	"tokobiru/config"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // JWTClaims adalah struct yang akan di-encode ke dalam JWT.
 // Hanya didefinisikan di sini.
 type JWTClaims struct {
-	UserID string `json:"userId"`
-	Role   string `json:"role"`
+	UserID    string `json:"userId"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // Keluarga refresh token yang menerbitkan token ini
 	jwt.RegisteredClaims
 }
 
+// mfaAudience menandai token "mfa pending" yang hanya bisa ditukar di
+// langkah kedua login dan tidak berlaku sebagai access token
+const mfaAudience = "mfa"
+
+// ErrMFATokenNotAccepted dikembalikan jika token "mfa pending" dipakai sebagai access token
+var ErrMFATokenNotAccepted = errors.New("mfa pending token cannot be used as access token")
+
+// passwordCost adalah cost bcrypt untuk semua hash password
+const passwordCost = 14
+
+// DummyPasswordHash adalah hash bcrypt dengan cost yang sama seperti
+// HashPassword. Login dengan email yang tidak terdaftar tetap membandingkan
+// password dengan hash ini, agar waktu responsnya sama dengan password salah
+// dan tidak membocorkan email mana yang terdaftar.
+const DummyPasswordHash = "$2a$14$x8dEytq.gO.ByqkBiBN7J.IXPXHeSSiEo2YAv9ouCnXdtLm/B3mf6"
+
 // HashPassword untuk melakukan hashing pada password.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
 	return string(bytes), err
 }
 
@@ -29,45 +50,90 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateToken untuk membuat token JWT baru.
-func GenerateToken(userID, role string) (string, error) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return "", err
-	}
-
-	// Token berlaku selama 72 jam.
-	expirationTime := time.Now().Add(72 * time.Hour)
+// GenerateToken untuk membuat access token JWT baru yang ditandatangani
+// dengan kunci aktif di keys. sessionID adalah keluarga refresh token yang
+// menerbitkannya (boleh kosong).
+func GenerateToken(keys *KeyRing, userID, role, sessionID string, ttl time.Duration) (string, error) {
+	// Access token berumur pendek; perpanjang lewat refresh token.
+	expirationTime := time.Now().Add(ttl)
 	claims := &JWTClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "tokobiru",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.JWTSecretKey))
+	return keys.Sign(claims)
 }
 
-// ValidateToken untuk memvalidasi token JWT.
-func ValidateToken(tokenString string, secretKey string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(secretKey), nil
-	})
+// ValidateToken untuk memvalidasi token JWT berdasarkan header kid-nya.
+func ValidateToken(tokenString string, keys *KeyRing) (*JWTClaims, error) {
+	token, err := keys.Parse(tokenString, &JWTClaims{})
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+		for _, audience := range claims.Audience {
+			if audience == mfaAudience {
+				return nil, ErrMFATokenNotAccepted
+			}
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
+
+// GenerateMFAToken membuat token "mfa pending" berumur pendek setelah password
+// benar. Token ini hanya bisa ditukar dengan access token bersama kode TOTP.
+func GenerateMFAToken(keys *KeyRing, userID string, ttl time.Duration) (string, error) {
+	claims := &JWTClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "tokobiru",
+		},
+	}
+
+	return keys.Sign(claims)
+}
+
+// ValidateMFAToken memvalidasi token "mfa pending" dan mengembalikan ID user-nya
+func ValidateMFAToken(tokenString string, keys *KeyRing) (string, error) {
+	token, err := keys.Parse(tokenString, &JWTClaims{}, jwt.WithAudience(mfaAudience))
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return "", errors.New("invalid token")
+	}
+	return claims.UserID, nil
+}
+
+// newOpaqueToken membuat token acak 256-bit untuk refresh token dan undangan
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashOpaqueToken menghasilkan hash SHA-256 yang disimpan di database.
+// Token sudah acak 256-bit, jadi hash cepat tanpa salt sudah cukup.
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDummyPasswordHashMatchesPasswordCost(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(DummyPasswordHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != passwordCost {
+		t.Fatalf("dummy hash cost %d, want %d", cost, passwordCost)
+	}
+	if CheckPasswordHash("", DummyPasswordHash) {
+		t.Fatal("dummy hash should not match an empty password")
+	}
+}
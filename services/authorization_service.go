@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidRoleName dikembalikan jika nama role tidak sesuai format
+	ErrInvalidRoleName = errors.New("role name must be 2-32 lowercase letters, digits, '-' or '_'")
+	// ErrUnknownPermission dikembalikan jika permission tidak dikenal
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrBuiltInRole dikembalikan saat mencoba menghapus role bawaan atau mengubah role admin
+	ErrBuiltInRole = errors.New("built-in role cannot be modified")
+	// ErrRoleInUse dikembalikan saat menghapus role yang masih dipakai user
+	ErrRoleInUse = errors.New("role is still assigned to users")
+	// ErrLastAdmin dikembalikan saat mencabut role admin dari admin terakhir
+	ErrLastAdmin = errors.New("cannot remove the last admin")
+)
+
+// roleCacheTTL adalah lama cache permission per role di memori. Perubahan
+// dari instance lain baru terlihat setelah cache kedaluwarsa.
+const roleCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// defaultRoles adalah role yang dibuat saat startup jika belum ada
+var defaultRoles = []models.Role{
+	{
+		Name:        models.RoleAdmin,
+		Description: "Akses penuh ke seluruh toko",
+		Permissions: models.Permissions,
+		BuiltIn:     true,
+	},
+	{
+		Name:        models.RoleCustomer,
+		Description: "Pelanggan: keranjang dan pesanan sendiri",
+		Permissions: []string{models.PermCartManage, models.PermOrdersOwn},
+		BuiltIn:     true,
+	},
+	{
+		Name:        "warehouse",
+		Description: "Staf gudang: melihat pesanan dan mengubah statusnya",
+		Permissions: []string{models.PermOrdersRead, models.PermOrdersUpdateStatus},
+	},
+	{
+		Name:        "support",
+		Description: "Customer support: membaca pesanan, user dan transkrip chatbot",
+		Permissions: []string{models.PermOrdersRead, models.PermUsersRead, models.PermConversationsRead},
+	},
+}
+
+// AuthorizationService memetakan role ke permission dan mengelola role
+type AuthorizationService struct {
+	roles repositories.RoleRepository
+	users repositories.UserRepository
+
+	mu       sync.RWMutex
+	cache    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+// NewAuthorizationService membuat instance baru dari AuthorizationService
+func NewAuthorizationService(roles repositories.RoleRepository, users repositories.UserRepository) *AuthorizationService {
+	return &AuthorizationService{roles: roles, users: users}
+}
+
+// EnsureDefaultRoles membuat role bawaan yang belum ada. Role yang sudah ada
+// tidak diubah, kecuali admin yang selalu memiliki semua permission.
+func (s *AuthorizationService) EnsureDefaultRoles(ctx context.Context) error {
+	now := time.Now()
+	for _, role := range defaultRoles {
+		role.CreatedAt, role.UpdatedAt = now, now
+		err := s.roles.Create(ctx, &role)
+		if err == repositories.ErrDuplicate && role.Name == models.RoleAdmin {
+			err = s.roles.Update(ctx, &role)
+		}
+		if err != nil && err != repositories.ErrDuplicate {
+			return err
+		}
+	}
+	s.invalidate()
+	return nil
+}
+
+// HasPermissions bernilai true jika role memiliki semua permission yang diminta
+func (s *AuthorizationService) HasPermissions(ctx context.Context, role string, permissions ...string) (bool, error) {
+	cache, err := s.permissionCache(ctx)
+	if err != nil {
+		return false, err
+	}
+	granted := cache[role]
+	for _, permission := range permissions {
+		if !granted[permission] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *AuthorizationService) permissionCache(ctx context.Context) (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	cache, loadedAt := s.cache, s.loadedAt
+	s.mu.RUnlock()
+	if cache != nil && time.Since(loadedAt) < roleCacheTTL {
+		return cache, nil
+	}
+
+	roles, err := s.roles.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache = make(map[string]map[string]bool, len(roles))
+	for _, role := range roles {
+		granted := make(map[string]bool, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			granted[permission] = true
+		}
+		cache[role.Name] = granted
+	}
+
+	s.mu.Lock()
+	s.cache, s.loadedAt = cache, time.Now()
+	s.mu.Unlock()
+	return cache, nil
+}
+
+func (s *AuthorizationService) invalidate() {
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+}
+
+// ListRoles mengembalikan semua role beserta permission-nya
+func (s *AuthorizationService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roles.List(ctx)
+}
+
+// CreateRole membuat role baru
+func (s *AuthorizationService) CreateRole(ctx context.Context, name, description string, permissions []string) (*models.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidRoleName
+	}
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	role := &models.Role{Name: name, Description: description, Permissions: permissions, CreatedAt: now, UpdatedAt: now}
+	if err := s.roles.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return role, nil
+}
+
+// UpdateRole mengganti deskripsi dan permission sebuah role. Role admin tidak
+// bisa diubah agar toko tidak kehilangan akses administrasi.
+func (s *AuthorizationService) UpdateRole(ctx context.Context, name, description string, permissions []string) (*models.Role, error) {
+	if name == models.RoleAdmin {
+		return nil, ErrBuiltInRole
+	}
+	role, err := s.roles.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role.Permissions, err = normalizePermissions(permissions); err != nil {
+		return nil, err
+	}
+	role.Description = description
+	role.UpdatedAt = time.Now()
+	if err := s.roles.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return role, nil
+}
+
+// DeleteRole menghapus role yang bukan bawaan dan tidak dipakai oleh user manapun
+func (s *AuthorizationService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.roles.FindByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return ErrBuiltInRole
+	}
+	count, err := s.users.CountByRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+	if err := s.roles.Delete(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// AssignRole mengganti role seorang user. Role baru berlaku pada access token
+// berikutnya (setelah refresh atau login ulang).
+func (s *AuthorizationService) AssignRole(ctx context.Context, userID primitive.ObjectID, role string) error {
+	if _, err := s.roles.FindByName(ctx, role); err != nil {
+		return err
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		count, err := s.users.CountByRole(ctx, models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return ErrLastAdmin
+		}
+	}
+	return s.users.UpdateRole(ctx, userID, role)
+}
+
+// normalizePermissions memvalidasi dan menghapus duplikat permission
+func normalizePermissions(permissions []string) ([]string, error) {
+	known := make(map[string]bool, len(models.Permissions))
+	for _, permission := range models.Permissions {
+		known[permission] = true
+	}
+	seen := make(map[string]bool, len(permissions))
+	out := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if !known[permission] {
+			return nil, ErrUnknownPermission
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			out = append(out, permission)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuthorizationServicePermissions(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	authz := NewAuthorizationService(store.Roles, store.Users)
+	ctx := context.Background()
+	if err := authz.EnsureDefaultRoles(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{models.RoleAdmin, models.PermRolesManage, true},
+		{models.RoleCustomer, models.PermCartManage, true},
+		{models.RoleCustomer, models.PermOrdersRead, false},
+		{"warehouse", models.PermOrdersUpdateStatus, true},
+		{"warehouse", models.PermProductsWrite, false},
+		{"support", models.PermConversationsRead, true},
+		{"unknown", models.PermCartManage, false},
+	}
+	for _, tc := range cases {
+		got, err := authz.HasPermissions(ctx, tc.role, tc.permission)
+		if err != nil || got != tc.want {
+			t.Errorf("HasPermissions(%s, %s) = %v, %v; want %v", tc.role, tc.permission, got, err, tc.want)
+		}
+	}
+
+	// Perubahan role langsung terlihat karena cache di-invalidate
+	if _, err := authz.UpdateRole(ctx, "warehouse", "", []string{models.PermProductsWrite}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := authz.HasPermissions(ctx, "warehouse", models.PermProductsWrite); !got {
+		t.Fatal("updated permission not visible")
+	}
+}
+
+func TestAssignRoleKeepsLastAdmin(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	authz := NewAuthorizationService(store.Roles, store.Users)
+	ctx := context.Background()
+	if err := authz.EnsureDefaultRoles(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	admin := &models.User{ID: primitive.NewObjectID(), Email: "admin@example.com", Role: models.RoleAdmin, CreatedAt: time.Now()}
+	if err := store.Users.Create(ctx, admin); err != nil {
+		t.Fatal(err)
+	}
+	if err := authz.AssignRole(ctx, admin.ID, models.RoleCustomer); err != ErrLastAdmin {
+		t.Fatalf("demote last admin: got %v, want ErrLastAdmin", err)
+	}
+	if err := authz.AssignRole(ctx, admin.ID, "ghost"); err != repositories.ErrNotFound {
+		t.Fatalf("assign unknown role: got %v, want ErrNotFound", err)
+	}
+}
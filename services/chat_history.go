@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"tokobiru/models"
+	"unicode/utf8"
+)
+
+// maxSummaryQuestionRunes membatasi panjang setiap pertanyaan dalam ringkasan
+const maxSummaryQuestionRunes = 80
+
+// approxTokens memperkirakan jumlah token sebuah teks (sekitar 4 karakter per token)
+func approxTokens(text string) int {
+	return utf8.RuneCountInString(text)/4 + 1
+}
+
+// trimHistory mengambil pesan terbaru yang muat dalam budget token. Pesan yang
+// lebih lama tidak dikirim ulang ke LLM, tetapi pertanyaan pelanggan di
+// dalamnya diringkas agar konteks awal percakapan tidak hilang.
+func trimHistory(messages []models.ChatMessage, budget int) ([]models.ChatMessage, string) {
+	used := 0
+	start := len(messages)
+	for start > 0 {
+		cost := approxTokens(messages[start-1].Content)
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		start--
+	}
+	// Mulai dari pesan pelanggan agar urutan giliran tetap user → assistant
+	for start < len(messages) && messages[start].Role != models.ChatRoleUser {
+		start++
+	}
+	return messages[start:], summarizeMessages(messages[:start], budget/4)
+}
+
+// summarizeMessages membuat ringkasan ekstraktif dari pertanyaan-pertanyaan
+// pelanggan, memprioritaskan yang paling baru jika melebihi budget.
+func summarizeMessages(messages []models.ChatMessage, budget int) string {
+	var questions []string
+	used := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != models.ChatRoleUser {
+			continue
+		}
+		question := truncateRunes(strings.TrimSpace(messages[i].Content), maxSummaryQuestionRunes)
+		if used+approxTokens(question) > budget {
+			break
+		}
+		used += approxTokens(question)
+		questions = append([]string{question}, questions...)
+	}
+	if len(questions) == 0 {
+		return ""
+	}
+	return "Ringkasan percakapan sebelumnya, pelanggan telah menanyakan: " + strings.Join(questions, "; ")
+}
+
+// lastUserMessage mengembalikan pertanyaan pelanggan terakhir dalam riwayat
+func lastUserMessage(history []models.ChatMessage) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == models.ChatRoleUser {
+			return history[i].Content
+		}
+	}
+	return ""
+}
+
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "…"
+}
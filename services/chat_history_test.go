@@ -0,0 +1,38 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"tokobiru/models"
+)
+
+func TestTrimHistory(t *testing.T) {
+	long := strings.Repeat("kata ", 40) // ~50 token
+	messages := []models.ChatMessage{
+		{Role: models.ChatRoleUser, Content: "Ada kaos polos?"},
+		{Role: models.ChatRoleAssistant, Content: long},
+		{Role: models.ChatRoleUser, Content: "Kalau celana jeans?"},
+		{Role: models.ChatRoleAssistant, Content: long},
+		{Role: models.ChatRoleUser, Content: "Yang biru ada?"},
+		{Role: models.ChatRoleAssistant, Content: "Ada."},
+	}
+
+	kept, summary := trimHistory(messages, 40)
+	if len(kept) != 2 || kept[0].Content != "Yang biru ada?" {
+		t.Fatalf("expected only the last turn to fit, got %+v", kept)
+	}
+	if !strings.Contains(summary, "Ada kaos polos?") || !strings.Contains(summary, "Kalau celana jeans?") {
+		t.Fatalf("older questions should be summarized: %q", summary)
+	}
+
+	kept, summary = trimHistory(messages, 10000)
+	if len(kept) != len(messages) || summary != "" {
+		t.Fatalf("everything fits: got %d messages, summary %q", len(kept), summary)
+	}
+
+	// Riwayat tidak boleh diawali jawaban assistant
+	kept, _ = trimHistory(messages, 60)
+	if len(kept) > 0 && kept[0].Role != models.ChatRoleUser {
+		t.Fatalf("history must start with a user turn, got %+v", kept[0])
+	}
+}
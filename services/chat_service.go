@@ -2,101 +2,379 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"strings"
+	"time"
+	"tokobiru/metrics"
 	"tokobiru/models"
+	"tokobiru/repositories"
+	"tokobiru/tracing"
 
-	"github.com/google/generative-ai-go/genai"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-	"google.golang.org/api/option"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const chatSystemPrompt = `Anda adalah asisten AI untuk toko online bernama 'Toko Biru'. 
+Tugas Anda adalah menjawab pertanyaan pelanggan dengan ramah, membantu, dan informatif berdasarkan data produk yang tersedia.
+Selalu jawab dalam Bahasa Indonesia.`
+
+const chatToolsPrompt = `
+Gunakan tools yang tersedia untuk mengecek stok terkini, status pesanan, atau isi keranjang pelanggan.
+Jangan pernah menebak data pesanan atau keranjang; jika tool mengembalikan error, sampaikan dengan sopan.`
+
+// maxToolRounds membatasi jumlah putaran pemanggilan tool per pertanyaan
+const maxToolRounds = 3
+
 // ChatService menangani semua logika yang berhubungan dengan AI
 type ChatService struct {
-	db          *mongo.Client
-	geminiModel *genai.GenerativeModel
+	retriever     *ProductRetriever
+	tools         *ChatTools
+	conversations repositories.ConversationRepository
+	provider      LLMProvider
+	fallback      LLMProvider
+	historyTokens int
+}
+
+// ChatOwner mengidentifikasi pemilik percakapan: user yang login (UserID)
+// atau sesi anonim (SessionID).
+type ChatOwner struct {
+	UserID    string
+	SessionID string
 }
 
-// NewChatService membuat instance baru dari ChatService
-func NewChatService(db *mongo.Client, model *genai.GenerativeModel) *ChatService {
+// ChatRequest adalah pertanyaan pelanggan beserta percakapan yang dilanjutkan
+type ChatRequest struct {
+	Prompt         string
+	ConversationID string // Kosong berarti memulai percakapan baru
+	Owner          ChatOwner
+}
+
+// ChatResponse adalah jawaban chatbot beserta produk yang dipakai sebagai konteks
+type ChatResponse struct {
+	Reply          string   `json:"reply"`
+	ProductIDs     []string `json:"productIds"`
+	ConversationID string   `json:"conversationId"`
+	SessionID      string   `json:"sessionId,omitempty"` // Diisi untuk pemanggil anonim
+}
+
+// NewChatService membuat instance baru dari ChatService. Jika provider gagal
+// menjawab, ChatService beralih ke RuleBasedProvider. historyTokens adalah
+// perkiraan jumlah token riwayat percakapan yang dikirim ulang ke LLM.
+func NewChatService(retriever *ProductRetriever, tools *ChatTools, conversations repositories.ConversationRepository, provider LLMProvider, historyTokens int) *ChatService {
 	return &ChatService{
-		db:          db,
-		geminiModel: model,
+		retriever:     retriever,
+		tools:         tools,
+		conversations: conversations,
+		provider:      provider,
+		fallback:      NewRuleBasedProvider(),
+		historyTokens: historyTokens,
 	}
 }
 
-// SetupGeminiClient adalah fungsi helper untuk inisialisasi klien Gemini
-func SetupGeminiClient(ctx context.Context) *genai.GenerativeModel {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("GEMINI_API_KEY environment variable not set.")
+// ProviderName mengembalikan nama LLM provider yang aktif
+func (s *ChatService) ProviderName() string {
+	return s.provider.Name()
+}
+
+// GenerateResponse mengirimkan prompt ke LLM dan mengembalikan jawaban.
+// Riwayat percakapan diputar ulang dan giliran baru disimpan. Owner.UserID
+// kosong berarti pemanggil anonim; tool data pribadi tidak tersedia.
+func (s *ChatService) GenerateResponse(ctx context.Context, input ChatRequest) (*ChatResponse, error) {
+	return s.generate(ctx, input, nil)
+}
+
+// GenerateStream sama seperti GenerateResponse, tetapi memanggil onToken untuk
+// setiap potongan jawaban. Provider tanpa dukungan streaming mengirim jawaban
+// utuh sebagai satu potongan. Jika ctx dibatalkan (misalnya klien memutus
+// koneksi), pemanggilan LLM dihentikan dan percakapan tidak disimpan.
+func (s *ChatService) GenerateStream(ctx context.Context, input ChatRequest, onToken func(string) error) (*ChatResponse, error) {
+	return s.generate(ctx, input, onToken)
+}
+
+func (s *ChatService) generate(ctx context.Context, input ChatRequest, onToken func(string) error) (*ChatResponse, error) {
+	owner := input.Owner
+	if owner.UserID == "" && owner.SessionID == "" {
+		owner.SessionID = newSessionID()
 	}
 
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	conversation, err := s.loadConversation(ctx, input.ConversationID, owner)
 	if err != nil {
-		log.Fatalf("Failed to create Gemini client: %v", err)
+		return nil, err
 	}
+	history, summary := trimHistory(conversation.Messages, s.historyTokens)
 
-	model := client.GenerativeModel("gemini-1.5-flash")
-	return model
-}
-
-// GenerateResponse mengirimkan prompt ke Gemini dan mengembalikan jawaban
-func (s *ChatService) GenerateResponse(ctx context.Context, prompt string) (string, error) {
-	// --- LOGIKA BARU: Ambil semua data produk dari DB ---
-	productCollection := s.db.Database("tokobiruDB").Collection("products")
-	cursor, err := productCollection.Find(ctx, bson.M{})
+	// Ambil hanya produk yang relevan dengan pertanyaan, bukan seluruh katalog.
+	// Pertanyaan sebelumnya ikut dipakai agar pertanyaan lanjutan tetap relevan.
+	query := input.Prompt
+	if previous := lastUserMessage(history); previous != "" {
+		query = previous + " " + input.Prompt
+	}
+	products, err := s.retriever.Retrieve(ctx, query)
 	if err != nil {
-		log.Printf("Error fetching products for AI context: %v", err)
+		slog.ErrorContext(ctx, "could not retrieve products for chatbot context", "error", err)
 		// Tetap lanjutkan tanpa konteks produk jika ada error
+		products = nil
 	}
 
-	var products []models.Product
-	if err = cursor.All(ctx, &products); err != nil {
-		log.Printf("Error decoding products for AI context: %v", err)
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID.Hex())
 	}
 
-	// Ubah data produk menjadi format JSON yang bisa dibaca AI
-	productContextBytes, err := json.Marshal(products)
-	productContext := ""
-	if err == nil {
-		productContext = string(productContextBytes)
+	req := LLMRequest{
+		SystemPrompt: chatSystemPrompt + chatToolsPrompt,
+		Prompt:       buildProductPrompt(products, input.Prompt),
+		Question:     input.Prompt,
+		Products:     products,
+		History:      history,
+		Summary:      summary,
+		Tools:        s.tools.Definitions(owner.UserID != ""),
 	}
-	// --- AKHIR LOGIKA BARU ---
 
-	// Buat prompt yang kaya dengan konteks produk
-	fullPrompt := fmt.Sprintf(`
-Anda adalah asisten AI untuk toko online bernama 'Toko Biru'. 
-Tugas Anda adalah menjawab pertanyaan pelanggan dengan ramah, membantu, dan informatif berdasarkan data produk yang tersedia.
-Selalu jawab dalam Bahasa Indonesia.
+	// Fallback hanya aman selama belum ada potongan jawaban yang terkirim
+	emitted := false
+	var emit func(string) error
+	if onToken != nil {
+		emit = func(token string) error {
+			emitted = true
+			return onToken(token)
+		}
+	}
 
-Berikut adalah data produk kami dalam format JSON:
-%s
+	reply, err := s.run(ctx, s.provider, req, owner.UserID, emit)
+	if err != nil && !emitted && ctx.Err() == nil && s.provider.Name() != s.fallback.Name() {
+		slog.WarnContext(ctx, "LLM provider failed, using fallback", "provider", s.provider.Name(), "fallback", s.fallback.Name(), "error", err)
+		reply, err = s.run(ctx, s.fallback, req, owner.UserID, emit)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-Berdasarkan data di atas, jawab pertanyaan pelanggan berikut: "%s"
-`, productContext, prompt)
+	if reply == "" {
+		reply = "Maaf, saya tidak bisa memberikan jawaban saat ini."
+		if emit != nil {
+			if err := emit(reply); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	turn := []models.ChatMessage{
+		{Role: models.ChatRoleUser, Content: input.Prompt, CreatedAt: now},
+		{Role: models.ChatRoleAssistant, Content: reply, ProductIDs: productIDs, CreatedAt: now},
+	}
+	if conversation.ID.IsZero() {
+		conversation.Title = truncateRunes(input.Prompt, 60)
+		conversation.Messages = turn
+		conversation.MessageCount = len(turn)
+		conversation.CreatedAt = now
+		conversation.UpdatedAt = now
+		err = s.conversations.Create(ctx, conversation)
+	} else {
+		err = s.conversations.AppendMessages(ctx, conversation.ID, turn...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan percakapan: %w", err)
+	}
+
+	response := &ChatResponse{Reply: reply, ProductIDs: productIDs, ConversationID: conversation.ID.Hex()}
+	if owner.UserID == "" {
+		response.SessionID = owner.SessionID
+	}
+	return response, nil
+}
+
+// loadConversation mengambil percakapan milik owner, atau menyiapkan percakapan
+// baru (belum disimpan) jika id kosong.
+func (s *ChatService) loadConversation(ctx context.Context, id string, owner ChatOwner) (*models.Conversation, error) {
+	if id == "" {
+		conversation := &models.Conversation{SessionID: owner.SessionID}
+		if owner.UserID != "" {
+			userID, err := primitive.ObjectIDFromHex(owner.UserID)
+			if err != nil {
+				return nil, err
+			}
+			conversation.UserID = &userID
+			conversation.SessionID = ""
+		}
+		return conversation, nil
+	}
+	return s.GetConversation(ctx, id, owner)
+}
+
+// ListConversations mengembalikan daftar percakapan milik owner tanpa isi pesan
+func (s *ChatService) ListConversations(ctx context.Context, owner ChatOwner, skip, limit int64) ([]models.Conversation, int64, error) {
+	filter := repositories.ConversationFilter{Skip: skip, Limit: limit}
+	switch {
+	case owner.UserID != "":
+		userID, err := primitive.ObjectIDFromHex(owner.UserID)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.UserID = userID
+	case owner.SessionID != "":
+		filter.SessionID = owner.SessionID
+	default:
+		// Tanpa identitas tidak ada percakapan yang boleh ditampilkan
+		return nil, 0, nil
+	}
+	return s.conversations.List(ctx, filter)
+}
 
-	resp, err := s.geminiModel.GenerateContent(ctx, genai.Text(fullPrompt))
+// GetConversation mengambil percakapan lengkap. repositories.ErrNotFound
+// dikembalikan jika percakapan tidak ada atau bukan milik owner.
+func (s *ChatService) GetConversation(ctx context.Context, id string, owner ChatOwner) (*models.Conversation, error) {
+	conversationID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return "", fmt.Errorf("gagal menghasilkan konten dari Gemini: %w", err)
+		return nil, repositories.ErrNotFound
 	}
+	conversation, err := s.conversations.FindByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !ownsConversation(conversation, owner) {
+		return nil, repositories.ErrNotFound
+	}
+	return conversation, nil
+}
+
+// DeleteConversation menghapus percakapan milik owner
+func (s *ChatService) DeleteConversation(ctx context.Context, id string, owner ChatOwner) error {
+	conversation, err := s.GetConversation(ctx, id, owner)
+	if err != nil {
+		return err
+	}
+	return s.conversations.Delete(ctx, conversation.ID)
+}
+
+func ownsConversation(conversation *models.Conversation, owner ChatOwner) bool {
+	if owner.UserID != "" {
+		return conversation.UserID != nil && conversation.UserID.Hex() == owner.UserID
+	}
+	return owner.SessionID != "" && conversation.UserID == nil && conversation.SessionID == owner.SessionID
+}
+
+// newSessionID membuat ID sesi acak untuk pemanggil anonim
+func newSessionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return primitive.NewObjectID().Hex()
+	}
+	return hex.EncodeToString(buf)
+}
+
+// run memanggil provider dan menjalankan tool yang diminta sampai provider
+// mengembalikan jawaban teks atau batas maxToolRounds tercapai. Jika onToken
+// tidak nil, teks jawaban diteruskan secara bertahap.
+func (s *ChatService) run(ctx context.Context, provider LLMProvider, req LLMRequest, userID string, onToken func(string) error) (string, error) {
+	var reply strings.Builder
+	for round := 0; ; round++ {
+		resp, err := generateWith(ctx, provider, req, onToken)
+		if err != nil {
+			return "", err
+		}
+		reply.WriteString(resp.Text)
+		if len(resp.ToolCalls) == 0 || round == maxToolRounds {
+			return reply.String(), nil
+		}
 
-	var replyText strings.Builder
-	if len(resp.Candidates) > 0 {
-		for _, part := range resp.Candidates[0].Content.Parts {
-			if txt, ok := part.(genai.Text); ok {
-				replyText.WriteString(string(txt))
+		exchange := ToolExchange{Calls: resp.ToolCalls}
+		for i := range exchange.Calls {
+			call := &exchange.Calls[i]
+			if call.ID == "" {
+				call.ID = fmt.Sprintf("call_%d_%d", round, i)
 			}
+			exchange.Results = append(exchange.Results, ToolResult{
+				CallID: call.ID,
+				Name:   call.Name,
+				Result: s.tools.Execute(ctx, userID, *call),
+			})
 		}
+		req.Exchanges = append(req.Exchanges, exchange)
 	}
+}
 
-	if replyText.Len() == 0 {
-		return "Maaf, saya tidak bisa memberikan jawaban saat ini.", nil
+// generateWith memanggil provider di dalam span "llm.generate" dan mencatat
+// latensi, error dan pemakaian token untuk metrik
+func generateWith(ctx context.Context, provider LLMProvider, req LLMRequest, onToken func(string) error) (*LLMResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "llm.generate", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("llm.provider", provider.Name()),
+		attribute.Bool("llm.stream", onToken != nil),
+	))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := callProvider(ctx, provider, req, onToken)
+	var usage LLMUsage
+	if resp != nil {
+		usage = resp.Usage
+		span.SetAttributes(
+			attribute.Int("llm.usage.prompt_tokens", usage.PromptTokens),
+			attribute.Int("llm.usage.completion_tokens", usage.CompletionTokens),
+			attribute.Int("llm.tool_calls", len(resp.ToolCalls)),
+		)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
+	metrics.ObserveLLMCall(provider.Name(), time.Since(start), usage.PromptTokens, usage.CompletionTokens, err)
+	return resp, err
+}
 
-	return replyText.String(), nil
+// callProvider memakai streaming jika diminta dan didukung oleh provider
+func callProvider(ctx context.Context, provider LLMProvider, req LLMRequest, onToken func(string) error) (*LLMResponse, error) {
+	if onToken == nil {
+		return provider.Generate(ctx, req)
+	}
+	if streamer, ok := provider.(StreamingProvider); ok {
+		return streamer.GenerateStream(ctx, req, onToken)
+	}
+	resp, err := provider.Generate(ctx, req)
+	if err == nil && resp.Text != "" {
+		err = onToken(resp.Text)
+	}
+	return resp, err
+}
+
+// promptProduct adalah representasi ringkas produk untuk konteks LLM
+type promptProduct struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       string `json:"price"` // Sudah diformat, misalnya "Rp 85.000"
+	Stock       int    `json:"stock"`
+	Category    string `json:"category"`
+}
+
+// buildProductPrompt membuat prompt yang kaya dengan konteks produk hasil retrieval
+func buildProductPrompt(products []models.Product, question string) string {
+	items := make([]promptProduct, 0, len(products))
+	for _, product := range products {
+		items = append(items, promptProduct{
+			ID:          product.ID.Hex(),
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price.String(),
+			Stock:       product.Stock,
+			Category:    product.Category,
+		})
+	}
+
+	// Ubah data produk menjadi format JSON yang bisa dibaca AI
+	productContext, _ := json.Marshal(items)
+
+	return fmt.Sprintf(`Berikut adalah data produk yang relevan dalam format JSON:
+%s
+
+Jika data di atas kosong atau tidak cukup, katakan dengan jujur bahwa Anda tidak menemukan produknya.
+Berdasarkan data di atas, jawab pertanyaan pelanggan berikut: "%s"
+`, productContext, question)
 }
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"tokobiru/repositories"
+)
+
+func TestChatServiceStreamStopsWhenClientDisconnects(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	seedRetrieverProducts(t, store.Products)
+	service := NewChatService(
+		NewProductRetriever(store.Products, 3, false),
+		NewChatTools(store.Orders, store.Carts, store.Products),
+		store.Conversations,
+		NewRuleBasedProvider(),
+		1500,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens := 0
+	_, err := service.GenerateStream(ctx, ChatRequest{Prompt: "Berapa harga kaos?"}, func(token string) error {
+		tokens++
+		cancel() // Klien memutus koneksi setelah potongan pertama
+		return ctx.Err()
+	})
+	if err == nil || tokens != 1 {
+		t.Fatalf("expected the stream to stop after the first token, got %d tokens, err %v", tokens, err)
+	}
+
+	conversations, _, _ := store.Conversations.List(context.Background(), repositories.ConversationFilter{})
+	if len(conversations) != 0 {
+		t.Fatalf("cancelled turn must not be persisted, got %d conversations", len(conversations))
+	}
+}
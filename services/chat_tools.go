@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Nama tool yang tersedia untuk chatbot
+const (
+	ToolGetMyOrders       = "get_my_orders"
+	ToolGetMyCart         = "get_my_cart"
+	ToolCheckProductStock = "check_product_stock"
+)
+
+// maxToolOrders membatasi jumlah pesanan yang dikirim ke LLM
+const maxToolOrders = 5
+
+// ChatTools menjalankan tool sisi server untuk chatbot. Semua data pengguna
+// selalu dibatasi pada userID dari token yang terautentikasi, bukan dari
+// argumen yang dikirim oleh LLM, sehingga chatbot tidak bisa membaca data
+// pelanggan lain.
+type ChatTools struct {
+	orders   repositories.OrderRepository
+	carts    repositories.CartRepository
+	products repositories.ProductRepository
+}
+
+// NewChatTools membuat instance baru dari ChatTools
+func NewChatTools(orders repositories.OrderRepository, carts repositories.CartRepository, products repositories.ProductRepository) *ChatTools {
+	return &ChatTools{orders: orders, carts: carts, products: products}
+}
+
+// Harga dan total di hasil tool sudah diformat (misalnya "Rp 85.000") agar
+// LLM tidak salah membaca nominal dalam minor unit.
+
+// ToolOrder adalah ringkasan pesanan yang dikembalikan oleh get_my_orders
+type ToolOrder struct {
+	OrderID   string `json:"orderId"`
+	Status    string `json:"status"`
+	Total     string `json:"total"`
+	ItemCount int    `json:"itemCount"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ToolCartItem adalah item keranjang yang dikembalikan oleh get_my_cart
+type ToolCartItem struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Price    string `json:"price"`
+}
+
+// ToolStock adalah stok produk yang dikembalikan oleh check_product_stock
+type ToolStock struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	Price     string `json:"price"`
+	Stock     int    `json:"stock"`
+}
+
+// Definitions mengembalikan tool yang boleh dipakai. Tool data pribadi hanya
+// tersedia jika pemanggil sudah login.
+func (t *ChatTools) Definitions(authenticated bool) []ToolDefinition {
+	tools := []ToolDefinition{{
+		Name:        ToolCheckProductStock,
+		Description: "Cek stok dan harga terkini sebuah produk Toko Biru.",
+		Parameters: []ToolParameter{
+			{Name: "product", Description: "Nama atau ID produk", Required: true},
+		},
+	}}
+	if authenticated {
+		tools = append(tools,
+			ToolDefinition{
+				Name:        ToolGetMyOrders,
+				Description: "Ambil status pesanan milik pelanggan yang sedang login. Tanpa order_id, mengembalikan pesanan terbaru.",
+				Parameters: []ToolParameter{
+					{Name: "order_id", Description: "Nomor pesanan, misalnya TB-1718000000000000000"},
+				},
+			},
+			ToolDefinition{
+				Name:        ToolGetMyCart,
+				Description: "Ambil isi keranjang belanja pelanggan yang sedang login.",
+			},
+		)
+	}
+	return tools
+}
+
+// Execute menjalankan sebuah tool call. Error dikembalikan sebagai field "error"
+// agar LLM bisa menjelaskannya kepada pelanggan.
+func (t *ChatTools) Execute(ctx context.Context, userID string, call ToolCall) map[string]interface{} {
+	switch call.Name {
+	case ToolCheckProductStock:
+		return t.checkProductStock(ctx, call.Arguments["product"])
+	case ToolGetMyOrders, ToolGetMyCart:
+		uid, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return toolError("Pelanggan harus login untuk mengakses data ini.")
+		}
+		if call.Name == ToolGetMyOrders {
+			return t.getMyOrders(ctx, uid, call.Arguments["order_id"])
+		}
+		return t.getMyCart(ctx, uid)
+	default:
+		return toolError(fmt.Sprintf("Tool %q tidak dikenal.", call.Name))
+	}
+}
+
+func (t *ChatTools) getMyOrders(ctx context.Context, userID primitive.ObjectID, orderID string) map[string]interface{} {
+	orders, err := t.orders.FindByUserID(ctx, userID)
+	if err != nil {
+		return toolError("Gagal mengambil data pesanan.")
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.After(orders[j].CreatedAt) })
+
+	orderID = strings.ToUpper(strings.TrimSpace(orderID))
+	result := make([]ToolOrder, 0, maxToolOrders)
+	for _, order := range orders {
+		if orderID != "" && order.OrderID != orderID {
+			continue
+		}
+		result = append(result, ToolOrder{
+			OrderID:   order.OrderID,
+			Status:    order.Status,
+			Total:     order.Total.String(),
+			ItemCount: len(order.Items),
+			CreatedAt: order.CreatedAt.Format(time.RFC3339),
+		})
+		if len(result) == maxToolOrders {
+			break
+		}
+	}
+	if orderID != "" && len(result) == 0 {
+		return toolError(fmt.Sprintf("Pesanan %s tidak ditemukan di akun ini.", orderID))
+	}
+	return map[string]interface{}{"orders": result}
+}
+
+func (t *ChatTools) getMyCart(ctx context.Context, userID primitive.ObjectID) map[string]interface{} {
+	items := make([]ToolCartItem, 0)
+	var total models.Money
+	cart, err := t.carts.FindByUserID(ctx, userID)
+	if err != nil && err != repositories.ErrNotFound {
+		return toolError("Gagal mengambil data keranjang.")
+	}
+	if cart != nil {
+		for _, item := range cart.Items {
+			items = append(items, ToolCartItem{Name: item.Name, Quantity: item.Quantity, Price: item.Price.String()})
+			total = total.Add(item.Price.Mul(item.Quantity))
+		}
+	}
+	return map[string]interface{}{"items": items, "total": total.String()}
+}
+
+func (t *ChatTools) checkProductStock(ctx context.Context, query string) map[string]interface{} {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return toolError("Nama produk harus diisi.")
+	}
+
+	result := make([]ToolStock, 0)
+	if id, err := primitive.ObjectIDFromHex(query); err == nil {
+		if product, err := t.products.FindByID(ctx, id); err == nil {
+			result = append(result, ToolStock{ProductID: product.ID.Hex(), Name: product.Name, Price: product.Price.String(), Stock: product.Stock})
+		}
+	} else {
+		products, err := t.products.Search(ctx, query, 3)
+		if err != nil {
+			return toolError("Gagal mengecek stok produk.")
+		}
+		for _, product := range products {
+			result = append(result, ToolStock{ProductID: product.ID.Hex(), Name: product.Name, Price: product.Price.String(), Stock: product.Stock})
+		}
+	}
+	if len(result) == 0 {
+		return toolError(fmt.Sprintf("Produk %q tidak ditemukan.", query))
+	}
+	return map[string]interface{}{"products": result}
+}
+
+func toolError(message string) map[string]interface{} {
+	return map[string]interface{}{"error": message}
+}
+
+// toJSONMap mengubah hasil tool menjadi map JSON murni (untuk provider yang membutuhkannya)
+func toJSONMap(value map[string]interface{}) map[string]interface{} {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return toolError("Hasil tool tidak valid.")
+	}
+	var out map[string]interface{}
+	json.Unmarshal(raw, &out)
+	return out
+}
+
+// stringArguments menormalkan argumen tool dari LLM menjadi string
+func stringArguments(args map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(args))
+	for key, value := range args {
+		if value != nil {
+			out[key] = fmt.Sprint(value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChatToolsScopeOrdersToUser(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	tools := NewChatTools(store.Orders, store.Carts, store.Products)
+	ctx := context.Background()
+
+	owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+	order := &models.Order{UserID: owner, OrderID: "TB-42", Status: models.OrderStatusShipped, Total: models.IDR(100000), CreatedAt: time.Now()}
+	if err := store.Orders.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	call := ToolCall{Name: ToolGetMyOrders, Arguments: map[string]string{"order_id": "tb-42"}}
+	result := tools.Execute(ctx, owner.Hex(), call)
+	orders, _ := result["orders"].([]ToolOrder)
+	if len(orders) != 1 || orders[0].Status != models.OrderStatusShipped {
+		t.Fatalf("owner: unexpected result %+v", result)
+	}
+
+	if result := tools.Execute(ctx, other.Hex(), call); result["error"] == nil {
+		t.Fatalf("other user must not see the order: %+v", result)
+	}
+	if result := tools.Execute(ctx, "", call); result["error"] == nil {
+		t.Fatalf("anonymous caller must not see the order: %+v", result)
+	}
+
+	if defs := tools.Definitions(false); len(defs) != 1 || defs[0].Name != ToolCheckProductStock {
+		t.Fatalf("anonymous tools: got %+v", defs)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"tokobiru/models"
+	"tokobiru/repositories"
+	"unicode"
+)
+
+// EmbeddingDimensions adalah ukuran vektor embedding lokal
+const EmbeddingDimensions = 256
+
+// EmbedText membuat embedding lokal tanpa layanan eksternal memakai feature
+// hashing atas kata dan trigram karakter. Trigram membuat ejaan yang mirip
+// (misalnya "kaos" dan "kaus") tetap berdekatan. Vektor dinormalisasi (L2).
+func EmbedText(text string) []float32 {
+	vector := make([]float32, EmbeddingDimensions)
+	for _, token := range tokenize(text) {
+		addFeature(vector, "w:"+token, 1)
+		padded := " " + token + " "
+		for i := 0; i+3 <= len(padded); i++ {
+			addFeature(vector, "t:"+padded[i:i+3], 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
+
+// EmbedProduct membuat embedding dari nama, kategori dan deskripsi produk
+func EmbedProduct(product models.Product) []float32 {
+	// Nama diulang agar bobotnya lebih besar daripada deskripsi
+	return EmbedText(strings.Join([]string{product.Name, product.Name, product.Category, product.Description}, " "))
+}
+
+// CosineSimilarity menghitung kemiripan dua vektor yang sudah dinormalisasi
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i] * b[i])
+	}
+	return dot
+}
+
+// ReindexProductEmbeddings menghitung ulang embedding untuk semua produk
+// dan mengembalikan jumlah produk yang diperbarui.
+func ReindexProductEmbeddings(ctx context.Context, products repositories.ProductRepository) (int, error) {
+	all, _, err := products.List(ctx, repositories.ProductFilter{})
+	if err != nil {
+		return 0, err
+	}
+	for _, product := range all {
+		if err := products.SetEmbedding(ctx, product.ID, EmbedProduct(product)); err != nil {
+			return 0, err
+		}
+	}
+	return len(all), nil
+}
+
+func addFeature(vector []float32, feature string, weight float32) {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	sum := h.Sum32()
+	// Bit tertinggi menentukan tanda agar tabrakan hash saling meniadakan
+	if sum&(1<<31) != 0 {
+		weight = -weight
+	}
+	vector[sum%EmbeddingDimensions] += weight
+}
+
+// tokenize memecah teks menjadi kata huruf kecil tanpa tanda baca
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidInvitation dikembalikan jika undangan tidak dikenal, kedaluwarsa atau sudah dipakai
+	ErrInvalidInvitation = errors.New("invalid, expired or already used invitation")
+	// ErrEmailRegistered dikembalikan jika email sudah dipakai oleh akun lain
+	ErrEmailRegistered = errors.New("email already registered")
+	// ErrAdminExists dikembalikan oleh BootstrapAdmin jika sudah ada admin
+	ErrAdminExists = errors.New("an admin account already exists")
+)
+
+// InvitationService mengelola pembuatan akun admin. Registrasi publik selalu
+// membuat customer; admin hanya bisa dibuat lewat undangan dari admin lain
+// atau BootstrapAdmin untuk admin pertama.
+type InvitationService struct {
+	users       repositories.UserRepository
+	invitations repositories.InvitationRepository
+	ttl         time.Duration
+}
+
+// NewInvitationService membuat instance baru dari InvitationService
+func NewInvitationService(users repositories.UserRepository, invitations repositories.InvitationRepository, ttl time.Duration) *InvitationService {
+	return &InvitationService{users: users, invitations: invitations, ttl: ttl}
+}
+
+// Invite membuat undangan admin sekali pakai. Token mentah hanya dikembalikan
+// di sini dan tidak bisa diambil lagi.
+func (s *InvitationService) Invite(ctx context.Context, email string, invitedBy primitive.ObjectID) (*models.Invitation, string, error) {
+	email = strings.TrimSpace(email)
+	exists, err := s.users.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, "", err
+	}
+	if exists {
+		return nil, "", ErrEmailRegistered
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	invitation := &models.Invitation{
+		Email:     email,
+		Role:      models.RoleAdmin,
+		TokenHash: hashOpaqueToken(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+	if err := s.invitations.Create(ctx, invitation); err != nil {
+		return nil, "", err
+	}
+	return invitation, token, nil
+}
+
+// Accept memakai undangan untuk membuat akun dengan email dan role dari undangan
+func (s *InvitationService) Accept(ctx context.Context, token, name, password string) (*models.User, error) {
+	invitation, err := s.invitations.FindByTokenHash(ctx, hashOpaqueToken(token))
+	if err == repositories.ErrNotFound {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if invitation.AcceptedAt != nil || !now.Before(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+
+	exists, err := s.users.ExistsByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailRegistered
+	}
+
+	accepted, err := s.invitations.MarkAccepted(ctx, invitation.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvalidInvitation
+	}
+	return s.createUser(ctx, name, invitation.Email, password, invitation.Role)
+}
+
+// BootstrapAdmin membuat admin pertama. Gagal dengan ErrAdminExists jika
+// sudah ada admin, sehingga tidak bisa dipakai untuk menambah admin baru.
+func (s *InvitationService) BootstrapAdmin(ctx context.Context, name, email, password string) (*models.User, error) {
+	count, err := s.users.CountByRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAdminExists
+	}
+
+	email = strings.TrimSpace(email)
+	exists, err := s.users.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailRegistered
+	}
+	return s.createUser(ctx, name, email, password, models.RoleAdmin)
+}
+
+func (s *InvitationService) createUser(ctx context.Context, name, email, password, role string) (*models.User, error) {
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user := &models.User{
+		ID:       primitive.NewObjectID(),
+		Name:     name,
+		Email:    email,
+		Password: hashedPassword,
+		Role:     role,
+		// Undangan dikirim ke email ini dan admin pertama dibuat oleh operator
+		EmailVerified: true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.users.Create(ctx, user); err == repositories.ErrDuplicate {
+		return nil, ErrEmailRegistered
+	} else if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return user, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInvitationExpires(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	service := NewInvitationService(store.Users, store.Invitations, -time.Minute)
+
+	_, token, err := service.Invite(context.Background(), "late@example.com", primitive.NewObjectID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Accept(context.Background(), token, "Late", "rahasia123"); err != ErrInvalidInvitation {
+		t.Fatalf("expired invitation: got %v, want ErrInvalidInvitation", err)
+	}
+}
+
+func TestBootstrapAdminOnlyOnce(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	service := NewInvitationService(store.Users, store.Invitations, time.Hour)
+	ctx := context.Background()
+
+	if _, err := service.BootstrapAdmin(ctx, "Admin", "admin@example.com", "rahasia123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.BootstrapAdmin(ctx, "Admin 2", "admin2@example.com", "rahasia123"); err != ErrAdminExists {
+		t.Fatalf("second bootstrap: got %v, want ErrAdminExists", err)
+	}
+}
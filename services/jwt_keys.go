@@ -0,0 +1,445 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"tokobiru/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritma JWT yang didukung untuk penandatanganan
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// keyCreatedHeader adalah header PEM yang menyimpan waktu pembuatan kunci
+const keyCreatedHeader = "Created"
+
+// reloadCooldown membatasi reload direktori kunci saat menerima kid yang belum dikenal
+const reloadCooldown = 10 * time.Second
+
+// KeyRingOptions mengatur lokasi, algoritma dan jadwal rotasi kunci JWT
+type KeyRingOptions struct {
+	Dir       string // Direktori berisi <kid>.pem (private) dan <kid>.pub.pem (hanya verifikasi)
+	Algorithm string // Algoritma untuk kunci yang dibuat otomatis: RS256 atau EdDSA
+	// RotateEvery adalah umur kunci penandatangan sebelum diganti; 0 menonaktifkan rotasi
+	RotateEvery time.Duration
+	// ActivationDelay adalah jeda antara kunci baru dipublikasikan di JWKS dan
+	// mulai dipakai untuk menandatangani, agar layanan lain sempat memuatnya
+	ActivationDelay time.Duration
+	// Retain adalah lama kunci lama tetap diterima setelah tidak lagi dipakai menandatangani
+	Retain time.Duration
+	// LegacySecret, jika diisi, membuat token HS256 lama (tanpa kid) tetap diterima
+	LegacySecret string
+}
+
+// KeyRingOptionsFromConfig membuat KeyRingOptions dari konfigurasi aplikasi
+func KeyRingOptionsFromConfig(cfg config.Config) KeyRingOptions {
+	return KeyRingOptions{
+		Dir:             cfg.JWTKeysDir,
+		Algorithm:       cfg.JWTAlgorithm,
+		RotateEvery:     cfg.JWTKeyRotation,
+		ActivationDelay: cfg.JWTKeyActivationDelay,
+		Retain:          cfg.JWTKeyRetention,
+		LegacySecret:    cfg.JWTSecretKey,
+	}
+}
+
+type jwtKey struct {
+	id        string
+	algorithm string
+	private   crypto.Signer // nil untuk kunci yang hanya dipakai verifikasi
+	public    crypto.PublicKey
+	createdAt time.Time
+}
+
+// KeyRing menyimpan kunci penandatangan dan verifikasi JWT yang dimuat dari
+// disk. Beberapa instance bisa berbagi direktori yang sama: kunci baru dari
+// instance lain dimuat saat reload berkala atau saat kid belum dikenal.
+type KeyRing struct {
+	opts KeyRingOptions
+
+	mu         sync.RWMutex
+	keys       map[string]*jwtKey
+	lastReload time.Time
+}
+
+// NewKeyRing memuat kunci dari opts.Dir dan membuat kunci pertama jika belum ada
+func NewKeyRing(opts KeyRingOptions) (*KeyRing, error) {
+	switch opts.Algorithm {
+	case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", opts.Algorithm)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	k := &KeyRing{opts: opts}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	if k.signingKey(time.Now()) == nil {
+		if _, err := k.generate(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Reload membaca ulang semua kunci dari direktori
+func (k *KeyRing) Reload() error {
+	entries, err := os.ReadDir(k.opts.Dir)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*jwtKey)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+		key, err := loadJWTKey(filepath.Join(k.opts.Dir, name))
+		if err != nil {
+			slog.Warn("skipping JWT key", "file", name, "error", err)
+			continue
+		}
+		keys[key.id] = key
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.lastReload = time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+// loadJWTKey membaca satu file PEM. Nama file tanpa .pem / .pub.pem menjadi kid.
+func loadJWTKey(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	name := filepath.Base(path)
+	key := &jwtKey{id: strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub")}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key cannot sign")
+		}
+		key.private = signer
+		key.public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.private = parsed
+		key.public = parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+
+	switch key.public.(type) {
+	case *rsa.PublicKey:
+		key.algorithm = JWTAlgorithmRS256
+	case ed25519.PublicKey:
+		key.algorithm = JWTAlgorithmEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.public)
+	}
+
+	// Kunci yang dibuat manual (misalnya dengan openssl) tidak punya header
+	// Created, sehingga waktu modifikasi file yang dipakai
+	if created, err := time.Parse(time.RFC3339, block.Headers[keyCreatedHeader]); err == nil {
+		key.createdAt = created
+	} else if info, err := os.Stat(path); err == nil {
+		key.createdAt = info.ModTime()
+	}
+	return key, nil
+}
+
+// privateKeys mengembalikan kunci penandatangan, dari yang terlama
+func (k *KeyRing) privateKeys() []*jwtKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	var keys []*jwtKey
+	for _, key := range k.keys {
+		if key.private != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].createdAt.Equal(keys[j].createdAt) {
+			return keys[i].id < keys[j].id
+		}
+		return keys[i].createdAt.Before(keys[j].createdAt)
+	})
+	return keys
+}
+
+// signingKey memilih kunci terbaru yang sudah melewati ActivationDelay. Jika
+// belum ada (misalnya saat pertama kali dijalankan), kunci terbaru dipakai.
+func (k *KeyRing) signingKey(now time.Time) *jwtKey {
+	keys := k.privateKeys()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].createdAt.Add(k.opts.ActivationDelay).After(now) {
+			return keys[i]
+		}
+	}
+	if len(keys) > 0 {
+		return keys[len(keys)-1]
+	}
+	return nil
+}
+
+// generate membuat kunci baru dan menyimpannya ke direktori secara atomik
+func (k *KeyRing) generate(now time.Time) (*jwtKey, error) {
+	var signer crypto.Signer
+	var err error
+	switch k.opts.Algorithm {
+	case JWTAlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := &jwtKey{
+		id:        now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		algorithm: k.opts.Algorithm,
+		private:   signer,
+		public:    signer.Public(),
+		createdAt: now.UTC().Truncate(time.Second),
+	}
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{keyCreatedHeader: key.createdAt.Format(time.RFC3339)},
+		Bytes:   der,
+	})
+
+	path := filepath.Join(k.opts.Dir, key.id+".pem")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	k.mu.Lock()
+	if k.keys == nil {
+		k.keys = make(map[string]*jwtKey)
+	}
+	k.keys[key.id] = key
+	k.mu.Unlock()
+	slog.Info("generated JWT signing key", "kid", key.id, "alg", key.algorithm)
+	return key, nil
+}
+
+// Rotate membuat kunci baru jika kunci terbaru sudah mendekati RotateEvery.
+// Kunci baru dibuat ActivationDelay lebih awal agar sudah ada di JWKS saat
+// mulai dipakai. Kunci yang sudah pensiun lebih dari Retain dihapus.
+func (k *KeyRing) Rotate(now time.Time) error {
+	if k.opts.RotateEvery <= 0 {
+		return nil
+	}
+	keys := k.privateKeys()
+	if len(keys) == 0 || !keys[len(keys)-1].createdAt.Add(k.opts.RotateEvery-k.opts.ActivationDelay).After(now) {
+		if _, err := k.generate(now); err != nil {
+			return err
+		}
+		keys = k.privateKeys()
+	}
+
+	// Kunci i pensiun saat kunci berikutnya aktif
+	for i := 0; i < len(keys)-1; i++ {
+		retiredAt := keys[i+1].createdAt.Add(k.opts.ActivationDelay)
+		if now.Sub(retiredAt) <= k.opts.Retain {
+			continue
+		}
+		path := filepath.Join(k.opts.Dir, keys[i].id+".pem")
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		k.mu.Lock()
+		delete(k.keys, keys[i].id)
+		k.mu.Unlock()
+		slog.Info("removed retired JWT signing key", "kid", keys[i].id)
+	}
+	return nil
+}
+
+// Run memuat ulang direktori dan menjalankan Rotate setiap interval sampai stop ditutup
+func (k *KeyRing) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				slog.Warn("could not reload JWT keys", "error", err)
+				continue
+			}
+			if err := k.Rotate(time.Now()); err != nil {
+				slog.Warn("could not rotate JWT keys", "error", err)
+			}
+		}
+	}
+}
+
+// Sign menandatangani claims dengan kunci aktif dan menambahkan header kid
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	key := k.signingKey(time.Now())
+	if key == nil {
+		return "", errors.New("no JWT signing key available")
+	}
+	var method jwt.SigningMethod = jwt.SigningMethodRS256
+	if key.algorithm == JWTAlgorithmEdDSA {
+		method = jwt.SigningMethodEdDSA
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// Parse memvalidasi token dengan kunci sesuai header kid
+func (k *KeyRing) Parse(tokenString string, claims jwt.Claims, options ...jwt.ParserOption) (*jwt.Token, error) {
+	methods := []string{JWTAlgorithmRS256, JWTAlgorithmEdDSA}
+	if k.opts.LegacySecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	options = append(options, jwt.WithValidMethods(methods))
+	return jwt.ParseWithClaims(tokenString, claims, k.keyFunc, options...)
+}
+
+func (k *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// Token lama sebelum migrasi ke kunci asimetris
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && k.opts.LegacySecret != "" {
+			return []byte(k.opts.LegacySecret), nil
+		}
+		return nil, errors.New("missing kid header")
+	}
+
+	key := k.lookup(kid)
+	if key == nil {
+		// Kunci mungkin baru dibuat oleh instance lain
+		k.mu.RLock()
+		stale := time.Since(k.lastReload) > reloadCooldown
+		k.mu.RUnlock()
+		if stale {
+			if err := k.Reload(); err != nil {
+				return nil, err
+			}
+			key = k.lookup(kid)
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+func (k *KeyRing) lookup(kid string) *jwtKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[kid]
+}
+
+// JWK adalah satu kunci publik dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet adalah isi /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan semua kunci publik yang masih diterima, termasuk kunci
+// baru yang belum aktif
+func (k *KeyRing) JWKS() JWKSet {
+	k.mu.RLock()
+	keys := make([]*jwtKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	k.mu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].id < keys[j].id })
+
+	set := JWKSet{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.algorithm}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
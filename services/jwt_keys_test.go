@@ -0,0 +1,110 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeyRingSignAndShareDirectory(t *testing.T) {
+	dir := t.TempDir()
+	opts := KeyRingOptions{Dir: dir, Algorithm: JWTAlgorithmRS256}
+	first, err := NewKeyRing(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := first.Sign(&JWTClaims{UserID: "u1", Role: "customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Method.Alg() != "RS256" || parsed.Header["kid"] == "" {
+		t.Fatalf("unexpected header %v", parsed.Header)
+	}
+
+	// Instance kedua memakai direktori yang sama dan tidak membuat kunci baru
+	second, err := NewKeyRing(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(token, second)
+	if err != nil || claims.UserID != "u1" {
+		t.Fatalf("validate on second instance: %v", err)
+	}
+	if n := len(second.JWKS().Keys); n != 1 {
+		t.Fatalf("expected 1 published key, got %d", n)
+	}
+
+	// Token HS256 lama ditolak jika LegacySecret tidak diisi
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &JWTClaims{UserID: "u1"}).SignedString([]byte("old"))
+	if _, err := ValidateToken(legacy, second); err == nil {
+		t.Fatal("legacy HS256 token accepted without LegacySecret")
+	}
+}
+
+func TestKeyRingRotateAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := NewKeyRing(KeyRingOptions{
+		Dir:             dir,
+		Algorithm:       JWTAlgorithmEdDSA,
+		RotateEvery:     time.Hour,
+		ActivationDelay: 10 * time.Minute,
+		Retain:          30 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	oldKey := keys.signingKey(start)
+
+	// Belum waktunya rotasi
+	if err := keys.Rotate(start.Add(30 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(keys.JWKS().Keys); n != 1 {
+		t.Fatalf("rotated too early: %d keys", n)
+	}
+
+	// Kunci baru langsung dipublikasikan tetapi baru aktif setelah ActivationDelay
+	rotatedAt := start.Add(2 * time.Hour)
+	if err := keys.Rotate(rotatedAt); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(keys.JWKS().Keys); n != 2 {
+		t.Fatalf("expected 2 published keys, got %d", n)
+	}
+	if got := keys.signingKey(rotatedAt.Add(5 * time.Minute)); got.id != oldKey.id {
+		t.Fatalf("new key active before activation delay")
+	}
+	newKey := keys.signingKey(rotatedAt.Add(11 * time.Minute))
+	if newKey.id == oldKey.id {
+		t.Fatalf("new key not active after activation delay")
+	}
+
+	// Kunci lama tetap ada selama Retain setelah pensiun, lalu dihapus
+	if err := keys.Rotate(rotatedAt.Add(20 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if keys.lookup(oldKey.id) == nil {
+		t.Fatal("old key pruned before retention elapsed")
+	}
+	if err := keys.Rotate(rotatedAt.Add(45 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if keys.lookup(oldKey.id) != nil {
+		t.Fatal("old key still present after retention")
+	}
+	if _, err := os.Stat(filepath.Join(dir, oldKey.id+".pem")); !os.IsNotExist(err) {
+		t.Fatalf("old key file not removed: %v", err)
+	}
+	if n := len(keys.JWKS().Keys); n != 1 {
+		t.Fatalf("expected 1 published key after prune, got %d", n)
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"tokobiru/models"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GeminiProvider adalah adapter LLMProvider untuk Google Gemini
+type GeminiProvider struct {
+	model *genai.GenerativeModel
+}
+
+// NewGeminiProvider membuat klien Gemini. Error dikembalikan (bukan log.Fatal)
+// agar caller bisa beralih ke provider lain.
+func NewGeminiProvider(ctx context.Context, apiKey, modelName string) (*GeminiProvider, error) {
+	if apiKey == "" {
+		return nil, errors.New("GEMINI_API_KEY environment variable not set")
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	return &GeminiProvider{model: client.GenerativeModel(modelName)}, nil
+}
+
+func (p *GeminiProvider) Name() string {
+	return "gemini"
+}
+
+func (p *GeminiProvider) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	session, next := p.startChat(req)
+	resp, err := session.SendMessage(ctx, next...)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghasilkan konten dari Gemini: %w", err)
+	}
+
+	var out LLMResponse
+	out.Text = collectGeminiParts(resp, &out)
+	return &out, nil
+}
+
+// GenerateStream memakai SendMessageStream dan memanggil onToken untuk setiap
+// potongan teks yang diterima dari Gemini.
+func (p *GeminiProvider) GenerateStream(ctx context.Context, req LLMRequest, onToken func(string) error) (*LLMResponse, error) {
+	session, next := p.startChat(req)
+	iter := session.SendMessageStream(ctx, next...)
+
+	var out LLMResponse
+	var replyText strings.Builder
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gagal menghasilkan konten dari Gemini: %w", err)
+		}
+		if chunk := collectGeminiParts(resp, &out); chunk != "" {
+			replyText.WriteString(chunk)
+			if err := onToken(chunk); err != nil {
+				return nil, err
+			}
+		}
+	}
+	out.Text = replyText.String()
+	return &out, nil
+}
+
+// startChat menyiapkan sesi chat Gemini berisi riwayat percakapan dan tool
+// exchange, lalu mengembalikan pesan yang harus dikirim berikutnya.
+func (p *GeminiProvider) startChat(req LLMRequest) (*genai.ChatSession, []genai.Part) {
+	// Salin model agar konfigurasi tool per request tidak bocor antar goroutine
+	model := *p.model
+	if len(req.Tools) > 0 {
+		model.Tools = []*genai.Tool{{FunctionDeclarations: geminiFunctions(req.Tools)}}
+	}
+
+	// Instruksi sistem dan ringkasan percakapan dikirim pada giliran pertama
+	preamble := req.SystemPrompt
+	if req.Summary != "" {
+		preamble += "\n\n" + req.Summary
+	}
+
+	session := model.StartChat()
+	for _, message := range req.History {
+		role := "user"
+		if message.Role == models.ChatRoleAssistant {
+			role = "model"
+		}
+		session.History = append(session.History, &genai.Content{Role: role, Parts: []genai.Part{genai.Text(message.Content)}})
+	}
+	if len(session.History) > 0 {
+		first := session.History[0]
+		if text, ok := first.Parts[0].(genai.Text); ok && first.Role == "user" {
+			first.Parts[0] = genai.Text(preamble + "\n\n" + string(text))
+			preamble = ""
+		}
+	}
+
+	prompt := req.Prompt
+	if preamble != "" {
+		prompt = preamble + "\n\n" + prompt
+	}
+	next := []genai.Part{genai.Text(prompt)}
+	for _, exchange := range req.Exchanges {
+		session.History = append(session.History, &genai.Content{Role: "user", Parts: next})
+
+		calls := make([]genai.Part, 0, len(exchange.Calls))
+		for _, call := range exchange.Calls {
+			args := make(map[string]any, len(call.Arguments))
+			for k, v := range call.Arguments {
+				args[k] = v
+			}
+			calls = append(calls, genai.FunctionCall{Name: call.Name, Args: args})
+		}
+		session.History = append(session.History, &genai.Content{Role: "model", Parts: calls})
+
+		next = make([]genai.Part, 0, len(exchange.Results))
+		for _, result := range exchange.Results {
+			next = append(next, genai.FunctionResponse{Name: result.Name, Response: toJSONMap(result.Result)})
+		}
+	}
+	return session, next
+}
+
+// collectGeminiParts menambahkan function call ke out dan mengembalikan teks
+// dari kandidat pertama.
+func collectGeminiParts(resp *genai.GenerateContentResponse, out *LLMResponse) string {
+	// Pada streaming, setiap potongan membawa total pemakaian sejauh ini
+	if resp.UsageMetadata != nil {
+		out.Usage = LLMUsage{
+			PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+			CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		}
+	}
+	var replyText strings.Builder
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			switch part := part.(type) {
+			case genai.Text:
+				replyText.WriteString(string(part))
+			case genai.FunctionCall:
+				out.ToolCalls = append(out.ToolCalls, ToolCall{Name: part.Name, Arguments: stringArguments(part.Args)})
+			}
+		}
+	}
+	return replyText.String()
+}
+
+func geminiFunctions(tools []ToolDefinition) []*genai.FunctionDeclaration {
+	declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		var schema *genai.Schema
+		if len(tool.Parameters) > 0 {
+			schema = &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{}}
+		}
+		for _, param := range tool.Parameters {
+			schema.Properties[param.Name] = &genai.Schema{Type: genai.TypeString, Description: param.Description}
+			if param.Required {
+				schema.Required = append(schema.Required, param.Name)
+			}
+		}
+		declarations = append(declarations, &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  schema,
+		})
+	}
+	return declarations
+}
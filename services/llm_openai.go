@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// OpenAIProvider adalah adapter LLMProvider untuk API yang kompatibel dengan
+// OpenAI Chat Completions, termasuk server lokal seperti Ollama atau llama.cpp.
+type OpenAIProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIProvider membuat instance baru dari OpenAIProvider
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: 60 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded object
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type openAIChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream,omitempty"`
+	// StreamOptions meminta chunk terakhir berisi pemakaian token
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIUsage adalah pemakaian token yang dilaporkan server
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// openAIStreamChunk adalah satu event pada mode stream
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage,omitempty"`
+}
+
+func (u *openAIUsage) toLLMUsage() LLMUsage {
+	if u == nil {
+		return LLMUsage{}
+	}
+	return LLMUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+func (p *OpenAIProvider) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	resp, err := p.send(ctx, p.chatRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chatResp openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("gagal membaca respons LLM: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return &LLMResponse{Usage: chatResp.Usage.toLLMUsage()}, nil
+	}
+
+	message := chatResp.Choices[0].Message
+	out := &LLMResponse{Text: message.Content, Usage: chatResp.Usage.toLLMUsage()}
+	out.ToolCalls, err = parseOpenAIToolCalls(message.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GenerateStream memakai mode "stream" Chat Completions (Server-Sent Events)
+// dan memanggil onToken untuk setiap potongan teks.
+func (p *OpenAIProvider) GenerateStream(ctx context.Context, req LLMRequest, onToken func(string) error) (*LLMResponse, error) {
+	resp, err := p.send(ctx, p.chatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var calls []openAIToolCall
+	var usage LLMUsage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("gagal membaca stream LLM: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.toLLMUsage()
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			text.WriteString(delta.Content)
+			if err := onToken(delta.Content); err != nil {
+				return nil, err
+			}
+		}
+		// Tool call dikirim bertahap: id dan nama di awal, argumen per potongan
+		for _, part := range delta.ToolCalls {
+			for len(calls) <= part.Index {
+				calls = append(calls, openAIToolCall{Type: "function"})
+			}
+			call := &calls[part.Index]
+			if part.ID != "" {
+				call.ID = part.ID
+			}
+			call.Function.Name += part.Function.Name
+			call.Function.Arguments += part.Function.Arguments
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("stream LLM terputus: %w", err)
+	}
+
+	out := &LLMResponse{Text: text.String(), Usage: usage}
+	out.ToolCalls, err = parseOpenAIToolCalls(calls)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// chatRequest menyusun payload Chat Completions dari LLMRequest
+func (p *OpenAIProvider) chatRequest(req LLMRequest, stream bool) openAIChatRequest {
+	chatReq := openAIChatRequest{
+		Model:    p.model,
+		Messages: []openAIMessage{{Role: "system", Content: req.SystemPrompt}},
+		Tools:    openAITools(req.Tools),
+		Stream:   stream,
+	}
+	if stream {
+		chatReq.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	if req.Summary != "" {
+		chatReq.Messages = append(chatReq.Messages, openAIMessage{Role: "system", Content: req.Summary})
+	}
+	for _, message := range req.History {
+		chatReq.Messages = append(chatReq.Messages, openAIMessage{Role: message.Role, Content: message.Content})
+	}
+	chatReq.Messages = append(chatReq.Messages, openAIMessage{Role: "user", Content: req.Prompt})
+	for _, exchange := range req.Exchanges {
+		assistant := openAIMessage{Role: "assistant"}
+		for _, call := range exchange.Calls {
+			toolCall := openAIToolCall{ID: call.ID, Type: "function"}
+			toolCall.Function.Name = call.Name
+			args, _ := json.Marshal(call.Arguments)
+			toolCall.Function.Arguments = string(args)
+			assistant.ToolCalls = append(assistant.ToolCalls, toolCall)
+		}
+		chatReq.Messages = append(chatReq.Messages, assistant)
+		for _, result := range exchange.Results {
+			content, _ := json.Marshal(result.Result)
+			chatReq.Messages = append(chatReq.Messages, openAIMessage{Role: "tool", ToolCallID: result.CallID, Content: string(content)})
+		}
+	}
+	return chatReq
+}
+
+// send mengirim request ke endpoint /chat/completions. Body respons hanya
+// dikembalikan jika status 200; caller wajib menutupnya.
+func (p *OpenAIProvider) send(ctx context.Context, chatReq openAIChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	client := p.httpClient
+	if chatReq.Stream {
+		// Timeout klien juga memotong stream yang sedang berjalan; pembatalan
+		// stream cukup lewat ctx (misalnya saat klien memutus koneksi).
+		client = &http.Client{Transport: p.httpClient.Transport}
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menghubungi LLM: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var chatResp openAIChatResponse
+		if json.NewDecoder(resp.Body).Decode(&chatResp) == nil && chatResp.Error != nil {
+			return nil, fmt.Errorf("LLM mengembalikan status %d: %s", resp.StatusCode, chatResp.Error.Message)
+		}
+		return nil, fmt.Errorf("LLM mengembalikan status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func parseOpenAIToolCalls(calls []openAIToolCall) ([]ToolCall, error) {
+	var out []ToolCall
+	for _, call := range calls {
+		var args map[string]interface{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("argumen tool %s tidak valid: %w", call.Function.Name, err)
+			}
+		}
+		out = append(out, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: stringArguments(args)})
+	}
+	return out, nil
+}
+
+func openAITools(tools []ToolDefinition) []openAITool {
+	var out []openAITool
+	for _, tool := range tools {
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, param := range tool.Parameters {
+			properties[param.Name] = map[string]interface{}{"type": "string", "description": param.Description}
+			if param.Required {
+				required = append(required, param.Name)
+			}
+		}
+		out = append(out, openAITool{
+			Type: "function",
+			Function: openAIToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  map[string]interface{}{"type": "object", "properties": properties, "required": required},
+			},
+		})
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"tokobiru/config"
+	"tokobiru/models"
+)
+
+// ToolParameter adalah satu argumen (bertipe string) dari sebuah tool
+type ToolParameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// ToolDefinition mendeskripsikan tool sisi server yang boleh dipanggil oleh LLM
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  []ToolParameter
+}
+
+// ToolCall adalah permintaan LLM untuk menjalankan sebuah tool
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]string
+}
+
+// ToolResult adalah hasil eksekusi ToolCall yang dikirim kembali ke LLM
+type ToolResult struct {
+	CallID string
+	Name   string
+	Result map[string]interface{}
+}
+
+// ToolExchange adalah satu putaran pemanggilan tool beserta hasilnya
+type ToolExchange struct {
+	Calls   []ToolCall
+	Results []ToolResult
+}
+
+// LLMRequest adalah input untuk LLMProvider
+type LLMRequest struct {
+	SystemPrompt string
+	Prompt       string // Prompt lengkap termasuk konteks produk
+	Question     string // Pertanyaan asli pelanggan
+	Products     []models.Product
+	History      []models.ChatMessage // Giliran sebelumnya yang muat dalam budget token
+	Summary      string               // Ringkasan giliran lama yang tidak ikut dikirim
+	Tools        []ToolDefinition
+	Exchanges    []ToolExchange // Riwayat pemanggilan tool pada request ini
+}
+
+// LLMResponse adalah jawaban LLM: teks final atau permintaan pemanggilan tool
+type LLMResponse struct {
+	Text      string
+	ToolCalls []ToolCall
+	Usage     LLMUsage
+}
+
+// LLMUsage adalah jumlah token yang dilaporkan provider (nol jika tidak tersedia)
+type LLMUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// LLMProvider adalah abstraksi model bahasa yang dipakai oleh chatbot
+type LLMProvider interface {
+	// Name mengembalikan nama provider, misalnya "gemini"
+	Name() string
+	Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error)
+}
+
+// StreamingProvider adalah LLMProvider yang bisa mengirim jawaban secara
+// bertahap. onToken dipanggil untuk setiap potongan teks; jika onToken
+// mengembalikan error, streaming dihentikan dan error tersebut dikembalikan.
+type StreamingProvider interface {
+	LLMProvider
+	GenerateStream(ctx context.Context, req LLMRequest, onToken func(string) error) (*LLMResponse, error)
+}
+
+// LLMStatus menggambarkan provider chatbot yang sedang dipakai, untuk endpoint readiness
+type LLMStatus struct {
+	Provider string `json:"provider"`
+	// Fallback bernilai true jika provider yang dikonfigurasi gagal dibuat
+	// dan chatbot menjawab dengan rule-based
+	Fallback bool   `json:"fallback"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewLLMProvider memilih provider berdasarkan konfigurasi
+func NewLLMProvider(ctx context.Context, cfg config.Config) (LLMProvider, error) {
+	provider := strings.ToLower(cfg.LLMProvider)
+	if provider == "" {
+		provider = "rule"
+		if cfg.GeminiAPIKey != "" {
+			provider = "gemini"
+		}
+	}
+
+	switch provider {
+	case "gemini":
+		return NewGeminiProvider(ctx, cfg.GeminiAPIKey, cfg.GeminiModel)
+	case "openai":
+		return NewOpenAIProvider(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel), nil
+	case "rule":
+		return NewRuleBasedProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.LLMProvider)
+	}
+}
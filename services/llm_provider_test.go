@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tokobiru/config"
+	"tokobiru/models"
+)
+
+func TestNewLLMProviderSelection(t *testing.T) {
+	ctx := context.Background()
+
+	provider, err := NewLLMProvider(ctx, config.Config{})
+	if err != nil || provider.Name() != "rule" {
+		t.Fatalf("no key: got %v, %v; want rule provider", provider, err)
+	}
+
+	if _, err := NewLLMProvider(ctx, config.Config{LLMProvider: "gemini"}); err == nil {
+		t.Fatal("gemini without key: expected error")
+	}
+
+	provider, err = NewLLMProvider(ctx, config.Config{LLMProvider: "openai", OpenAIBaseURL: "http://localhost:11434/v1"})
+	if err != nil || provider.Name() != "openai" {
+		t.Fatalf("openai: got %v, %v", provider, err)
+	}
+
+	if _, err := NewLLMProvider(ctx, config.Config{LLMProvider: "skynet"}); err == nil {
+		t.Fatal("unknown provider: expected error")
+	}
+}
+
+func TestRuleBasedProvider(t *testing.T) {
+	products := []models.Product{
+		{Name: "Kaos Polos Biru Dongker", Price: models.IDR(85000), Stock: 100, Category: "Pakaian"},
+		{Name: "Celana Jeans Slim Fit", Price: models.IDR(250000), Stock: 0, Category: "Celana"},
+	}
+	provider := NewRuleBasedProvider()
+
+	resp, err := provider.Generate(context.Background(), LLMRequest{Question: "Berapa harga jeans?", Products: products})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply := resp.Text; !strings.Contains(reply, "Celana Jeans Slim Fit: Rp 250.000 (stok habis)") || strings.Contains(reply, "Kaos") {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+
+	resp, _ = provider.Generate(context.Background(), LLMRequest{Question: "Halo", Products: products})
+	if !strings.HasPrefix(resp.Text, "Halo!") {
+		t.Fatalf("greeting: unexpected reply %q", resp.Text)
+	}
+
+	again, _ := provider.Generate(context.Background(), LLMRequest{Question: "Halo", Products: products})
+	if again.Text != resp.Text {
+		t.Fatal("rule-based provider must be deterministic")
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Model != "llama3.2" || len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Errorf("unexpected payload %+v", req)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Stok masih ada."}}],"usage":{"prompt_tokens":42,"completion_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1/", "sk-test", "llama3.2")
+	resp, err := provider.Generate(context.Background(), LLMRequest{SystemPrompt: "sys", Prompt: "Ada stok?"})
+	if err != nil || resp.Text != "Stok masih ada." {
+		t.Fatalf("got %+v, %v", resp, err)
+	}
+	if resp.Usage != (LLMUsage{PromptTokens: 42, CompletionTokens: 5}) {
+		t.Fatalf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIProviderToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != ToolGetMyOrders {
+			t.Errorf("tools not sent: %+v", req.Tools)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"get_my_orders","arguments":"{\"order_id\":\"TB-1\"}"}}]}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL+"/v1", "", "llama3.2")
+	resp, err := provider.Generate(context.Background(), LLMRequest{
+		Prompt: "Status pesanan TB-1?",
+		Tools:  []ToolDefinition{{Name: ToolGetMyOrders, Parameters: []ToolParameter{{Name: "order_id"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "c1" || resp.ToolCalls[0].Arguments["order_id"] != "TB-1" {
+		t.Fatalf("unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestOpenAIProviderStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("stream flag or usage option not set")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Stok \"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"masih ada.\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"c1\",\"function\":{\"name\":\"check_product_stock\",\"arguments\":\"{\\\"prod\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"uct\\\":\\\"kaos\\\"}\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":30,\"completion_tokens\":8}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(server.URL, "", "llama3.2")
+	var tokens []string
+	resp, err := provider.GenerateStream(context.Background(), LLMRequest{Prompt: "Ada stok?"}, func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || resp.Text != "Stok masih ada." {
+		t.Fatalf("unexpected tokens %q / text %q", tokens, resp.Text)
+	}
+	if resp.Usage.PromptTokens != 30 || resp.Usage.CompletionTokens != 8 {
+		t.Fatalf("unexpected usage %+v", resp.Usage)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != ToolCheckProductStock || resp.ToolCalls[0].Arguments["product"] != "kaos" {
+		t.Fatalf("unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
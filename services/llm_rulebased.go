@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"tokobiru/models"
+)
+
+// RuleBasedProvider adalah LLMProvider offline yang deterministik. Dipakai saat
+// tidak ada LLM yang dikonfigurasi atau ketika provider utama gagal.
+type RuleBasedProvider struct{}
+
+// NewRuleBasedProvider membuat instance baru dari RuleBasedProvider
+func NewRuleBasedProvider() *RuleBasedProvider {
+	return &RuleBasedProvider{}
+}
+
+func (p *RuleBasedProvider) Name() string {
+	return "rule"
+}
+
+var greetingWords = []string{"halo", "hai", "hi", "hello", "selamat pagi", "selamat siang", "selamat sore", "selamat malam", "assalamualaikum"}
+
+var (
+	orderWords = []string{"pesanan", "order", "tb-", "kiriman", "paket"}
+	cartWords  = []string{"keranjang", "cart"}
+	orderIDRe  = regexp.MustCompile(`(?i)TB-\d+`)
+)
+
+func (p *RuleBasedProvider) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	question := strings.ToLower(req.Question)
+
+	// Putaran kedua: jawab berdasarkan hasil tool
+	if len(req.Exchanges) > 0 {
+		return &LLMResponse{Text: describeToolResults(req.Exchanges[len(req.Exchanges)-1].Results)}, nil
+	}
+
+	// Putaran pertama: minta tool yang sesuai dengan maksud pertanyaan
+	if containsAny(question, orderWords) {
+		if !hasTool(req.Tools, ToolGetMyOrders) {
+			return &LLMResponse{Text: "Silakan login terlebih dahulu agar saya bisa mengecek pesanan Anda."}, nil
+		}
+		args := map[string]string{}
+		if orderID := orderIDRe.FindString(req.Question); orderID != "" {
+			args["order_id"] = strings.ToUpper(orderID)
+		}
+		return &LLMResponse{ToolCalls: []ToolCall{{Name: ToolGetMyOrders, Arguments: args}}}, nil
+	}
+	if containsAny(question, cartWords) {
+		if !hasTool(req.Tools, ToolGetMyCart) {
+			return &LLMResponse{Text: "Silakan login terlebih dahulu agar saya bisa melihat keranjang Anda."}, nil
+		}
+		return &LLMResponse{ToolCalls: []ToolCall{{Name: ToolGetMyCart, Arguments: map[string]string{}}}}, nil
+	}
+
+	products := append([]models.Product(nil), req.Products...)
+	sort.Slice(products, func(i, j int) bool { return products[i].Name < products[j].Name })
+
+	var matched []models.Product
+	for _, product := range products {
+		if productMatches(question, product) {
+			matched = append(matched, product)
+		}
+	}
+	// Pertanyaan lanjutan seperti "stoknya masih ada?" merujuk produk dari giliran sebelumnya
+	if previous := lastUserMessage(req.History); len(matched) == 0 && previous != "" {
+		followUp := strings.ToLower(previous) + " " + question
+		for _, product := range products {
+			if productMatches(followUp, product) {
+				matched = append(matched, product)
+			}
+		}
+	}
+
+	var reply strings.Builder
+	switch {
+	case len(matched) > 0:
+		reply.WriteString("Berikut produk yang sesuai dengan pertanyaan Anda:\n")
+		for _, product := range matched {
+			reply.WriteString(describeProduct(product))
+		}
+	case containsAny(question, greetingWords):
+		reply.WriteString("Halo! Selamat datang di Toko Biru. Ada yang bisa saya bantu? Anda bisa menanyakan harga atau stok produk kami.")
+	case len(products) > 0:
+		reply.WriteString("Maaf, saya belum menemukan produk yang cocok. Beberapa produk yang tersedia di Toko Biru:\n")
+		for i, product := range products {
+			if i == 5 {
+				break
+			}
+			reply.WriteString(describeProduct(product))
+		}
+	default:
+		reply.WriteString("Maaf, saya tidak bisa memberikan jawaban saat ini.")
+	}
+	return &LLMResponse{Text: strings.TrimSpace(reply.String())}, nil
+}
+
+// GenerateStream mengirim jawaban rule-based kata demi kata
+func (p *RuleBasedProvider) GenerateStream(ctx context.Context, req LLMRequest, onToken func(string) error) (*LLMResponse, error) {
+	resp, err := p.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range strings.SplitAfter(resp.Text, " ") {
+		if chunk == "" {
+			continue
+		}
+		if err := onToken(chunk); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func hasTool(tools []ToolDefinition, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// describeToolResults mengubah hasil tool menjadi jawaban yang mudah dibaca
+func describeToolResults(results []ToolResult) string {
+	var reply strings.Builder
+	for _, result := range results {
+		if message, ok := result.Result["error"].(string); ok {
+			reply.WriteString("Maaf, " + strings.ToLower(message[:1]) + message[1:] + "\n")
+			continue
+		}
+		switch data := result.Result; {
+		case data["orders"] != nil:
+			orders, _ := data["orders"].([]ToolOrder)
+			if len(orders) == 0 {
+				reply.WriteString("Anda belum memiliki pesanan.\n")
+			}
+			for _, order := range orders {
+				reply.WriteString(fmt.Sprintf("- Pesanan %s berstatus \"%s\" dengan total %s\n", order.OrderID, order.Status, order.Total))
+			}
+		case data["items"] != nil:
+			items, _ := data["items"].([]ToolCartItem)
+			if len(items) == 0 {
+				reply.WriteString("Keranjang Anda masih kosong.\n")
+				continue
+			}
+			reply.WriteString("Isi keranjang Anda:\n")
+			for _, item := range items {
+				reply.WriteString(fmt.Sprintf("- %s x%d (%s)\n", item.Name, item.Quantity, item.Price))
+			}
+			total, _ := data["total"].(string)
+			reply.WriteString("Total: " + total + "\n")
+		case data["products"] != nil:
+			products, _ := data["products"].([]ToolStock)
+			for _, product := range products {
+				reply.WriteString(describeStock(product.Name, product.Price, product.Stock))
+			}
+		}
+	}
+	return strings.TrimSpace(reply.String())
+}
+
+// productMatches memeriksa apakah kata dari nama atau kategori produk muncul di pertanyaan
+func productMatches(question string, product models.Product) bool {
+	if product.Category != "" && strings.Contains(question, strings.ToLower(product.Category)) {
+		return true
+	}
+	for _, word := range strings.Fields(strings.ToLower(product.Name)) {
+		// Abaikan kata pendek dan warna yang terlalu umum
+		if len(word) < 4 || word == "biru" {
+			continue
+		}
+		if strings.Contains(question, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeProduct(product models.Product) string {
+	return describeStock(product.Name, product.Price.String(), product.Stock)
+}
+
+func describeStock(name, price string, stock int) string {
+	availability := "stok habis"
+	if stock > 0 {
+		availability = fmt.Sprintf("stok %d", stock)
+	}
+	return fmt.Sprintf("- %s: %s (%s)\n", name, price, availability)
+}
+
+func containsAny(text string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
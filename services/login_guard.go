@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"tokobiru/config"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginPolicy mengatur batas login gagal. Nilai Max* <= 0 menonaktifkan
+// penguncian untuk kunci tersebut.
+type LoginPolicy struct {
+	MaxFailures   int
+	IPMaxFailures int
+	Window        time.Duration
+	Lockout       time.Duration
+	DelayBase     time.Duration
+	DelayMax      time.Duration
+}
+
+// LoginPolicyFromConfig membuat LoginPolicy dari konfigurasi aplikasi
+func LoginPolicyFromConfig(cfg config.Config) LoginPolicy {
+	return LoginPolicy{
+		MaxFailures:   cfg.LoginMaxFailures,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		Window:        cfg.LoginFailureWindow,
+		Lockout:       cfg.LoginLockoutDuration,
+		DelayBase:     cfg.LoginDelayBase,
+		DelayMax:      cfg.LoginDelayMax,
+	}
+}
+
+// LoginGuard melindungi login dari brute-force dengan menghitung kegagalan per
+// email dan per IP, memberi jeda progresif, dan mengunci sementara.
+type LoginGuard struct {
+	attempts repositories.LoginAttemptRepository
+	audit    repositories.AuditRepository
+	policy   LoginPolicy
+}
+
+// NewLoginGuard membuat instance baru dari LoginGuard
+func NewLoginGuard(attempts repositories.LoginAttemptRepository, audit repositories.AuditRepository, policy LoginPolicy) *LoginGuard {
+	return &LoginGuard{attempts: attempts, audit: audit, policy: policy}
+}
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check mengembalikan sisa waktu kunci jika email atau IP sedang dikunci,
+// atau 0 jika login boleh dicoba
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	attempts, err := g.attempts.Find(ctx, emailAttemptKey(email), ipAttemptKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, attempt := range attempts {
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			if remaining := attempt.LockedUntil.Sub(now); remaining > retryAfter {
+				retryAfter = remaining
+			}
+		}
+	}
+	return retryAfter, nil
+}
+
+// Failure mencatat login gagal dan mengunci email atau IP yang melewati batas.
+// Mengembalikan jeda yang harus ditunggu sebelum membalas request.
+// userID kosong jika email tidak terdaftar.
+func (g *LoginGuard) Failure(ctx context.Context, email, ip string, userID *primitive.ObjectID) (time.Duration, error) {
+	now := time.Now()
+	emailAttempt, err := g.attempts.RecordFailure(ctx, emailAttemptKey(email), now, g.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	ipAttempt, err := g.attempts.RecordFailure(ctx, ipAttemptKey(ip), now, g.policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	if g.policy.MaxFailures > 0 && emailAttempt.Failures >= g.policy.MaxFailures {
+		if err := g.lock(ctx, emailAttempt.Key, now, &models.AuditLog{
+			Action:  models.AuditAccountLocked,
+			UserID:  userID,
+			Email:   email,
+			IP:      ip,
+			Details: fmt.Sprintf("%d failed login attempts", emailAttempt.Failures),
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if g.policy.IPMaxFailures > 0 && ipAttempt.Failures >= g.policy.IPMaxFailures {
+		if err := g.lock(ctx, ipAttempt.Key, now, &models.AuditLog{
+			Action:  models.AuditIPLocked,
+			IP:      ip,
+			Details: fmt.Sprintf("%d failed login attempts", ipAttempt.Failures),
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return g.delay(emailAttempt.Failures), nil
+}
+
+// Success menghapus penghitung kegagalan email setelah login berhasil.
+// Penghitung IP tetap berjalan agar tidak bisa di-reset dengan akun sendiri.
+func (g *LoginGuard) Success(ctx context.Context, email string) error {
+	return g.attempts.Reset(ctx, emailAttemptKey(email))
+}
+
+// Unlock membuka kunci akun sebelum waktunya dan mencatatnya di audit log
+func (g *LoginGuard) Unlock(ctx context.Context, user *models.User, actorID primitive.ObjectID) error {
+	if err := g.attempts.Reset(ctx, emailAttemptKey(user.Email)); err != nil {
+		return err
+	}
+	return g.audit.Create(ctx, &models.AuditLog{
+		Action:    models.AuditAccountUnlocked,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		ActorID:   &actorID,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (g *LoginGuard) lock(ctx context.Context, key string, now time.Time, entry *models.AuditLog) error {
+	until := now.Add(g.policy.Lockout)
+	if err := g.attempts.Lock(ctx, key, until); err != nil {
+		return err
+	}
+	entry.Details += fmt.Sprintf(", locked until %s", until.UTC().Format(time.RFC3339))
+	entry.CreatedAt = now
+	if err := g.audit.Create(ctx, entry); err != nil {
+		// Penguncian tetap berlaku walaupun audit log gagal ditulis
+		slog.ErrorContext(ctx, "could not write audit log", "action", entry.Action, "error", err)
+	}
+	return nil
+}
+
+// delay menghitung jeda progresif: DelayBase, 2x, 4x, ... sampai DelayMax
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if g.policy.DelayBase <= 0 || failures < 1 {
+		return 0
+	}
+	delay := g.policy.DelayBase
+	for i := 1; i < failures && delay < g.policy.DelayMax; i++ {
+		delay *= 2
+	}
+	if g.policy.DelayMax > 0 && delay > g.policy.DelayMax {
+		delay = g.policy.DelayMax
+	}
+	return delay
+}
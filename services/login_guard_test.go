@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"tokobiru/repositories"
+)
+
+func TestLoginGuardProgressiveDelay(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	guard := NewLoginGuard(store.LoginAttempts, store.Audit, LoginPolicy{
+		MaxFailures: 4,
+		Window:      time.Minute,
+		Lockout:     time.Minute,
+		DelayBase:   100 * time.Millisecond,
+		DelayMax:    300 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, expected := range want {
+		delay, err := guard.Failure(ctx, "Budi@Example.com", "10.0.0.1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if delay != expected {
+			t.Errorf("failure %d: got delay %v, want %v", i+1, delay, expected)
+		}
+	}
+	if retryAfter, _ := guard.Check(ctx, "budi@example.com", "10.0.0.2"); retryAfter != 0 {
+		t.Fatalf("locked before threshold: retry after %v", retryAfter)
+	}
+
+	// Kegagalan keempat mengunci email, tidak peduli huruf besar/kecil
+	guard.Failure(ctx, "budi@example.com", "10.0.0.1", nil)
+	if retryAfter, _ := guard.Check(ctx, "BUDI@example.com", "10.0.0.2"); retryAfter <= 0 || retryAfter > time.Minute {
+		t.Fatalf("expected lock of about a minute, got %v", retryAfter)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"tokobiru/config"
+)
+
+// Email adalah pesan teks sederhana yang dikirim ke satu penerima
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email transaksional (reset password, verifikasi email)
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// NewMailer memilih implementasi Mailer berdasarkan konfigurasi
+func NewMailer(cfg config.Config) (Mailer, error) {
+	switch strings.ToLower(cfg.MailDriver) {
+	case "", "log":
+		return NewLogMailer(cfg.MailFrom, cfg.MailDir), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
+	}
+}
+
+// buildMessage menyusun email dalam format RFC 5322
+func buildMessage(from string, email Email) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", email.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// SMTPMailer mengirim email lewat server SMTP. STARTTLS dipakai jika server
+// mendukungnya; autentikasi hanya dilakukan jika username diisi. Untuk
+// development bisa diarahkan ke MailHog (SMTP_HOST=localhost, SMTP_PORT=1025).
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer membuat instance baru dari SMTPMailer
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{host: host, port: port, username: username, password: password, from: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email Email) error {
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(sender.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(m.from, email)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// LogMailer tidak mengirim email, tetapi menuliskannya ke log. Jika dir diisi,
+// setiap email juga disimpan sebagai file .eml agar mudah dibuka saat development.
+type LogMailer struct {
+	from string
+	dir  string
+}
+
+// NewLogMailer membuat instance baru dari LogMailer
+func NewLogMailer(from, dir string) *LogMailer {
+	return &LogMailer{from: from, dir: dir}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (m *LogMailer) Send(ctx context.Context, email Email) error {
+	slog.InfoContext(ctx, "email logged instead of sent", "to", email.To, "subject", email.Subject, "body", email.Body)
+	if m.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(email.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, email), 0o644)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+)
+
+var (
+	// ErrInvalidMFACode dikembalikan jika kode TOTP atau recovery code salah atau sudah dipakai
+	ErrInvalidMFACode = errors.New("invalid verification code")
+	// ErrMFAAlreadyEnabled dikembalikan saat memulai enrollment untuk user yang sudah memakai 2FA
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrMFANotEnabled dikembalikan jika user belum mengaktifkan 2FA
+	ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrMFAEnrollmentNotStarted dikembalikan saat konfirmasi tanpa enrollment
+	ErrMFAEnrollmentNotStarted = errors.New("two-factor enrollment has not been started")
+	// ErrMFARequired dikembalikan saat menonaktifkan 2FA yang diwajibkan untuk role user
+	ErrMFARequired = errors.New("two-factor authentication is required for this role")
+)
+
+// recoveryCodeCount adalah jumlah recovery code yang dibuat setiap kali
+const recoveryCodeCount = 10
+
+// MFAEnrollment berisi secret TOTP baru yang harus didaftarkan ke aplikasi authenticator
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// MFAService mengelola two-factor authentication berbasis TOTP
+type MFAService struct {
+	users         repositories.UserRepository
+	issuer        string
+	requiredRoles map[string]bool
+}
+
+// NewMFAService membuat instance baru dari MFAService. requiredRoles adalah
+// role yang wajib memakai 2FA.
+func NewMFAService(users repositories.UserRepository, issuer string, requiredRoles []string) *MFAService {
+	required := make(map[string]bool, len(requiredRoles))
+	for _, role := range requiredRoles {
+		required[role] = true
+	}
+	return &MFAService{users: users, issuer: issuer, requiredRoles: required}
+}
+
+// RequiredForRole bernilai true jika role wajib memakai 2FA
+func (s *MFAService) RequiredForRole(role string) bool {
+	return s.requiredRoles[role]
+}
+
+// LoginRequiresMFA bernilai true jika login user harus melewati langkah kedua,
+// baik karena 2FA aktif maupun karena role-nya mewajibkan enrollment
+func (s *MFAService) LoginRequiresMFA(user *models.User) bool {
+	return user.MFAEnabled || s.RequiredForRole(user.Role)
+}
+
+// BeginEnrollment membuat secret baru yang belum aktif sampai dikonfirmasi
+// dengan ConfirmEnrollment
+func (s *MFAService) BeginEnrollment(ctx context.Context, user *models.User) (*MFAEnrollment, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.UpdateMFA(ctx, user.ID, false, &models.UserMFA{PendingSecret: secret}); err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{Secret: secret, OTPAuthURI: totpURI(s.issuer, user.Email, secret)}, nil
+}
+
+// ConfirmEnrollment mengaktifkan 2FA jika kode cocok dengan secret yang sedang
+// didaftarkan, lalu mengembalikan recovery code yang hanya ditampilkan sekali
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFA == nil || user.MFA.PendingSecret == "" {
+		return nil, ErrMFAEnrollmentNotStarted
+	}
+	step, ok := validateTOTP(user.MFA.PendingSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	mfa := &models.UserMFA{
+		Secret:        user.MFA.PendingSecret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+		EnabledAt:     &now,
+	}
+	if err := s.users.UpdateMFA(ctx, user.ID, true, mfa); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify menerima kode TOTP atau recovery code. Setiap kode hanya bisa dipakai sekali.
+func (s *MFAService) Verify(ctx context.Context, user *models.User, code string) error {
+	if !user.MFAEnabled || user.MFA == nil {
+		return ErrMFANotEnabled
+	}
+	if step, ok := validateTOTP(user.MFA.Secret, code, time.Now()); ok {
+		fresh, err := s.users.MarkTOTPUsed(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.users.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// Disable menonaktifkan 2FA setelah memverifikasi kode
+func (s *MFAService) Disable(ctx context.Context, user *models.User, code string) error {
+	if s.RequiredForRole(user.Role) {
+		return ErrMFARequired
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+	return s.users.UpdateMFA(ctx, user.ID, false, nil)
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code setelah memverifikasi kode
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if err := s.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	// Baca ulang agar LastUsedStep dari Verify tidak tertimpa
+	current, err := s.users.FindByID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	mfa := *current.MFA
+	mfa.RecoveryCodes = hashes
+	if err := s.users.UpdateMFA(ctx, user.ID, true, &mfa); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCodes membuat recovery code berformat xxxx-xxxx beserta hash-nya
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode menormalkan kode (huruf kecil, tanpa tanda hubung/spasi) lalu meng-hash-nya
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashOpaqueToken(normalized)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rrfK adalah konstanta Reciprocal Rank Fusion untuk menggabungkan peringkat
+const rrfK = 60
+
+// minEmbeddingSimilarity adalah ambang kemiripan minimum hasil embedding
+const minEmbeddingSimilarity = 0.12
+
+// stopWords adalah kata umum dalam pertanyaan pelanggan yang tidak membantu pencarian
+var stopWords = map[string]bool{
+	"apa": true, "apakah": true, "berapa": true, "harga": true, "ada": true, "yang": true,
+	"untuk": true, "saya": true, "aku": true, "ini": true, "itu": true, "dan": true, "di": true,
+	"ke": true, "dari": true, "stok": true, "masih": true, "dengan": true, "produk": true,
+	"mau": true, "ingin": true, "cari": true, "beli": true, "tolong": true, "kak": true,
+	"dong": true, "ya": true, "bisa": true, "punya": true, "jual": true, "tersedia": true,
+}
+
+// ProductRetriever memilih top-k produk yang relevan untuk sebuah pertanyaan,
+// sehingga prompt chatbot tidak perlu memuat seluruh katalog.
+type ProductRetriever struct {
+	products      repositories.ProductRepository
+	topK          int
+	useEmbeddings bool
+}
+
+// NewProductRetriever membuat instance baru dari ProductRetriever
+func NewProductRetriever(products repositories.ProductRepository, topK int, useEmbeddings bool) *ProductRetriever {
+	if topK <= 0 {
+		topK = 5
+	}
+	return &ProductRetriever{products: products, topK: topK, useEmbeddings: useEmbeddings}
+}
+
+// Retrieve menggabungkan hasil pencarian kata kunci dan (opsional) embedding
+// dengan Reciprocal Rank Fusion, lalu mengembalikan maksimal topK produk.
+func (r *ProductRetriever) Retrieve(ctx context.Context, question string) ([]models.Product, error) {
+	var keywords []string
+	for _, token := range tokenize(question) {
+		if !stopWords[token] {
+			keywords = append(keywords, token)
+		}
+	}
+	query := strings.Join(keywords, " ")
+	if query == "" {
+		return nil, nil
+	}
+
+	scores := make(map[primitive.ObjectID]float64)
+	byID := make(map[primitive.ObjectID]models.Product)
+
+	keywordResults, err := r.products.Search(ctx, query, int64(r.topK*2))
+	if err != nil {
+		return nil, err
+	}
+	for rank, product := range keywordResults {
+		scores[product.ID] += 1.0 / float64(rrfK+rank+1)
+		byID[product.ID] = product
+	}
+
+	if r.useEmbeddings {
+		embeddingIDs, err := r.searchEmbeddings(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		var missing []primitive.ObjectID
+		for rank, id := range embeddingIDs {
+			scores[id] += 1.0 / float64(rrfK+rank+1)
+			if _, ok := byID[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+		found, err := r.products.FindByIDs(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range found {
+			byID[product.ID] = product
+		}
+	}
+
+	var ranked []models.Product
+	for id := range scores {
+		if product, ok := byID[id]; ok {
+			ranked = append(ranked, product)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		si, sj := scores[ranked[i].ID], scores[ranked[j].ID]
+		if si != sj {
+			return si > sj
+		}
+		return ranked[i].ID.Hex() < ranked[j].ID.Hex()
+	})
+	if len(ranked) > r.topK {
+		ranked = ranked[:r.topK]
+	}
+	return ranked, nil
+}
+
+// searchEmbeddings mengembalikan ID produk yang diurutkan berdasarkan kemiripan embedding
+func (r *ProductRetriever) searchEmbeddings(ctx context.Context, query string) ([]primitive.ObjectID, error) {
+	embeddings, err := r.products.FindEmbeddings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queryVector := EmbedText(query)
+	type scored struct {
+		id         primitive.ObjectID
+		similarity float64
+	}
+	var results []scored
+	for _, embedding := range embeddings {
+		similarity := CosineSimilarity(queryVector, embedding.Embedding)
+		if similarity >= minEmbeddingSimilarity {
+			results = append(results, scored{embedding.ID, similarity})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].similarity > results[j].similarity })
+
+	var ids []primitive.ObjectID
+	for i, result := range results {
+		if i == r.topK*2 {
+			break
+		}
+		ids = append(ids, result.id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"tokobiru/models"
+	"tokobiru/repositories"
+)
+
+func seedRetrieverProducts(t *testing.T, products repositories.ProductRepository) map[string]models.Product {
+	t.Helper()
+	catalog := []models.Product{
+		{Name: "Kaos Polos Biru Dongker", Description: "Kaos katun combed 30s, nyaman dan adem.", Category: "Pakaian", Price: models.IDR(85000), Stock: 100},
+		{Name: "Kemeja Flanel Kotak-kotak", Description: "Kemeja flanel lengan panjang.", Category: "Pakaian", Price: models.IDR(175000), Stock: 50},
+		{Name: "Celana Jeans Slim Fit", Description: "Celana jeans dengan bahan stretch.", Category: "Celana", Price: models.IDR(250000), Stock: 75},
+		{Name: "Topi Baseball Biru", Description: "Topi baseball dengan logo Toko Biru.", Category: "Aksesoris", Price: models.IDR(60000), Stock: 200},
+	}
+	byName := make(map[string]models.Product)
+	for _, product := range catalog {
+		product.Embedding = EmbedProduct(product)
+		if err := products.Create(context.Background(), &product); err != nil {
+			t.Fatal(err)
+		}
+		byName[product.Name] = product
+	}
+	return byName
+}
+
+func TestProductRetrieverKeyword(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	byName := seedRetrieverProducts(t, store.Products)
+	retriever := NewProductRetriever(store.Products, 2, false)
+
+	products, err := retriever.Retrieve(context.Background(), "Berapa harga topi baseball?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) == 0 || products[0].ID != byName["Topi Baseball Biru"].ID {
+		t.Fatalf("expected topi first, got %+v", products)
+	}
+
+	products, _ = retriever.Retrieve(context.Background(), "biru")
+	if len(products) != 2 {
+		t.Fatalf("expected top-k limit of 2, got %d", len(products))
+	}
+
+	products, _ = retriever.Retrieve(context.Background(), "apa ada?")
+	if len(products) != 0 {
+		t.Fatalf("stop words only: expected no products, got %+v", products)
+	}
+}
+
+func TestProductRetrieverEmbeddings(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	byName := seedRetrieverProducts(t, store.Products)
+
+	// "kaus" tidak cocok secara kata kunci, tapi trigram-nya dekat dengan "kaos"
+	keywordOnly := NewProductRetriever(store.Products, 3, false)
+	if products, _ := keywordOnly.Retrieve(context.Background(), "kaus"); len(products) != 0 {
+		t.Fatalf("keyword only: expected no match, got %+v", products)
+	}
+
+	hybrid := NewProductRetriever(store.Products, 3, true)
+	products, err := hybrid.Retrieve(context.Background(), "kaus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) == 0 || products[0].ID != byName["Kaos Polos Biru Dongker"].ID {
+		t.Fatalf("hybrid: expected kaos first, got %+v", products)
+	}
+}
+
+func TestReindexProductEmbeddings(t *testing.T) {
+	store := repositories.NewMemoryStore()
+	product := models.Product{Name: "Sepatu Kanvas", Category: "Sepatu"}
+	store.Products.Create(context.Background(), &product)
+
+	count, err := ReindexProductEmbeddings(context.Background(), store.Products)
+	if err != nil || count != 1 {
+		t.Fatalf("got %d, %v", count, err)
+	}
+	embeddings, _ := store.Products.FindEmbeddings(context.Background())
+	if len(embeddings) != 1 || len(embeddings[0].Embedding) != EmbeddingDimensions {
+		t.Fatalf("unexpected embeddings %+v", embeddings)
+	}
+}
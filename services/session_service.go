@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"tokobiru/models"
+	"tokobiru/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidRefreshToken dikembalikan jika refresh token tidak dikenal, kedaluwarsa atau dicabut
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused dikembalikan jika refresh token yang sudah ditukar dipakai lagi.
+	// Seluruh keluarga session dicabut karena token kemungkinan bocor.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// TokenPair adalah pasangan access token dan refresh token yang dikirim ke klien
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"` // Masa berlaku access token dalam detik
+	Role         string `json:"role"`
+}
+
+// ClientInfo adalah metadata perangkat yang disimpan bersama session
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionService mengelola refresh token yang berotasi dan pencabutan token
+type SessionService struct {
+	users       repositories.UserRepository
+	sessions    repositories.SessionRepository
+	revocations repositories.RevocationRepository
+	keys        *KeyRing
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+// NewSessionService membuat instance baru dari SessionService
+func NewSessionService(users repositories.UserRepository, sessions repositories.SessionRepository, revocations repositories.RevocationRepository, keys *KeyRing, accessTTL, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		users:       users,
+		sessions:    sessions,
+		revocations: revocations,
+		keys:        keys,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// Login memulai keluarga session baru untuk user yang sudah terautentikasi
+func (s *SessionService) Login(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, error) {
+	return s.issue(ctx, user, primitive.NewObjectID().Hex(), client)
+}
+
+// Refresh menukar refresh token dengan pasangan token baru. Refresh token lama
+// tidak bisa dipakai lagi; jika dipakai lagi, seluruh keluarga session dicabut.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	session, err := s.sessions.FindByTokenHash(ctx, hashOpaqueToken(refreshToken))
+	if err == repositories.ErrNotFound {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated := false
+	if session.RotatedAt == nil {
+		if rotated, err = s.sessions.MarkRotated(ctx, session.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	if !rotated {
+		slog.WarnContext(ctx, "refresh token reuse detected, revoking session family", "user_id", session.UserID.Hex(), "session_family", session.FamilyID)
+		if err := s.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.users.FindByID(ctx, session.UserID)
+	if err == repositories.ErrNotFound {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, session.FamilyID, client)
+}
+
+// LogoutRefreshToken mencabut keluarga session pemilik refresh token
+func (s *SessionService) LogoutRefreshToken(ctx context.Context, refreshToken string) error {
+	session, err := s.sessions.FindByTokenHash(ctx, hashOpaqueToken(refreshToken))
+	if err == repositories.ErrNotFound {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	return s.RevokeFamily(ctx, session.FamilyID)
+}
+
+// RevokeFamily mencabut semua refresh token dalam keluarga session dan
+// memasukkan keluarga tersebut ke daftar pencabutan access token.
+func (s *SessionService) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	if err := s.sessions.RevokeFamily(ctx, familyID, now); err != nil {
+		return err
+	}
+	// Access token yang sudah terbit tetap berlaku paling lama accessTTL
+	return s.revocations.Revoke(ctx, familyID, now.Add(s.accessTTL))
+}
+
+// RevokeAccessToken mencabut satu access token (jti) sampai masa berlakunya habis
+func (s *SessionService) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return s.revocations.Revoke(ctx, tokenID, expiresAt)
+}
+
+func (s *SessionService) issue(ctx context.Context, user *models.User, familyID string, client ClientInfo) (*TokenPair, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashOpaqueToken(refreshToken),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := GenerateToken(s.keys, user.ID.Hex(), user.Role, familyID, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		Role:         user.Role,
+	}, nil
+}
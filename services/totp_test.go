@@ -0,0 +1,46 @@
+package services
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// Test vector SHA1 dari RFC 6238 Appendix B, dipotong menjadi 6 digit
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := GenerateTOTPCode(secret, time.Unix(unix, 0))
+		if err != nil || got != want {
+			t.Errorf("T=%d: got %q, %v; want %q", unix, got, err, want)
+		}
+	}
+}
+
+func TestValidateTOTPAllowsClockSkew(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	previous, _ := GenerateTOTPCode(secret, now.Add(-totpPeriod))
+	if _, ok := validateTOTP(secret, previous, now); !ok {
+		t.Error("code from the previous period should be accepted")
+	}
+	stale, _ := GenerateTOTPCode(secret, now.Add(-3*totpPeriod))
+	if _, ok := validateTOTP(secret, stale, now); ok {
+		t.Error("code from three periods ago should be rejected")
+	}
+
+	uri := totpURI("Tokobiru", "admin@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Tokobiru:admin@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected otpauth URI %q", uri)
+	}
+}
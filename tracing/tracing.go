@@ -0,0 +1,89 @@
+// Package tracing menyiapkan OpenTelemetry. Jika tracing tidak diaktifkan,
+// tracer provider global tetap no-op sehingga instrumentasi di Gin, MongoDB
+// dan provider LLM tidak menambah biaya apa pun.
+package tracing
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName dipakai sebagai service.name dan nama tracer
+const ServiceName = "tokobiru"
+
+// Options mengatur exporter OTLP/HTTP
+type Options struct {
+	Enabled bool
+	// EndpointURL adalah URL collector, misalnya http://localhost:4318.
+	// Skema http mengirim tanpa TLS.
+	EndpointURL string
+	SampleRatio float64 // 0..1, rasio trace yang disimpan
+}
+
+// Setup memasang tracer provider global dan propagator W3C. Fungsi yang
+// dikembalikan mengirim span yang tersisa dan harus dipanggil saat shutdown.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !opts.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.EndpointURL))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer mengembalikan tracer aplikasi dari provider global
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// CombineMonitors menggabungkan beberapa command monitor MongoDB, karena
+// driver hanya menerima satu monitor. Span MongoDB dibuat oleh
+// otelmongo.NewMonitor, metrik oleh metrics.MongoMonitor.
+func CombineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useRecorder memasang tracer provider in-memory selama test berjalan
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSetupDisabledIsNoop(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, span := Tracer().Start(context.Background(), "noop")
+	defer span.End()
+	if span.SpanContext().IsValid() {
+		t.Fatal("span should not be recorded when tracing is disabled")
+	}
+}
+
+// Monitor gabungan dari database.ConnectDB harus tetap meneruskan event ke
+// otelmongo sehingga span MongoDB menjadi anak dari span request
+func TestCombinedMongoMonitorCreatesChildSpans(t *testing.T) {
+	recorder := useRecorder(t)
+	monitor := CombineMonitors(&event.CommandMonitor{}, otelmongo.NewMonitor())
+
+	ctx, parent := Tracer().Start(context.Background(), "POST /api/v1/orders")
+	command, _ := bson.Marshal(bson.D{{Key: "find", Value: "products"}})
+	monitor.Started(ctx, &event.CommandStartedEvent{
+		Command:      command,
+		DatabaseName: "tokobiru",
+		CommandName:  "find",
+		RequestID:    1,
+	})
+	monitor.Started(ctx, &event.CommandStartedEvent{
+		Command:      command,
+		DatabaseName: "tokobiru",
+		CommandName:  "find",
+		RequestID:    2,
+	})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1}})
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2}, Failure: "timeout"})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	for _, span := range spans[:2] {
+		if span.Name() != "products.find" {
+			t.Errorf("span name: got %q", span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the request span", span.Name())
+		}
+		if span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("span kind: got %v", span.SpanKind())
+		}
+		found := false
+		for _, attr := range span.Attributes() {
+			if attr == semconv.DBMongoDBCollection("products") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing collection attribute: %v", span.Attributes())
+		}
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Error("successful command should not have error status")
+	}
+	if spans[1].Status().Code != codes.Error || spans[1].Status().Description != "timeout" {
+		t.Errorf("failed command status: got %+v", spans[1].Status())
+	}
+}
+
+func TestCombineMonitorsCallsEachMonitor(t *testing.T) {
+	var calls []string
+	record := func(name string) *event.CommandMonitor {
+		return &event.CommandMonitor{
+			Started: func(context.Context, *event.CommandStartedEvent) { calls = append(calls, name) },
+		}
+	}
+	combined := CombineMonitors(record("metrics"), &event.CommandMonitor{}, record("tracing"))
+	combined.Started(context.Background(), &event.CommandStartedEvent{})
+	combined.Succeeded(context.Background(), &event.CommandSucceededEvent{})
+
+	if len(calls) != 2 || calls[0] != "metrics" || calls[1] != "tracing" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}